@@ -3,6 +3,7 @@ package main
 
 import (
 	"flag"
+	"os"
 
 	alertmanager "alertmanagerWebhookAdapter/pkg/alertmanager"
 )
@@ -21,5 +22,10 @@ func init() {
 func main() {
 	flag.Parse()
 
+	// report 子命令：按需生成告警报表
+	if flag.Arg(0) == "report" {
+		os.Exit(alertmanager.Report(syslogProtocol, flag.Args()[1:]))
+	}
+
 	alertmanager.Run(syslogProtocol)
 }
@@ -0,0 +1,65 @@
+// Package ack 记录告警的认领（acknowledge）状态，key 为告警指纹。
+package ack
+
+import (
+	"sync"
+	"time"
+)
+
+// Info 告警的认领信息。
+type Info struct {
+	By string    // 认领人
+	At time.Time // 认领时间
+}
+
+// Listener 告警首次被认领时的回调。
+type Listener func(fingerprint string, info Info)
+
+var (
+	mu        sync.RWMutex
+	acked     = make(map[string]Info)
+	listeners []Listener
+)
+
+// OnAcknowledge 注册告警首次被认领时的回调，如取消告警升级。
+func OnAcknowledge(fn Listener) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Acknowledge 记录告警已被认领，重复认领时保留最早的记录。
+// 返回最终生效的认领信息，以及本次调用是否为首次认领。
+func Acknowledge(fingerprint, by string) (Info, bool) {
+	mu.Lock()
+	if info, ok := acked[fingerprint]; ok {
+		mu.Unlock()
+		return info, false
+	}
+	info := Info{By: by, At: time.Now()}
+	acked[fingerprint] = info
+	fns := append([]Listener(nil), listeners...)
+	mu.Unlock()
+
+	// 在锁外调用回调，避免回调中再次访问认领状态时死锁
+	for _, fn := range fns {
+		fn(fingerprint, info)
+	}
+	return info, true
+}
+
+// Get 返回告警的认领信息。
+func Get(fingerprint string) (Info, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	info, ok := acked[fingerprint]
+	return info, ok
+}
+
+// Clear 清除告警的认领记录，通常在告警恢复时调用。
+func Clear(fingerprint string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(acked, fingerprint)
+}
@@ -0,0 +1,92 @@
+package ack
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"time"
+)
+
+// token 认领接口的共享密钥，未配置时拒绝通过接口认领告警。
+var token string
+
+// Init 从 ACK_TOKEN 加载认领接口的共享密钥。认领请求需要在 Authorization 头中携带 Bearer <ACK_TOKEN>。
+func Init() {
+	token = os.Getenv("ACK_TOKEN")
+	if token == "" {
+		log.Println("⚠️ ACK_TOKEN is not set, acknowledging alerts via /api/ack is disabled")
+	}
+}
+
+// ackRequest 认领请求，也可以通过同名的 URL 参数传递。
+type ackRequest struct {
+	Fingerprint string `json:"fingerprint"`
+	By          string `json:"by"`
+}
+
+// ackResponse 认领接口的响应。
+type ackResponse struct {
+	Fingerprint  string    `json:"fingerprint"`
+	Acknowledged bool      `json:"acknowledged"`
+	By           string    `json:"by,omitempty"`
+	At           time.Time `json:"at,omitempty"`
+}
+
+// Handler 处理 /api/ack 请求。
+// GET 查询告警的认领状态；POST 认领告警，认领后会取消该告警后续的升级。
+// POST 请求需要携带 ACK_TOKEN，未配置 ACK_TOKEN 时拒绝认领。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	req := ackRequest{
+		Fingerprint: r.URL.Query().Get("fingerprint"),
+		By:          r.URL.Query().Get("by"),
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if token == "" {
+			http.Error(w, "ack API not enabled", http.StatusForbidden)
+			return
+		}
+		if !common.BearerAuthorized(r, token) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Fingerprint == "" {
+		http.Error(w, "fingerprint is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if req.By == "" {
+			req.By = "api"
+		}
+		if _, first := Acknowledge(req.Fingerprint, req.By); first {
+			log.Printf("✅ Alert %s acknowledged by %s via API", req.Fingerprint, req.By)
+		}
+	}
+
+	resp := ackResponse{Fingerprint: req.Fingerprint}
+	if info, ok := Get(req.Fingerprint); ok {
+		resp.Acknowledged, resp.By, resp.At = true, info.By, info.At
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package ack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string // 配置的 ACK_TOKEN
+		method string
+		auth   string
+		status int
+		acked  bool
+	}{
+		{"token not configured", "", http.MethodPost, "Bearer secret", http.StatusForbidden, false},
+		{"missing authorization", "secret", http.MethodPost, "", http.StatusUnauthorized, false},
+		{"wrong token", "secret", http.MethodPost, "Bearer wrong", http.StatusUnauthorized, false},
+		{"not bearer", "secret", http.MethodPost, "secret", http.StatusUnauthorized, false},
+		{"valid token", "secret", http.MethodPost, "Bearer secret", http.StatusOK, true},
+		{"query without token", "", http.MethodGet, "", http.StatusOK, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACK_TOKEN", tt.token)
+			Init()
+
+			fingerprint := fmt.Sprintf("auth-%d", i)
+			req := httptest.NewRequest(tt.method, "/api/ack?fingerprint="+fingerprint+"&by=alice", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			Handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			info, acked := Get(fingerprint)
+			if acked != tt.acked {
+				t.Errorf("acknowledged = %v, want %v", acked, tt.acked)
+			}
+			if acked && info.By != "alice" {
+				t.Errorf("acknowledged by %q, want alice", info.By)
+			}
+		})
+	}
+}
+
+func TestHandlerBody(t *testing.T) {
+	t.Setenv("ACK_TOKEN", "secret")
+	Init()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		by          string
+	}{
+		{"json", "application/json", `{"fingerprint": "body-0", "by": "bob"}`, http.StatusOK, "bob"},
+		{"json with charset", "application/json; charset=utf-8", `{"fingerprint": "body-1", "by": "carol"}`, http.StatusOK, "carol"},
+		{"json upper case", "Application/JSON", `{"fingerprint": "body-2"}`, http.StatusOK, "api"},
+		{"invalid json", "application/json; charset=utf-8", `{"fingerprint":`, http.StatusBadRequest, ""},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/ack", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			Handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.by == "" {
+				return
+			}
+			info, ok := Get(fmt.Sprintf("body-%d", i))
+			if !ok || info.By != tt.by {
+				t.Errorf("ack = %+v, %v, want by %s", info, ok, tt.by)
+			}
+		})
+	}
+}
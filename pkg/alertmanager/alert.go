@@ -2,30 +2,108 @@
 package alertmanager
 
 import (
+	"alertmanagerWebhookAdapter/pkg/ack"
 	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/dedup"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/dingtalk"
+	"alertmanagerWebhookAdapter/pkg/elasticsearch"
+	"alertmanagerWebhookAdapter/pkg/email"
+	"alertmanagerWebhookAdapter/pkg/escalation"
 	"alertmanagerWebhookAdapter/pkg/feishu"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/kafka"
+	"alertmanagerWebhookAdapter/pkg/metrics"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"alertmanagerWebhookAdapter/pkg/oncall"
+	"alertmanagerWebhookAdapter/pkg/opsgenie"
+	"alertmanagerWebhookAdapter/pkg/pagerduty"
+	"alertmanagerWebhookAdapter/pkg/pubsub"
+	"alertmanagerWebhookAdapter/pkg/quiet"
+	"alertmanagerWebhookAdapter/pkg/report"
+	"alertmanagerWebhookAdapter/pkg/slack"
+	"alertmanagerWebhookAdapter/pkg/snmptrap"
 	"alertmanagerWebhookAdapter/pkg/syslogtools"
+	"alertmanagerWebhookAdapter/pkg/teams"
+	"alertmanagerWebhookAdapter/pkg/telegram"
+	"alertmanagerWebhookAdapter/pkg/webhook"
+	"alertmanagerWebhookAdapter/pkg/wecom"
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+// shutdownTimeout 退出时等待进行中的请求、投递队列和 Elasticsearch 队列处理完成的最长时间。
+const shutdownTimeout = 30 * time.Second
+
 // Run 启动 Alertmanager webhook 适配器服务。
 func Run(syslogProtocol string) {
 	common.LoadWebhooks()
-	if len(common.FeishuWebhook) == 0 && len(common.SyslogWebhook) == 0 {
-		log.Fatal("❌ No FEISHU_WEBHOOK_xxx env vars found and no SYSLOG_WEBHOOK_xxx env vars found")
+	notify.Init()
+	delivery.Init()
+	history.Init()
+	dedup.Init()
+	flapping.Init()
+	email.Init()
+	kafka.Init()
+	pubsub.Init()
+	elasticsearch.Init()
+
+	// 告警通知渠道：路由、目标配置和已配置的目标数量
+	channels := []struct {
+		path    string
+		env     string
+		targets int
+		handler http.HandlerFunc
+	}{
+		{"/feishu", "FEISHU_WEBHOOK_xxx/FEISHU_CHAT_xxx", len(common.FeishuTargets), feishu.Handler},
+		{"/syslog", "SYSLOG_WEBHOOK_xxx", len(common.SyslogWebhook), syslogtools.Handler},
+		{"/dingtalk", "DINGTALK_WEBHOOK_xxx", len(common.DingtalkTargets), dingtalk.Handler},
+		{"/wecom", "WECOM_WEBHOOK_xxx", len(common.WecomWebhook), wecom.Handler},
+		{"/slack", "SLACK_WEBHOOK_xxx", len(common.SlackWebhook), slack.Handler},
+		{"/teams", "TEAMS_WEBHOOK_xxx", len(common.TeamsWebhook), teams.Handler},
+		{"/telegram", "TELEGRAM_CHAT_xxx", len(common.TelegramTargets), telegram.Handler},
+		{"/email", "EMAIL_TARGET_xxx", len(common.EmailTargets), email.Handler},
+		{"/kafka", "KAFKA_TARGET_xxx", len(common.KafkaTargets), kafka.Handler},
+		{"/nats", "NATS_TARGET_xxx", len(common.NATSTargets), pubsub.NATSHandler},
+		{"/mqtt", "MQTT_TARGET_xxx", len(common.MQTTTargets), pubsub.MQTTHandler},
+		{"/pagerduty", "PAGERDUTY_ROUTING_KEY_xxx", len(common.PagerDutyTargets), pagerduty.Handler},
+		{"/opsgenie", "OPSGENIE_API_KEY_xxx", len(common.OpsgenieTargets), opsgenie.Handler},
+		{"/snmp", "SNMP_TARGET_xxx", len(common.SNMPTargets), snmptrap.Handler},
+		{"/elasticsearch", "ELASTICSEARCH_TARGET_xxx", len(common.ElasticsearchTargets), elasticsearch.Handler},
+		{"/webhook", "WEBHOOK_TARGET_xxx", len(common.WebhookTargets), webhook.Handler},
 	}
-	if len(common.FeishuWebhook) == 0 {
-		log.Println("❌ No FEISHU_WEBHOOK_xxx env vars found")
+	configured := false
+	for _, ch := range channels {
+		if ch.targets == 0 {
+			log.Printf("❌ No %s env vars found", ch.env)
+		} else {
+			configured = true
+		}
+		http.HandleFunc(ch.path, delivery.Async(ch.handler))
 	}
-	http.HandleFunc("/feishu", feishu.Handler)
-
-	if len(common.SyslogWebhook) == 0 {
-		log.Println("❌ No SYSLOG_WEBHOOK_xxx env vars found")
+	if !configured {
+		log.Fatal("❌ No notification targets configured, at least one channel is required")
 	}
-	syslogtools.Protocol = syslogProtocol
-	http.HandleFunc("/syslog", syslogtools.Handler)
+	http.HandleFunc("/feishu/callback", feishu.CallbackHandler)
+
+	http.HandleFunc("/api/oncall", oncall.Handler(common.Oncall))
+	http.HandleFunc("/api/ack", ack.Handler)
+	http.HandleFunc("/api/history", history.Handler)
+	http.HandleFunc("/api/report", report.Handler)
+	http.HandleFunc("/metrics", metrics.Handler)
+
+	// 注册后台任务使用的通知渠道，并加载告警升级策略、静默规则和定时报表
+	registerChannels(syslogProtocol)
+	ack.Init()
+	escalation.Init()
+	quiet.Init()
+	report.Init()
 
 	log.Println("🚀 Multi-hook adapter is running on :8080")
 	srv := &http.Server{
@@ -35,5 +113,67 @@ func Run(syslogProtocol string) {
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
-	log.Fatal(srv.ListenAndServe())
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	log.Println("🛑 Shutting down, delivering queued alerts")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	shutdown(ctx, srv)
+}
+
+// shutdown 依次停止接收请求、发送投递队列中的告警、写入 Elasticsearch 队列中的文档，最后关闭告警历史存储。
+// 顺序不能调换：投递队列中的告警会写入 Elasticsearch 并记录历史。
+func shutdown(ctx context.Context, srv *http.Server) {
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ Failed to shut down HTTP server: %v", err)
+	}
+	if err := delivery.Drain(ctx); err != nil {
+		log.Printf("⚠️ Delivery queue not drained before shutdown: %v", err)
+	}
+	if err := elasticsearch.Drain(ctx); err != nil {
+		log.Printf("⚠️ Elasticsearch queue not flushed before shutdown: %v", err)
+	}
+	history.Close()
+	log.Println("👋 Adapter stopped")
+}
+
+// registerChannels 注册升级、报表等后台任务使用的通知渠道。
+func registerChannels(syslogProtocol string) {
+	syslogtools.Protocol = syslogProtocol
+	notify.Register("feishu", feishu.SendText)
+	notify.Register("syslog", syslogtools.SendText)
+	notify.Register("dingtalk", dingtalk.SendText)
+	notify.Register("wecom", wecom.SendText)
+	notify.Register("slack", slack.SendText)
+	notify.Register("teams", teams.SendText)
+	notify.Register("telegram", telegram.SendText)
+	notify.Register("email", email.SendText)
+	notify.Register("nats", pubsub.SendNATSText)
+	notify.Register("mqtt", pubsub.SendMQTTText)
+	notify.Register("pagerduty", pagerduty.SendText)
+	notify.Register("opsgenie", opsgenie.SendText)
+	notify.Register("snmp", snmptrap.SendText)
+	notify.Register("webhook", webhook.SendText)
+
+	// 事件类渠道按告警指纹触发和关闭事件，抖动恢复、静默改道和摘要按告警的当前状态发送
+	notify.RegisterAlert("pagerduty", pagerduty.SendAlert)
+	notify.RegisterAlert("opsgenie", opsgenie.SendAlert)
+}
+
+// Report 执行 report 子命令，按需生成告警报表，返回进程退出码。
+func Report(syslogProtocol string, args []string) int {
+	common.LoadWebhooks()
+	notify.Init()
+	email.Init()
+	pubsub.Init()
+	registerChannels(syslogProtocol)
+	return report.Command(args)
 }
@@ -0,0 +1,60 @@
+package alertmanager
+
+import (
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestShutdownDeliversQueuedAlerts 退出时发送投递队列中已确认但还没有发送的告警。
+func TestShutdownDeliversQueuedAlerts(t *testing.T) {
+	t.Setenv("DELIVERY_WORKERS", "1")
+	delivery.Init()
+
+	var delivered atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slack", delivery.Async(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond) // 模拟发送较慢的目标
+		delivered.Add(1)
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ln) }()
+
+	payload := `{"status":"firing","alerts":[{"status":"firing","labels":{"alertname":"HighCPU"}}]}`
+	for range 3 {
+		resp, err := http.Post("http://"+ln.Addr().String()+"/slack", "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+	}
+	if n := delivered.Load(); n == 3 {
+		t.Fatal("alerts were delivered before the webhook returned, queue not used")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown(ctx, srv)
+
+	if n := delivered.Load(); n != 3 {
+		t.Errorf("delivered %d alerts before shutdown returned, want 3", n)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Serve = %v, want ErrServerClosed", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package amapi 提供与 Alertmanager v2 API 交互的客户端功能。
+package amapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Client Alertmanager API 客户端。
+type Client struct {
+	URL      string        // Alertmanager 服务地址，如 http://alertmanager:9093
+	Username string        // Basic Auth 用户名（可选）
+	Password string        // Basic Auth 密码（可选）
+	Timeout  time.Duration // HTTP 请求超时时间
+}
+
+// Matcher 静默规则的标签匹配条件。
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// Silence 静默规则。
+type Silence struct {
+	Matchers  []Matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+// MatchersFromLabels 将告警标签转换为精确匹配的静默条件，按标签名排序。
+func MatchersFromLabels(labels map[string]string) []Matcher {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]Matcher, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, Matcher{Name: name, Value: labels[name], IsEqual: true})
+	}
+	return matchers
+}
+
+// CreateSilence 创建静默规则，返回 silenceID。
+func (c *Client) CreateSilence(silence Silence) (string, error) {
+	if c.URL == "" {
+		return "", fmt.Errorf("Alertmanager URL not configured")
+	}
+	if len(silence.Matchers) == 0 {
+		return "", fmt.Errorf("silence requires at least one matcher")
+	}
+
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal silence: %w", err)
+	}
+
+	apiURL := strings.TrimRight(c.URL, "/") + "/api/v2/silences"
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 添加 Basic Auth（如果配置了）
+	if c.Username != "" && c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := &http.Client{
+		Timeout: c.Timeout,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create silence: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			log.Printf("failed to close response body: %v", cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Alertmanager API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.SilenceID, nil
+}
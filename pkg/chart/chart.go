@@ -0,0 +1,342 @@
+// Package chart 提供纯 Go 实现的时间序列折线图渲染功能，输出 PNG 图片。
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Point 单个数据点。
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series 一条折线。
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart 折线图定义。
+type Chart struct {
+	Title    string
+	Width    int
+	Height   int
+	Series   []Series
+	Location *time.Location // 时间轴标签使用的时区，默认本地时区
+	Marker   time.Time      // 可选：在该时间点绘制竖线（如告警触发时间）
+}
+
+// 布局常量（像素）。
+const (
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 30
+	marginBottom = 30
+	legendLine   = 16
+	maxLegend    = 5
+	gridLines    = 5
+)
+
+var (
+	colorBackground = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	colorAxis       = color.RGBA{R: 96, G: 96, B: 96, A: 255}
+	colorGrid       = color.RGBA{R: 225, G: 225, B: 225, A: 255}
+	colorText       = color.RGBA{R: 48, G: 48, B: 48, A: 255}
+	colorMarker     = color.RGBA{R: 220, G: 50, B: 47, A: 255}
+
+	// palette 折线颜色。
+	palette = []color.RGBA{
+		{R: 38, G: 139, B: 210, A: 255},
+		{R: 133, G: 153, B: 0, A: 255},
+		{R: 203, G: 75, B: 22, A: 255},
+		{R: 108, G: 113, B: 196, A: 255},
+		{R: 42, G: 161, B: 152, A: 255},
+		{R: 181, G: 137, B: 0, A: 255},
+	}
+)
+
+// RenderPNG 渲染折线图并编码为 PNG。
+func (c *Chart) RenderPNG() ([]byte, error) {
+	img, err := c.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Render 渲染折线图。
+func (c *Chart) Render() (*image.RGBA, error) {
+	minT, maxT, minV, maxV, ok := c.bounds()
+	if !ok {
+		return nil, fmt.Errorf("chart has no data points")
+	}
+
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = 800
+	}
+	if height <= 0 {
+		height = 320
+	}
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: colorBackground}, image.Point{}, draw.Src)
+
+	legendRows := 0
+	if len(c.Series) > 1 {
+		legendRows = min(len(c.Series), maxLegend)
+	}
+	// image.Rect 会交换颠倒的坐标，需要先检查绘图区域的大小
+	plotWidth := width - marginLeft - marginRight
+	plotHeight := height - marginTop - marginBottom - legendRows*legendLine
+	if plotWidth <= 0 || plotHeight <= 0 {
+		return nil, fmt.Errorf("chart size %dx%d is too small", width, height)
+	}
+	plot := image.Rect(marginLeft, marginTop, marginLeft+plotWidth, marginTop+plotHeight)
+
+	// 纵轴留出 5% 的上下边距，值全部相同时扩展为 ±1
+	if maxV == minV {
+		minV, maxV = minV-1, maxV+1
+	} else {
+		pad := (maxV - minV) * 0.05
+		minV, maxV = minV-pad, maxV+pad
+	}
+	if maxT.Equal(minT) {
+		minT, maxT = minT.Add(-time.Minute), maxT.Add(time.Minute)
+	}
+
+	toX := func(t time.Time) int {
+		return plot.Min.X + int(float64(t.Sub(minT))/float64(maxT.Sub(minT))*float64(plot.Dx()))
+	}
+	toY := func(v float64) int {
+		return plot.Max.Y - int((v-minV)/(maxV-minV)*float64(plot.Dy()))
+	}
+
+	// 标题
+	drawText(img, marginLeft, marginTop-10, c.Title, colorText)
+
+	// 网格线与纵轴刻度
+	for i := 0; i <= gridLines; i++ {
+		v := minV + (maxV-minV)*float64(i)/gridLines
+		y := toY(v)
+		drawHLine(img, plot.Min.X, plot.Max.X, y, colorGrid)
+		label := formatTick(v)
+		drawText(img, plot.Min.X-6-textWidth(label), y+4, label, colorText)
+	}
+
+	// 横轴时间刻度：起点、中点、终点
+	layout := "15:04"
+	if maxT.Sub(minT) > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	for i := 0; i <= 2; i++ {
+		t := minT.Add(time.Duration(float64(maxT.Sub(minT)) * float64(i) / 2))
+		label := t.In(loc).Format(layout)
+		x := toX(t) - textWidth(label)/2
+		x = max(0, min(x, width-textWidth(label)))
+		drawText(img, x, plot.Max.Y+18, label, colorText)
+	}
+
+	// 坐标轴
+	drawHLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, colorAxis)
+	drawVLine(img, plot.Min.X, plot.Min.Y, plot.Max.Y, colorAxis)
+
+	// 告警触发时间标记（虚线）
+	if !c.Marker.IsZero() && !c.Marker.Before(minT) && !c.Marker.After(maxT) {
+		x := toX(c.Marker)
+		for y := plot.Min.Y; y < plot.Max.Y; y += 6 {
+			drawVLine(img, x, y, min(y+3, plot.Max.Y), colorMarker)
+		}
+	}
+
+	// 折线
+	for i, s := range c.Series {
+		col := palette[i%len(palette)]
+		for j := 1; j < len(s.Points); j++ {
+			p0, p1 := s.Points[j-1], s.Points[j]
+			if !isFinite(p0.Value) || !isFinite(p1.Value) {
+				continue
+			}
+			drawLine(img, toX(p0.Time), toY(p0.Value), toX(p1.Time), toY(p1.Value), col)
+		}
+		if len(s.Points) == 1 && isFinite(s.Points[0].Value) {
+			drawDot(img, toX(s.Points[0].Time), toY(s.Points[0].Value), col)
+		}
+	}
+
+	// 图例
+	for i := 0; i < legendRows; i++ {
+		y := plot.Max.Y + marginBottom + i*legendLine
+		col := palette[i%len(palette)]
+		drawHLine(img, marginLeft, marginLeft+20, y-4, col)
+		drawHLine(img, marginLeft, marginLeft+20, y-3, col)
+		drawText(img, marginLeft+26, y, c.Series[i].Name, colorText)
+	}
+
+	return img, nil
+}
+
+// bounds 计算所有数据点的时间和数值范围。
+func (c *Chart) bounds() (minT, maxT time.Time, minV, maxV float64, ok bool) {
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if !isFinite(p.Value) {
+				continue
+			}
+			if !ok {
+				minT, maxT, minV, maxV, ok = p.Time, p.Time, p.Value, p.Value, true
+				continue
+			}
+			if p.Time.Before(minT) {
+				minT = p.Time
+			}
+			if p.Time.After(maxT) {
+				maxT = p.Time
+			}
+			minV = math.Min(minV, p.Value)
+			maxV = math.Max(maxV, p.Value)
+		}
+	}
+	return minT, maxT, minV, maxV, ok
+}
+
+// isFinite 判断数值是否可绘制。
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// formatTick 格式化纵轴刻度值，较大的值使用 k/M/G 后缀。
+func formatTick(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return strconv.FormatFloat(v/1e9, 'f', 1, 64) + "G"
+	case abs >= 1e6:
+		return strconv.FormatFloat(v/1e6, 'f', 1, 64) + "M"
+	case abs >= 1e4:
+		return strconv.FormatFloat(v/1e3, 'f', 1, 64) + "k"
+	case abs >= 100:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case abs >= 1:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+}
+
+// drawText 使用内置点阵字体绘制文本，(x, y) 为基线起点。
+// 点阵字体只包含 ASCII 字符，绘制前按 asciiText 替换其他字符。
+func drawText(img draw.Image, x, y int, text string, col color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(asciiText(text))
+}
+
+// textWidth 返回文本绘制后的像素宽度。
+func textWidth(text string) int {
+	return font.MeasureString(basicfont.Face7x13, asciiText(text)).Ceil()
+}
+
+// asciiText 将点阵字体无法绘制的字符替换为 ASCII 字符：控制字符替换为空格，
+// 中文等非 ASCII 字符替换为 ?，避免绘制出无法辨认的占位符方块。
+// 告警名称和标签值在卡片正文中完整显示，图表中只需要能够对应上。
+func asciiText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r < ' ' || r == 0x7f:
+			return ' '
+		case r > '~':
+			return '?'
+		default:
+			return r
+		}
+	}, text)
+}
+
+// drawHLine 绘制水平线。
+func drawHLine(img *image.RGBA, x0, x1, y int, col color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, col)
+	}
+}
+
+// drawVLine 绘制垂直线。
+func drawVLine(img *image.RGBA, x, y0, y1 int, col color.Color) {
+	for y := y0; y <= y1; y++ {
+		img.Set(x, y, col)
+	}
+}
+
+// drawDot 绘制 3x3 的点。
+func drawDot(img *image.RGBA, x, y int, col color.Color) {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			img.Set(x+dx, y+dy, col)
+		}
+	}
+}
+
+// drawLine 使用 Bresenham 算法绘制宽度为 2 像素的线段。
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, col color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, col)
+		img.Set(x0, y0+1, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// abs 返回整数的绝对值。
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
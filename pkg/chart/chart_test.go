@@ -0,0 +1,168 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+
+// plotRect 返回没有图例时 width x height 图表的绘图区域。
+func plotRect(width, height int) image.Rectangle {
+	return image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+}
+
+func TestRenderNoData(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []Series
+	}{
+		{"no series", nil},
+		{"empty series", []Series{{Name: "cpu"}}},
+		{"only NaN and Inf", []Series{{Name: "cpu", Points: []Point{
+			{t0, math.NaN()}, {t0.Add(time.Minute), math.Inf(1)}, {t0.Add(2 * time.Minute), math.Inf(-1)},
+		}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Chart{Title: "cpu", Series: tt.series}
+			if _, err := c.Render(); err == nil {
+				t.Error("Render should fail without finite data points")
+			}
+			if _, err := c.RenderPNG(); err == nil {
+				t.Error("RenderPNG should fail without finite data points")
+			}
+		})
+	}
+}
+
+func TestRenderSinglePoint(t *testing.T) {
+	c := &Chart{Width: 400, Height: 200, Series: []Series{{Name: "cpu", Points: []Point{{t0, 42}}}}}
+	img, err := c.Render()
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	// 只有一个点时时间和数值范围向两侧扩展，点绘制在绘图区域中央
+	plot := plotRect(400, 200)
+	x, y := plot.Min.X+plot.Dx()/2, plot.Max.Y-plot.Dy()/2
+	if got := img.RGBAAt(x, y); got != palette[0] {
+		t.Errorf("pixel at (%d, %d) = %v, want series color %v", x, y, got, palette[0])
+	}
+}
+
+func TestRenderSkipsNonFinite(t *testing.T) {
+	c := &Chart{Width: 400, Height: 200, Series: []Series{{Name: "cpu", Points: []Point{
+		{t0, 0},
+		{t0.Add(time.Minute), math.NaN()},
+		{t0.Add(2 * time.Minute), math.Inf(1)},
+		{t0.Add(3 * time.Minute), 10},
+	}}}}
+	minT, maxT, minV, maxV, ok := c.bounds()
+	if !ok || !minT.Equal(t0) || !maxT.Equal(t0.Add(3*time.Minute)) || minV != 0 || maxV != 10 {
+		t.Errorf("bounds = %v %v %v %v %v, want NaN and Inf ignored", minT, maxT, minV, maxV, ok)
+	}
+	if _, err := c.Render(); err != nil {
+		t.Errorf("Render: %v", err)
+	}
+}
+
+func TestRenderMarker(t *testing.T) {
+	series := []Series{{Name: "cpu", Points: []Point{{t0, 0}, {t0.Add(10 * time.Minute), 10}}}}
+	plot := plotRect(400, 200)
+	x := plot.Min.X + plot.Dx()/2
+
+	tests := []struct {
+		name   string
+		marker time.Time
+		drawn  bool
+	}{
+		{"at StartsAt", t0.Add(5 * time.Minute), true},
+		{"no marker", time.Time{}, false},
+		{"before range", t0.Add(-time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Chart{Width: 400, Height: 200, Series: series, Marker: tt.marker}
+			img, err := c.Render()
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got := img.RGBAAt(x, plot.Min.Y) == colorMarker; got != tt.drawn {
+				t.Errorf("marker drawn at x=%d: %v, want %v", x, got, tt.drawn)
+			}
+		})
+	}
+}
+
+func TestRenderPNGSize(t *testing.T) {
+	series := []Series{{Name: "cpu", Points: []Point{{t0, 1}, {t0.Add(time.Minute), 2}}}}
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{"configured", 640, 240, 640, 240},
+		{"default", 0, 0, 800, 320},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Chart{Title: "cpu", Width: tt.width, Height: tt.height, Series: series}
+			data, err := c.RenderPNG()
+			if err != nil {
+				t.Fatalf("RenderPNG: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode png: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != tt.wantW || size.Y != tt.wantH {
+				t.Errorf("size = %v, want %dx%d", size, tt.wantW, tt.wantH)
+			}
+		})
+	}
+
+	c := &Chart{Width: 50, Height: 50, Series: series}
+	if _, err := c.RenderPNG(); err == nil {
+		t.Error("RenderPNG should fail when the chart is too small")
+	}
+}
+
+func TestASCIIText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{instance="node-1"}`, `{instance="node-1"}`},
+		{"CPU 使用率过高", "CPU ?????"},
+		{"line1\nline2\t", "line1 line2 "},
+	}
+	for _, tt := range tests {
+		if got := asciiText(tt.in); got != tt.want {
+			t.Errorf("asciiText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// 宽度按替换后的文本计算，与绘制结果一致
+	if got, want := textWidth("磁盘"), 2*7; got != want {
+		t.Errorf("textWidth = %d, want %d", got, want)
+	}
+}
+
+func TestRenderNonASCIILabels(t *testing.T) {
+	c := &Chart{
+		Title:  "磁盘使用率",
+		Width:  400,
+		Height: 240,
+		Series: []Series{
+			{Name: `{机房="北京"}`, Points: []Point{{t0, 1}, {t0.Add(time.Minute), 2}}},
+			{Name: `{机房="上海"}`, Points: []Point{{t0, 3}, {t0.Add(time.Minute), 4}}},
+		},
+	}
+	if _, err := c.RenderPNG(); err != nil {
+		t.Errorf("RenderPNG: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"alertmanagerWebhookAdapter/pkg/loki"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SelectTargets 根据请求中的 target 参数（多个用逗号分隔）选择发送目标，
+// 参数为空时返回所有已配置的目标。未配置的目标会被忽略并记录日志。
+func SelectTargets[T any](param string, all map[string]T) map[string]T {
+	if param == "" {
+		return all
+	}
+
+	selected := make(map[string]T)
+	for _, t := range strings.Split(param, ",") {
+		t = strings.TrimSpace(strings.ToLower(t))
+		if target, exists := all[t]; exists {
+			selected[t] = target
+		} else {
+			log.Printf("⚠️ Target '%s' not found in configuration", t)
+		}
+	}
+	return selected
+}
+
+// TriggerLogs 返回告警的触发日志：配置了 Loki 且告警带有 log_query 注解时查询实际日志，
+// 否则使用 trigger_logs 注解。failedFormat（包含一个 %v）和 emptyText 分别为查询失败和无匹配日志时的提示，
+// 仅在没有 trigger_logs 注解时使用。
+func TriggerLogs(alert Alert, failedFormat, emptyText string) string {
+	triggerLogs := alert.Annotations["trigger_logs"]
+	if !LokiConfig.Enabled || LokiClient == nil {
+		return triggerLogs
+	}
+	logQuery := alert.Annotations["log_query"]
+	if logQuery == "" {
+		return triggerLogs
+	}
+
+	alertName := alert.Labels["alertname"]
+	logs, err := LokiClient.QueryLogs(logQuery, LokiConfig.LogLimit, LokiConfig.QueryRange)
+	if err != nil {
+		log.Printf("⚠️ Failed to query Loki for alert %s: %v", alertName, err)
+		// 查询失败时保留原有的 trigger_logs 或添加错误提示
+		if triggerLogs == "" {
+			triggerLogs = fmt.Sprintf(failedFormat, err)
+		}
+		return triggerLogs
+	}
+	if len(logs) == 0 {
+		// 查询成功但没有日志
+		if triggerLogs == "" {
+			triggerLogs = emptyText
+		}
+		return triggerLogs
+	}
+
+	log.Printf("✅ Queried %d logs from Loki for alert %s", len(logs), alertName)
+	return loki.FormatLogs(logs, LokiConfig.LogLimit)
+}
+
+// MetricTrendText 返回告警对应指标的当前值和近期趋势描述，未启用 Prometheus、查询失败或超时时返回空字符串。
+func MetricTrendText(alert Alert) string {
+	trend, err := QueryMetricTrend(alert)
+	if err != nil {
+		log.Printf("⚠️ Failed to query Prometheus for alert %s: %v", alert.Labels["alertname"], err)
+		return ""
+	}
+	if trend == nil {
+		return ""
+	}
+	return trend.String()
+}
@@ -2,20 +2,70 @@
 package common
 
 import (
+	"alertmanagerWebhookAdapter/pkg/amapi"
 	"alertmanagerWebhookAdapter/pkg/loki"
+	"alertmanagerWebhookAdapter/pkg/prometheus"
+	"context"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// FeishuWebhook 存储所有可用的飞书 webhook 地址，key 为目标标识。
-var FeishuWebhook = make(map[string]string)
+// 飞书发送目标类型。
+const (
+	FeishuTargetWebhook = "webhook" // 自定义机器人 webhook
+	FeishuTargetApp     = "app"     // 自建应用机器人，通过消息 API 发送到群聊
+)
+
+// FeishuTarget 飞书发送目标。
+type FeishuTarget struct {
+	Type   string // 目标类型：webhook 或 app
+	URL    string // webhook 类型的机器人地址
+	ChatID string // app 类型的群聊 chat_id
+}
+
+// FeishuTargets 存储所有可用的飞书发送目标，key 为目标标识。
+var FeishuTargets = make(map[string]FeishuTarget)
 
 // SyslogWebhook 存储所有可用的 syslog webhook 地址，key 为目标标识。
 var SyslogWebhook = make(map[string]string)
 
+// SlackWebhook 存储所有可用的 Slack incoming webhook 地址，key 为目标标识。
+var SlackWebhook = make(map[string]string)
+
+// TeamsWebhook 存储所有可用的 Microsoft Teams webhook 地址（Workflows 或 incoming webhook），key 为目标标识。
+var TeamsWebhook = make(map[string]string)
+
+// FeishuMsgType 飞书消息类型：text（默认）或 card。
+var FeishuMsgType = "text"
+
+// FeishuApp 飞书自建应用配置，用于调用开放平台 API（如上传图片）。
+var FeishuApp struct {
+	AppID     string // 应用 App ID
+	AppSecret string // 应用 App Secret
+	BaseURL   string // 开放平台地址，默认 https://open.feishu.cn
+
+	MessageTTL time.Duration // 告警与消息对应关系的保留时间，用于恢复时更新原卡片
+
+	VerificationToken string // 卡片回调的 Verification Token，配置后启用卡片按钮回调
+	EncryptKey        string // 卡片回调的 Encrypt Key（可选），用于解密请求和校验签名
+}
+
+// AlertmanagerClient Alertmanager API 客户端实例（全局单例），用于创建静默规则。
+// 未配置 ALERTMANAGER_URL 时为 nil，此时飞书卡片不提供静默按钮。
+var AlertmanagerClient *amapi.Client
+
+// GraphConfig 告警指标图表配置。
+var GraphConfig struct {
+	Enabled bool          // 是否在飞书卡片中附带指标图表
+	Range   time.Duration // 图表的时间范围
+	Width   int           // 图片宽度（像素）
+	Height  int           // 图片高度（像素）
+}
+
 // LokiClient Loki API 客户端实例（全局单例）。
 var LokiClient *loki.Client
 
@@ -27,27 +77,178 @@ var LokiConfig struct {
 	QueryTimeout time.Duration // 查询超时时间
 }
 
+// PrometheusClient Prometheus API 客户端实例（全局单例）。
+var PrometheusClient *prometheus.Client
+
+// PrometheusConfig Prometheus 配置参数。
+var PrometheusConfig struct {
+	Enabled      bool          // 是否启用 Prometheus 指标查询功能
+	TrendRange   time.Duration // 趋势查询的时间范围
+	TrendStep    time.Duration // 趋势查询的步长
+	QueryTimeout time.Duration // 查询超时时间
+	// EnrichTimeout 单个告警补充指标信息（趋势、图表）的总时长，超时后跳过，不阻塞告警发送
+	EnrichTimeout time.Duration
+}
+
 // LoadWebhooks 从环境变量中加载所有的 Webhook 配置和 Loki 配置。
 func LoadWebhooks() {
 	for _, env := range os.Environ() {
 		if strings.HasPrefix(env, "FEISHU_WEBHOOK_") {
 			parts := strings.SplitN(env, "=", 2)
 			key := strings.ToLower(strings.TrimPrefix(parts[0], "FEISHU_WEBHOOK_"))
-			FeishuWebhook[key] = parts[1]
+			FeishuTargets[key] = FeishuTarget{Type: FeishuTargetWebhook, URL: parts[1]}
+			continue
+		}
+		if strings.HasPrefix(env, "FEISHU_CHAT_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "FEISHU_CHAT_"))
+			FeishuTargets[key] = FeishuTarget{Type: FeishuTargetApp, ChatID: parts[1]}
 			continue
 		}
 		if strings.HasPrefix(env, "SYSLOG_WEBHOOK_") {
 			parts := strings.SplitN(env, "=", 2)
 			key := strings.ToLower(strings.TrimPrefix(parts[0], "SYSLOG_WEBHOOK_"))
 			SyslogWebhook[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "DINGTALK_WEBHOOK_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "DINGTALK_WEBHOOK_"))
+			DingtalkTargets[key] = DingtalkTarget{URL: parts[1]}
+			continue
+		}
+		if strings.HasPrefix(env, "DINGTALK_SECRET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "DINGTALK_SECRET_"))
+			dingtalkSecrets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "WECOM_WEBHOOK_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "WECOM_WEBHOOK_"))
+			WecomWebhook[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "SLACK_WEBHOOK_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "SLACK_WEBHOOK_"))
+			SlackWebhook[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "TEAMS_WEBHOOK_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "TEAMS_WEBHOOK_"))
+			TeamsWebhook[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "TELEGRAM_CHAT_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "TELEGRAM_CHAT_"))
+			TelegramTargets[key] = TelegramTarget{ChatID: parts[1]}
+			continue
+		}
+		if strings.HasPrefix(env, "TELEGRAM_TOKEN_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "TELEGRAM_TOKEN_"))
+			telegramTokens[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "KAFKA_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "KAFKA_TARGET_"))
+			KafkaTargets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "EMAIL_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "EMAIL_TARGET_"))
+			EmailTargets[key] = parseRecipients(parts[1])
+			continue
+		}
+		if strings.HasPrefix(env, "NATS_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "NATS_TARGET_"))
+			loadPublishTarget(NATSTargets, "NATS_TARGET_", key, parts[1])
+			continue
+		}
+		if strings.HasPrefix(env, "MQTT_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "MQTT_TARGET_"))
+			loadPublishTarget(MQTTTargets, "MQTT_TARGET_", key, parts[1])
+			continue
+		}
+		if strings.HasPrefix(env, "SNMP_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "SNMP_TARGET_"))
+			SNMPTargets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "PAGERDUTY_ROUTING_KEY_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "PAGERDUTY_ROUTING_KEY_"))
+			PagerDutyTargets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "OPSGENIE_API_KEY_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "OPSGENIE_API_KEY_"))
+			OpsgenieTargets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "ELASTICSEARCH_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "ELASTICSEARCH_TARGET_"))
+			ElasticsearchTargets[key] = parts[1]
+			continue
+		}
+		if strings.HasPrefix(env, "WEBHOOK_TARGET_") {
+			parts := strings.SplitN(env, "=", 2)
+			key := strings.ToLower(strings.TrimPrefix(parts[0], "WEBHOOK_TARGET_"))
+			loadWebhookTarget(key, parts[1])
 		}
 	}
 
 	// 加载 Loki 配置
 	loadLokiConfig()
 
-	log.Printf("🪝 Webhooks loaded:\n feishu webhook: %v\n syslog addresses: %v\n loki enabled: %v",
-		FeishuWebhook, SyslogWebhook, LokiConfig.Enabled)
+	// 加载 Prometheus 配置
+	loadPrometheusConfig()
+
+	// 加载飞书应用和图表配置
+	loadFeishuConfig()
+	loadGraphConfig()
+	loadDingtalkConfig()
+	loadWecomConfig()
+	loadTelegramConfig()
+	loadSMTPConfig()
+	loadKafkaConfig()
+	loadNATSConfig()
+	loadMQTTConfig()
+	loadSNMPConfig()
+	loadIncidentConfig()
+	loadElasticsearchConfig()
+	loadMentionConfig()
+	loadOncallConfig()
+
+	// 加载 Alertmanager API 配置
+	loadAlertmanagerConfig()
+
+	log.Printf("🪝 Webhooks loaded:\n feishu targets: %v\n syslog addresses: %v\n dingtalk targets: %v\n wecom targets: %v\n slack targets: %v\n teams targets: %v\n telegram targets: %v\n email targets: %v\n kafka targets: %v\n nats targets: %v\n mqtt targets: %v\n snmp targets: %v\n pagerduty targets: %v\n opsgenie targets: %v\n elasticsearch targets: %v\n webhook targets: %v\n loki enabled: %v\n prometheus enabled: %v",
+		FeishuTargets, SyslogWebhook, targetNames(DingtalkTargets), targetNames(WecomWebhook),
+		targetNames(SlackWebhook), targetNames(TeamsWebhook), targetNames(TelegramTargets), EmailTargets,
+		KafkaTargets, targetNames(NATSTargets), targetNames(MQTTTargets), SNMPTargets,
+		targetNames(PagerDutyTargets), targetNames(OpsgenieTargets), ElasticsearchTargets, targetNames(WebhookTargets),
+		LokiConfig.Enabled, PrometheusConfig.Enabled)
+}
+
+// targetNames 返回排序后的目标名称，用于在日志中展示包含密钥的目标。
+func targetNames[T any](targets map[string]T) []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // loadLokiConfig 从环境变量加载 Loki 配置。
@@ -96,6 +297,197 @@ func loadLokiConfig() {
 		lokiURL, LokiConfig.LogLimit, LokiConfig.QueryRange, LokiConfig.QueryTimeout)
 }
 
+// loadPrometheusConfig 从环境变量加载 Prometheus 配置。
+func loadPrometheusConfig() {
+	promURL := os.Getenv("PROMETHEUS_URL")
+	if promURL == "" {
+		log.Println("⚠️ PROMETHEUS_URL not set, Prometheus metric enrichment disabled")
+		PrometheusConfig.Enabled = false
+		return
+	}
+
+	// 设置默认值
+	PrometheusConfig.Enabled = true
+	PrometheusConfig.TrendRange = 30 * time.Minute
+	PrometheusConfig.TrendStep = time.Minute
+	PrometheusConfig.QueryTimeout = 5 * time.Second
+	PrometheusConfig.EnrichTimeout = 3 * time.Second
+
+	// 从环境变量读取自定义配置
+	if rangeMinutes := os.Getenv("PROMETHEUS_TREND_RANGE"); rangeMinutes != "" {
+		if val, err := strconv.Atoi(rangeMinutes); err == nil && val > 0 {
+			PrometheusConfig.TrendRange = time.Duration(val) * time.Minute
+		}
+	}
+
+	if step := os.Getenv("PROMETHEUS_TREND_STEP"); step != "" {
+		if val, err := time.ParseDuration(step); err == nil && val > 0 {
+			PrometheusConfig.TrendStep = val
+		}
+	}
+
+	if timeout := os.Getenv("PROMETHEUS_QUERY_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil {
+			PrometheusConfig.QueryTimeout = val
+		}
+	}
+
+	if timeout := os.Getenv("PROMETHEUS_ENRICH_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			PrometheusConfig.EnrichTimeout = val
+		}
+	}
+
+	// 初始化 Prometheus 客户端
+	PrometheusClient = &prometheus.Client{
+		URL:      promURL,
+		Username: os.Getenv("PROMETHEUS_USERNAME"),
+		Password: os.Getenv("PROMETHEUS_PASSWORD"),
+		Timeout:  PrometheusConfig.QueryTimeout,
+	}
+
+	log.Printf("✅ Prometheus client initialized: URL=%s, Range=%v, Step=%v, Timeout=%v, EnrichTimeout=%v",
+		promURL, PrometheusConfig.TrendRange, PrometheusConfig.TrendStep, PrometheusConfig.QueryTimeout,
+		PrometheusConfig.EnrichTimeout)
+}
+
+// loadFeishuConfig 从环境变量加载飞书消息类型和应用凭证。
+func loadFeishuConfig() {
+	if msgType := strings.ToLower(os.Getenv("FEISHU_MSG_TYPE")); msgType == "card" || msgType == "text" {
+		FeishuMsgType = msgType
+	}
+
+	FeishuApp.AppID = os.Getenv("FEISHU_APP_ID")
+	FeishuApp.AppSecret = os.Getenv("FEISHU_APP_SECRET")
+	FeishuApp.BaseURL = os.Getenv("FEISHU_API_BASE")
+	if FeishuApp.BaseURL == "" {
+		FeishuApp.BaseURL = "https://open.feishu.cn"
+	}
+	FeishuApp.VerificationToken = os.Getenv("FEISHU_VERIFICATION_TOKEN")
+	FeishuApp.EncryptKey = os.Getenv("FEISHU_ENCRYPT_KEY")
+	FeishuApp.MessageTTL = 72 * time.Hour
+	if ttl := os.Getenv("FEISHU_MESSAGE_TTL"); ttl != "" {
+		if val, err := time.ParseDuration(ttl); err == nil && val > 0 {
+			FeishuApp.MessageTTL = val
+		}
+	}
+
+	if FeishuApp.AppID != "" && FeishuApp.AppSecret != "" {
+		log.Printf("✅ Feishu app configured: AppID=%s, API=%s", FeishuApp.AppID, FeishuApp.BaseURL)
+		return
+	}
+
+	for name, target := range FeishuTargets {
+		if target.Type == FeishuTargetApp {
+			log.Printf("⚠️ Feishu target '%s' uses app mode but FEISHU_APP_ID/FEISHU_APP_SECRET are not set", name)
+		}
+	}
+}
+
+// loadGraphConfig 从环境变量加载指标图表配置。
+// 图表需要通过飞书应用上传图片，并且只在卡片消息中展示。
+func loadGraphConfig() {
+	GraphConfig.Enabled = false
+	if enabled, _ := strconv.ParseBool(os.Getenv("GRAPH_ENABLED")); !enabled {
+		return
+	}
+	if FeishuApp.AppID == "" || FeishuApp.AppSecret == "" {
+		log.Println("⚠️ GRAPH_ENABLED requires FEISHU_APP_ID and FEISHU_APP_SECRET, metric graph disabled")
+		return
+	}
+	if FeishuMsgType != "card" {
+		log.Println("⚠️ GRAPH_ENABLED requires FEISHU_MSG_TYPE=card, metric graph disabled")
+		return
+	}
+
+	// 设置默认值
+	GraphConfig.Enabled = true
+	GraphConfig.Range = time.Hour
+	GraphConfig.Width = 800
+	GraphConfig.Height = 320
+
+	if rangeMinutes := os.Getenv("GRAPH_RANGE"); rangeMinutes != "" {
+		if val, err := strconv.Atoi(rangeMinutes); err == nil && val > 0 {
+			GraphConfig.Range = time.Duration(val) * time.Minute
+		}
+	}
+
+	if width := os.Getenv("GRAPH_WIDTH"); width != "" {
+		if val, err := strconv.Atoi(width); err == nil && val > 0 {
+			GraphConfig.Width = val
+		}
+	}
+
+	if height := os.Getenv("GRAPH_HEIGHT"); height != "" {
+		if val, err := strconv.Atoi(height); err == nil && val > 0 {
+			GraphConfig.Height = val
+		}
+	}
+
+	log.Printf("✅ Metric graph enabled: Range=%v, Size=%dx%d",
+		GraphConfig.Range, GraphConfig.Width, GraphConfig.Height)
+}
+
+// loadAlertmanagerConfig 从环境变量加载 Alertmanager API 配置。
+func loadAlertmanagerConfig() {
+	amURL := os.Getenv("ALERTMANAGER_URL")
+	if amURL == "" {
+		return
+	}
+
+	AlertmanagerClient = &amapi.Client{
+		URL:      amURL,
+		Username: os.Getenv("ALERTMANAGER_USERNAME"),
+		Password: os.Getenv("ALERTMANAGER_PASSWORD"),
+		Timeout:  10 * time.Second,
+	}
+
+	log.Printf("✅ Alertmanager client initialized: URL=%s", amURL)
+}
+
+// MetricQuery 返回用于补充告警指标信息的 PromQL。
+// 优先使用告警注释中的 metric_query，否则从 GeneratorURL 中提取表达式。
+func MetricQuery(alert Alert) string {
+	if query := strings.TrimSpace(alert.Annotations["metric_query"]); query != "" {
+		return query
+	}
+	return prometheus.ExprFromGeneratorURL(alert.GeneratorURL)
+}
+
+// EnrichContext 返回补充告警指标信息使用的 context，最长执行 PrometheusConfig.EnrichTimeout。
+// 告警处理在 webhook 请求中同步执行，Prometheus 响应缓慢时不能让 Alertmanager 等待超时后重发。
+func EnrichContext() (context.Context, context.CancelFunc) {
+	timeout := PrometheusConfig.EnrichTimeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// QueryMetricTrend 查询告警对应指标在触发时刻的值和近期走势，总时长受 EnrichContext 限制。
+// 未启用 Prometheus 或无法确定查询语句时返回 nil, nil。
+func QueryMetricTrend(alert Alert) (*prometheus.Trend, error) {
+	if !PrometheusConfig.Enabled || PrometheusClient == nil {
+		return nil, nil
+	}
+
+	query := MetricQuery(alert)
+	if query == "" {
+		return nil, nil
+	}
+
+	// 已恢复的告警以恢复时间作为趋势的终点
+	end := time.Now()
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() && alert.EndsAt.Before(end) {
+		end = alert.EndsAt
+	}
+
+	ctx, cancel := EnrichContext()
+	defer cancel()
+	return PrometheusClient.AlertTrend(ctx, query, alert.Labels, alert.StartsAt, end,
+		PrometheusConfig.TrendRange, PrometheusConfig.TrendStep)
+}
+
 // WebhookMessage 定义了 Alertmanager 发送的 webhook 消息格式。
 // 该结构体包含了所有必要的字段，用于解析和处理 Alertmanager 的 webhook 消息。
 type WebhookMessage struct {
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// 钉钉消息类型。
+const (
+	DingtalkMsgMarkdown   = "markdown"   // markdown 消息
+	DingtalkMsgActionCard = "actionCard" // 带跳转按钮的 actionCard 消息
+)
+
+// DingtalkTarget 钉钉机器人发送目标。
+type DingtalkTarget struct {
+	URL    string // 机器人 webhook 地址（包含 access_token）
+	Secret string // 加签密钥（SEC 开头），为空时不签名
+}
+
+// DingtalkTargets 存储所有可用的钉钉发送目标，key 为目标标识。
+var DingtalkTargets = make(map[string]DingtalkTarget)
+
+// DingtalkMsgType 钉钉消息类型：markdown（默认）或 actionCard。
+var DingtalkMsgType = DingtalkMsgMarkdown
+
+// dingtalkSecrets 从 DINGTALK_SECRET_<name> 读取的加签密钥，key 为目标标识。
+var dingtalkSecrets = make(map[string]string)
+
+// loadDingtalkConfig 为钉钉目标设置加签密钥，并加载钉钉消息类型。
+func loadDingtalkConfig() {
+	for name, secret := range dingtalkSecrets {
+		target, ok := DingtalkTargets[name]
+		if !ok {
+			log.Printf("⚠️ DINGTALK_SECRET_%s is set but DINGTALK_WEBHOOK_%s is not, secret ignored", name, name)
+			continue
+		}
+		target.Secret = secret
+		DingtalkTargets[name] = target
+	}
+
+	if msgType := os.Getenv("DINGTALK_MSG_TYPE"); msgType != "" {
+		switch strings.ToLower(msgType) {
+		case "markdown":
+			DingtalkMsgType = DingtalkMsgMarkdown
+		case "actioncard":
+			DingtalkMsgType = DingtalkMsgActionCard
+		default:
+			log.Printf("⚠️ Unknown DINGTALK_MSG_TYPE %q, using markdown", msgType)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ElasticsearchTargets 存储所有可用的 Elasticsearch/OpenSearch 索引目标，key 为目标标识，value 为索引名称模式。
+// 索引名称中的 %{+yyyy.MM.dd} 按告警开始时间（UTC）替换为日期，如 alerts-%{+yyyy.MM.dd}。
+var ElasticsearchTargets = make(map[string]string)
+
+// ElasticsearchConfig Elasticsearch/OpenSearch 批量写入配置。
+var ElasticsearchConfig struct {
+	URLs          []string      // 集群节点地址，请求失败时依次尝试下一个节点
+	Username      string        // Basic Auth 用户名
+	Password      string        // Basic Auth 密码
+	APIKey        string        // Elasticsearch API key（base64 编码的 id:api_key），配置后优先于 Basic Auth
+	BulkActions   int           // 每批最多的文档数量
+	BulkBytes     int           // 每批请求体的最大字节数
+	FlushInterval time.Duration // 未攒满一批时的最长等待时间
+	QueueSize     int           // 等待写入的文档队列长度
+	Timeout       time.Duration // 单次 _bulk 请求的超时时间
+	TLS           TLSFiles
+}
+
+// loadElasticsearchConfig 从环境变量加载 Elasticsearch/OpenSearch 配置。
+func loadElasticsearchConfig() {
+	for _, u := range strings.Split(os.Getenv("ELASTICSEARCH_URLS"), ",") {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			ElasticsearchConfig.URLs = append(ElasticsearchConfig.URLs, u)
+		}
+	}
+	if len(ElasticsearchConfig.URLs) == 0 {
+		if len(ElasticsearchTargets) > 0 {
+			log.Println("⚠️ ELASTICSEARCH_TARGET_xxx is set but ELASTICSEARCH_URLS is not, elasticsearch disabled")
+		}
+		return
+	}
+
+	for name, index := range ElasticsearchTargets {
+		if strings.TrimSpace(index) == "" {
+			ElasticsearchTargets[name] = "alerts-%{+yyyy.MM.dd}"
+		}
+	}
+
+	ElasticsearchConfig.Username = os.Getenv("ELASTICSEARCH_USERNAME")
+	ElasticsearchConfig.Password = os.Getenv("ELASTICSEARCH_PASSWORD")
+	ElasticsearchConfig.APIKey = os.Getenv("ELASTICSEARCH_API_KEY")
+
+	ElasticsearchConfig.BulkActions = positiveInt("ELASTICSEARCH_BULK_ACTIONS", 500)
+	ElasticsearchConfig.BulkBytes = positiveInt("ELASTICSEARCH_BULK_BYTES", 5<<20)
+	ElasticsearchConfig.QueueSize = positiveInt("ELASTICSEARCH_QUEUE_SIZE", 10000)
+
+	ElasticsearchConfig.FlushInterval = 5 * time.Second
+	if interval := os.Getenv("ELASTICSEARCH_FLUSH_INTERVAL"); interval != "" {
+		if val, err := time.ParseDuration(interval); err == nil && val > 0 {
+			ElasticsearchConfig.FlushInterval = val
+		}
+	}
+	ElasticsearchConfig.Timeout = 30 * time.Second
+	if timeout := os.Getenv("ELASTICSEARCH_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			ElasticsearchConfig.Timeout = val
+		}
+	}
+
+	ElasticsearchConfig.TLS = loadTLSFiles("ELASTICSEARCH")
+
+	auth := "none"
+	if ElasticsearchConfig.APIKey != "" {
+		auth = "api-key"
+	} else if ElasticsearchConfig.Username != "" {
+		auth = "basic"
+	}
+	log.Printf("✅ Elasticsearch configured: urls=%v, auth=%s, bulk=%d actions/%d bytes, flush=%v, TLS=%v",
+		ElasticsearchConfig.URLs, auth, ElasticsearchConfig.BulkActions, ElasticsearchConfig.BulkBytes,
+		ElasticsearchConfig.FlushInterval, ElasticsearchConfig.TLS.Enabled)
+}
+
+// positiveInt 读取正整数环境变量，未设置或无效时返回默认值。
+func positiveInt(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		log.Printf("⚠️ Invalid %s %q, using default %d", key, val, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP 连接的加密方式。
+const (
+	SMTPStartTLS = "starttls" // 明文连接后通过 STARTTLS 升级（默认，通常为 587 端口）
+	SMTPTLS      = "tls"      // 隐式 TLS（通常为 465 端口）
+	SMTPNone     = "none"     // 不加密，仅用于内网中继或测试
+)
+
+// EmailTargets 存储所有可用的邮件发送目标，key 为目标标识，value 为收件人列表。
+// 收件人可以是邮箱地址，也可以是 label:<标签名>，表示从告警的标签中读取收件人（多个用逗号分隔）。
+var EmailTargets = make(map[string][]string)
+
+// SMTPConfig SMTP 服务器配置。
+var SMTPConfig struct {
+	Host               string        // 服务器地址
+	Port               int           // 端口，默认 587（tls 模式默认 465）
+	TLS                string        // 加密方式：starttls、tls 或 none
+	Auth               string        // 认证方式：plain（默认）或 login，未配置用户名时不认证
+	Username           string        // 用户名
+	Password           string        // 密码
+	From               string        // 发件人
+	Timeout            time.Duration // 连接和发送的超时时间
+	InsecureSkipVerify bool          // 是否跳过证书校验
+}
+
+// parseRecipients 解析 EMAIL_TARGET_<name> 的值：逗号分隔的邮箱地址或 label:<标签名>。
+func parseRecipients(value string) []string {
+	var recipients []string
+	for _, r := range strings.Split(value, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients
+}
+
+// loadSMTPConfig 从环境变量加载 SMTP 配置。
+func loadSMTPConfig() {
+	SMTPConfig.Host = os.Getenv("SMTP_HOST")
+	if SMTPConfig.Host == "" {
+		if len(EmailTargets) > 0 {
+			log.Println("⚠️ EMAIL_TARGET_xxx is set but SMTP_HOST is not, email disabled")
+		}
+		return
+	}
+
+	SMTPConfig.TLS = SMTPStartTLS
+	if mode := strings.ToLower(os.Getenv("SMTP_TLS")); mode != "" {
+		switch mode {
+		case SMTPStartTLS, SMTPTLS, SMTPNone:
+			SMTPConfig.TLS = mode
+		default:
+			log.Printf("⚠️ Unknown SMTP_TLS %q, using starttls", mode)
+		}
+	}
+
+	SMTPConfig.Port = 587
+	if SMTPConfig.TLS == SMTPTLS {
+		SMTPConfig.Port = 465
+	}
+	if port := os.Getenv("SMTP_PORT"); port != "" {
+		if val, err := strconv.Atoi(port); err == nil && val > 0 {
+			SMTPConfig.Port = val
+		}
+	}
+
+	SMTPConfig.Auth = "plain"
+	if auth := strings.ToLower(os.Getenv("SMTP_AUTH")); auth != "" {
+		switch auth {
+		case "plain", "login":
+			SMTPConfig.Auth = auth
+		default:
+			log.Printf("⚠️ Unknown SMTP_AUTH %q, using plain", auth)
+		}
+	}
+
+	SMTPConfig.Username = os.Getenv("SMTP_USERNAME")
+	SMTPConfig.Password = os.Getenv("SMTP_PASSWORD")
+	SMTPConfig.From = os.Getenv("SMTP_FROM")
+	if SMTPConfig.From == "" {
+		SMTPConfig.From = SMTPConfig.Username
+	}
+	SMTPConfig.InsecureSkipVerify = os.Getenv("SMTP_INSECURE_SKIP_VERIFY") == "true"
+
+	SMTPConfig.Timeout = 10 * time.Second
+	if timeout := os.Getenv("SMTP_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			SMTPConfig.Timeout = val
+		}
+	}
+
+	if SMTPConfig.From == "" {
+		log.Println("⚠️ SMTP_FROM is not set, email disabled")
+		SMTPConfig.Host = ""
+		return
+	}
+
+	log.Printf("✅ SMTP configured: %s:%d, TLS=%s, Auth=%s, From=%s",
+		SMTPConfig.Host, SMTPConfig.Port, SMTPConfig.TLS, SMTPConfig.Auth, SMTPConfig.From)
+}
@@ -0,0 +1,34 @@
+package common
+
+import "time"
+
+// AlertEvent 告警事件：告警本身的字段以及所在告警组的上下文，
+// 用于 Kafka、NATS、MQTT 等消息渠道的 JSON 记录。
+type AlertEvent struct {
+	Alert
+	Receiver          string            `json:"receiver"`
+	GroupKey          string            `json:"groupKey"`
+	GroupStatus       string            `json:"groupStatus"` // 告警组的状态，与告警本身的状态可能不同
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Flapping          bool              `json:"flapping"`  // 告警是否处于抖动状态
+	Timestamp         time.Time         `json:"timestamp"` // adapter 收到告警的时间
+}
+
+// NewAlertEvent 从 Alertmanager 消息中的单个告警创建事件。
+func NewAlertEvent(payload WebhookMessage, alert Alert, flapping bool) AlertEvent {
+	return AlertEvent{
+		Alert:             alert,
+		Receiver:          payload.Receiver,
+		GroupKey:          payload.GroupKey,
+		GroupStatus:       payload.Status,
+		GroupLabels:       payload.GroupLabels,
+		CommonLabels:      payload.CommonLabels,
+		CommonAnnotations: payload.CommonAnnotations,
+		ExternalURL:       payload.ExternalURL,
+		Flapping:          flapping,
+		Timestamp:         time.Now(),
+	}
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"os"
+	"strings"
+)
+
+// PagerDutyTargets 存储所有可用的 PagerDuty 发送目标，key 为目标标识，value 为 Events API v2 的 routing key（integration key）。
+var PagerDutyTargets = make(map[string]string)
+
+// OpsgenieTargets 存储所有可用的 Opsgenie 发送目标，key 为目标标识，value 为 API integration 的 API key。
+var OpsgenieTargets = make(map[string]string)
+
+// PagerDutyConfig PagerDuty Events API 配置。
+var PagerDutyConfig = struct {
+	APIBase string // Events API 地址，默认 https://events.pagerduty.com，EU 账号为 https://events.eu.pagerduty.com
+}{
+	APIBase: "https://events.pagerduty.com",
+}
+
+// OpsgenieConfig Opsgenie Alert API 配置。
+var OpsgenieConfig = struct {
+	APIBase string // API 地址，默认 https://api.opsgenie.com，EU 账号为 https://api.eu.opsgenie.com
+}{
+	APIBase: "https://api.opsgenie.com",
+}
+
+// loadIncidentConfig 加载 PagerDuty 和 Opsgenie 的 API 地址，可以指向测试桩。
+func loadIncidentConfig() {
+	if base := os.Getenv("PAGERDUTY_API_BASE"); base != "" {
+		PagerDutyConfig.APIBase = strings.TrimRight(base, "/")
+	}
+	if base := os.Getenv("OPSGENIE_API_BASE"); base != "" {
+		OpsgenieConfig.APIBase = strings.TrimRight(base, "/")
+	}
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Kafka 生产者的确认方式。
+const (
+	KafkaAcksAll    = "all"    // 等待所有同步副本确认（默认，幂等写入要求此方式）
+	KafkaAcksLeader = "leader" // 只等待 leader 确认
+	KafkaAcksNone   = "none"   // 不等待确认
+)
+
+// KafkaTargets 存储所有可用的 Kafka 发送目标，key 为目标标识，value 为 topic 模板。
+// topic 模板为 Go text/template，可以引用告警的标签，如 alerts-{{ .Labels.team }}。
+var KafkaTargets = make(map[string]string)
+
+// KafkaConfig Kafka 生产者配置。
+var KafkaConfig struct {
+	Brokers       []string      // broker 地址
+	ClientID      string        // 客户端标识
+	Acks          string        // 确认方式：all、leader 或 none
+	Compression   string        // 压缩方式：none、gzip、snappy、lz4 或 zstd
+	Idempotent    bool          // 是否启用幂等写入，生产者重试时不会产生重复记录
+	Timeout       time.Duration // 单条记录的发送超时时间，包含客户端内部的重试
+	SASLMechanism string        // SASL 认证方式：plain、scram-sha-256 或 scram-sha-512，为空时不认证
+	SASLUsername  string
+	SASLPassword  string
+	TLS           TLSFiles
+}
+
+// loadKafkaConfig 从环境变量加载 Kafka 生产者配置。
+func loadKafkaConfig() {
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			KafkaConfig.Brokers = append(KafkaConfig.Brokers, broker)
+		}
+	}
+	if len(KafkaConfig.Brokers) == 0 {
+		if len(KafkaTargets) > 0 {
+			log.Println("⚠️ KAFKA_TARGET_xxx is set but KAFKA_BROKERS is not, kafka disabled")
+		}
+		return
+	}
+
+	KafkaConfig.ClientID = os.Getenv("KAFKA_CLIENT_ID")
+	if KafkaConfig.ClientID == "" {
+		KafkaConfig.ClientID = "alertmanager-webhook-adapter"
+	}
+
+	KafkaConfig.Acks = KafkaAcksAll
+	if acks := strings.ToLower(os.Getenv("KAFKA_ACKS")); acks != "" {
+		switch acks {
+		case KafkaAcksAll, KafkaAcksLeader, KafkaAcksNone:
+			KafkaConfig.Acks = acks
+		default:
+			log.Printf("⚠️ Unknown KAFKA_ACKS %q, using all", acks)
+		}
+	}
+
+	KafkaConfig.Compression = "none"
+	if compression := strings.ToLower(os.Getenv("KAFKA_COMPRESSION")); compression != "" {
+		switch compression {
+		case "none", "gzip", "snappy", "lz4", "zstd":
+			KafkaConfig.Compression = compression
+		default:
+			log.Printf("⚠️ Unknown KAFKA_COMPRESSION %q, using none", compression)
+		}
+	}
+
+	KafkaConfig.Idempotent = os.Getenv("KAFKA_IDEMPOTENT") != "false"
+	if KafkaConfig.Idempotent && KafkaConfig.Acks != KafkaAcksAll {
+		log.Printf("⚠️ Idempotent writes require KAFKA_ACKS=all, disabled for acks=%s", KafkaConfig.Acks)
+		KafkaConfig.Idempotent = false
+	}
+
+	KafkaConfig.Timeout = 10 * time.Second
+	if timeout := os.Getenv("KAFKA_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			KafkaConfig.Timeout = val
+		}
+	}
+
+	if mechanism := strings.ToLower(os.Getenv("KAFKA_SASL_MECHANISM")); mechanism != "" {
+		switch mechanism {
+		case "plain", "scram-sha-256", "scram-sha-512":
+			KafkaConfig.SASLMechanism = mechanism
+		default:
+			log.Printf("⚠️ Unknown KAFKA_SASL_MECHANISM %q, SASL disabled", mechanism)
+		}
+	}
+	KafkaConfig.SASLUsername = os.Getenv("KAFKA_SASL_USERNAME")
+	KafkaConfig.SASLPassword = os.Getenv("KAFKA_SASL_PASSWORD")
+
+	KafkaConfig.TLS = loadTLSFiles("KAFKA")
+
+	log.Printf("✅ Kafka configured: brokers=%v, acks=%s, compression=%s, idempotent=%v, TLS=%v, SASL=%s",
+		KafkaConfig.Brokers, KafkaConfig.Acks, KafkaConfig.Compression, KafkaConfig.Idempotent, KafkaConfig.TLS.Enabled, KafkaConfig.SASLMechanism)
+}
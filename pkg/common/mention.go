@@ -0,0 +1,90 @@
+package common
+
+import (
+	"alertmanagerWebhookAdapter/pkg/oncall"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+)
+
+// severityRanks 告警级别的高低顺序，数值越大级别越高。
+var severityRanks = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// SeverityRank 返回告警级别的排序值，未知级别返回 0。
+func SeverityRank(severity string) int {
+	return severityRanks[strings.ToLower(strings.TrimSpace(severity))]
+}
+
+// MentionRule 将告警标签映射为需要 @ 的用户。
+type MentionRule struct {
+	Label   string   `json:"label"`   // 标签名，如 team、owner、namespace
+	Value   string   `json:"value"`   // 标签值
+	Users   []string `json:"users"`   // 飞书用户 open_id
+	Emails  []string `json:"emails"`  // 飞书用户邮箱
+	Mobiles []string `json:"mobiles"` // 钉钉、企业微信用户手机号
+	UserIDs []string `json:"userids"` // 企业微信用户 userid
+}
+
+// MentionConfig @ 提醒配置（各渠道共用），从 FEISHU_MENTION_CONFIG 指定的 JSON 文件加载。
+// 配置了值班表时，min_severity 同样作用于值班人的 @ 提醒。
+var MentionConfig = struct {
+	Enabled     bool          `json:"-"`
+	MinSeverity string        `json:"min_severity"` // 触发 @ 的最低告警级别，默认 critical
+	AtAll       []string      `json:"at_all"`       // 需要 @所有人 的告警级别
+	Rules       []MentionRule `json:"rules"`        // 标签映射规则
+}{
+	MinSeverity: "critical",
+}
+
+// loadMentionConfig 从 FEISHU_MENTION_CONFIG 指定的文件加载 @ 提醒配置。
+func loadMentionConfig() {
+	path := os.Getenv("FEISHU_MENTION_CONFIG")
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to read FEISHU_MENTION_CONFIG %s, mention disabled: %v", path, err)
+		return
+	}
+	if err := json.Unmarshal(data, &MentionConfig); err != nil {
+		log.Printf("⚠️ Failed to parse FEISHU_MENTION_CONFIG %s, mention disabled: %v", path, err)
+		return
+	}
+
+	if SeverityRank(MentionConfig.MinSeverity) == 0 {
+		log.Printf("⚠️ Unknown min_severity %q in FEISHU_MENTION_CONFIG, using critical", MentionConfig.MinSeverity)
+		MentionConfig.MinSeverity = "critical"
+	}
+	MentionConfig.Enabled = true
+
+	log.Printf("✅ Feishu mention config loaded: %d rules, min severity=%s, at all=%v",
+		len(MentionConfig.Rules), MentionConfig.MinSeverity, MentionConfig.AtAll)
+}
+
+// Oncall 值班配置，从 ONCALL_CONFIG 指定的 JSON 文件加载，未配置时为 nil。
+var Oncall *oncall.Config
+
+// loadOncallConfig 从 ONCALL_CONFIG 指定的文件加载值班配置。
+func loadOncallConfig() {
+	path := os.Getenv("ONCALL_CONFIG")
+	if path == "" {
+		return
+	}
+
+	cfg, err := oncall.Load(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to load ONCALL_CONFIG %s, oncall disabled: %v", path, err)
+		return
+	}
+	Oncall = cfg
+
+	log.Printf("✅ Oncall schedules loaded: teams=%v", cfg.Teams())
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSeverityRank(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     int
+	}{
+		{"info", 1},
+		{"warning", 2},
+		{"error", 3},
+		{"critical", 4},
+		{" Critical ", 4},
+		{"WARNING", 2},
+		{"", 0},
+		{"page", 0},
+	}
+	for _, tt := range tests {
+		if got := SeverityRank(tt.severity); got != tt.want {
+			t.Errorf("SeverityRank(%q) = %d, want %d", tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestLoadMentionConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		enabled     bool
+		minSeverity string
+		atAll       []string
+		rules       []MentionRule
+	}{
+		{
+			name: "valid",
+			path: write("valid.json", `{"min_severity": "warning", "at_all": ["critical"],
+				"rules": [{"label": "team", "value": "db", "users": ["ou_dba"], "emails": ["dba@example.com"], "mobiles": ["13800000000"]}]}`),
+			enabled:     true,
+			minSeverity: "warning",
+			atAll:       []string{"critical"},
+			rules: []MentionRule{{Label: "team", Value: "db", Users: []string{"ou_dba"},
+				Emails: []string{"dba@example.com"}, Mobiles: []string{"13800000000"}}},
+		},
+		{
+			name:        "default min_severity",
+			path:        write("default.json", `{"rules": []}`),
+			enabled:     true,
+			minSeverity: "critical",
+			rules:       []MentionRule{},
+		},
+		{
+			name:        "unknown min_severity",
+			path:        write("unknown.json", `{"min_severity": "page"}`),
+			enabled:     true,
+			minSeverity: "critical",
+		},
+		{name: "invalid json", path: write("invalid.json", `{"rules": [`), minSeverity: "critical"},
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), minSeverity: "critical"},
+		{name: "not configured", minSeverity: "critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := MentionConfig
+			t.Cleanup(func() { MentionConfig = saved })
+			MentionConfig.Enabled, MentionConfig.MinSeverity, MentionConfig.AtAll, MentionConfig.Rules = false, "critical", nil, nil
+
+			t.Setenv("FEISHU_MENTION_CONFIG", tt.path)
+			loadMentionConfig()
+
+			if MentionConfig.Enabled != tt.enabled {
+				t.Errorf("Enabled = %v, want %v", MentionConfig.Enabled, tt.enabled)
+			}
+			if MentionConfig.MinSeverity != tt.minSeverity {
+				t.Errorf("MinSeverity = %q, want %q", MentionConfig.MinSeverity, tt.minSeverity)
+			}
+			if !reflect.DeepEqual(MentionConfig.AtAll, tt.atAll) {
+				t.Errorf("AtAll = %v, want %v", MentionConfig.AtAll, tt.atAll)
+			}
+			if !reflect.DeepEqual(MentionConfig.Rules, tt.rules) {
+				t.Errorf("Rules = %+v, want %+v", MentionConfig.Rules, tt.rules)
+			}
+		})
+	}
+}
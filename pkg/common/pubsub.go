@@ -0,0 +1,157 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PublishTarget NATS 或 MQTT 发布目标。
+type PublishTarget struct {
+	Subject  string `json:"subject"`   // NATS subject 或 MQTT topic 模板，如 alerts.{{ .Labels.cluster }}.{{ .Labels.severity }}
+	Topic    string `json:"topic"`     // MQTT topic，与 subject 二选一
+	Body     string `json:"body"`      // 消息内容模板（Go text/template），为空时发送 JSON 格式的告警事件
+	BodyFile string `json:"body_file"` // 消息内容模板文件，与 body 二选一
+	QoS      *int   `json:"qos"`       // MQTT QoS（0、1 或 2），为空时使用 MQTT_QOS
+	Retain   bool   `json:"retain"`    // MQTT retain 标志
+}
+
+// NATSTargets 存储所有可用的 NATS 发布目标，key 为目标标识。
+var NATSTargets = make(map[string]PublishTarget)
+
+// MQTTTargets 存储所有可用的 MQTT 发布目标，key 为目标标识。
+var MQTTTargets = make(map[string]PublishTarget)
+
+// NATSConfig NATS 连接配置。
+var NATSConfig struct {
+	URL       string        // 服务器地址，多个用逗号分隔，如 nats://nats-0:4222,nats://nats-1:4222
+	User      string        // 用户名
+	Password  string        // 密码
+	Token     string        // token 认证
+	CredsFile string        // NATS 2.0 凭证文件（JWT 和 NKey）
+	JetStream bool          // 是否通过 JetStream 发布并等待 stream 确认
+	Timeout   time.Duration // 连接、发布确认的超时时间
+	TLS       TLSFiles
+}
+
+// MQTTConfig MQTT 连接配置。
+var MQTTConfig struct {
+	Broker   string        // broker 地址，多个用逗号分隔，如 tcp://mqtt:1883、ssl://mqtt:8883、ws://mqtt:8080/mqtt
+	ClientID string        // 客户端标识，同一 broker 上必须唯一
+	Username string        // 用户名
+	Password string        // 密码
+	QoS      int           // 默认的 QoS（0、1 或 2），默认 1
+	Timeout  time.Duration // 连接、发布确认的超时时间
+	TLS      TLSFiles
+}
+
+// parsePublishTarget 解析 NATS_TARGET_<name> 或 MQTT_TARGET_<name> 的值：可以是 subject/topic 模板，
+// 也可以是 JSON 格式的完整配置。
+func parsePublishTarget(value string) (PublishTarget, error) {
+	var target PublishTarget
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &target); err != nil {
+			return target, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else {
+		target.Subject = value
+	}
+
+	if target.Subject == "" {
+		target.Subject = target.Topic
+	}
+	if target.Subject == "" {
+		return target, fmt.Errorf("subject or topic is required")
+	}
+	if target.BodyFile != "" {
+		data, err := os.ReadFile(target.BodyFile)
+		if err != nil {
+			return target, fmt.Errorf("failed to read body_file: %w", err)
+		}
+		target.Body = string(data)
+	}
+	if target.QoS != nil && (*target.QoS < 0 || *target.QoS > 2) {
+		return target, fmt.Errorf("invalid qos %d", *target.QoS)
+	}
+	return target, nil
+}
+
+// loadPublishTarget 加载一个 NATS_TARGET_<name> 或 MQTT_TARGET_<name> 环境变量。
+func loadPublishTarget(targets map[string]PublishTarget, prefix, name, value string) {
+	target, err := parsePublishTarget(value)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s%s, target ignored: %v", prefix, strings.ToUpper(name), err)
+		return
+	}
+	targets[name] = target
+}
+
+// loadNATSConfig 从环境变量加载 NATS 连接配置。
+func loadNATSConfig() {
+	NATSConfig.URL = os.Getenv("NATS_URL")
+	if NATSConfig.URL == "" {
+		if len(NATSTargets) > 0 {
+			log.Println("⚠️ NATS_TARGET_xxx is set but NATS_URL is not, nats disabled")
+		}
+		return
+	}
+
+	NATSConfig.User = os.Getenv("NATS_USER")
+	NATSConfig.Password = os.Getenv("NATS_PASSWORD")
+	NATSConfig.Token = os.Getenv("NATS_TOKEN")
+	NATSConfig.CredsFile = os.Getenv("NATS_CREDS")
+	NATSConfig.JetStream = os.Getenv("NATS_JETSTREAM") == "true"
+	NATSConfig.Timeout = publishTimeout("NATS_TIMEOUT")
+	NATSConfig.TLS = loadTLSFiles("NATS")
+
+	log.Printf("✅ NATS configured: %s, JetStream=%v, TLS=%v", NATSConfig.URL, NATSConfig.JetStream, NATSConfig.TLS.Enabled)
+}
+
+// loadMQTTConfig 从环境变量加载 MQTT 连接配置。
+func loadMQTTConfig() {
+	MQTTConfig.Broker = os.Getenv("MQTT_BROKER")
+	if MQTTConfig.Broker == "" {
+		if len(MQTTTargets) > 0 {
+			log.Println("⚠️ MQTT_TARGET_xxx is set but MQTT_BROKER is not, mqtt disabled")
+		}
+		return
+	}
+
+	MQTTConfig.ClientID = os.Getenv("MQTT_CLIENT_ID")
+	if MQTTConfig.ClientID == "" {
+		// 多副本部署时使用主机名区分客户端，避免相同的客户端标识互相踢下线
+		hostname, _ := os.Hostname()
+		MQTTConfig.ClientID = "alertmanager-webhook-adapter-" + hostname
+	}
+	MQTTConfig.Username = os.Getenv("MQTT_USERNAME")
+	MQTTConfig.Password = os.Getenv("MQTT_PASSWORD")
+
+	MQTTConfig.QoS = 1
+	if qos := os.Getenv("MQTT_QOS"); qos != "" {
+		if val, err := strconv.Atoi(qos); err == nil && val >= 0 && val <= 2 {
+			MQTTConfig.QoS = val
+		} else {
+			log.Printf("⚠️ Invalid MQTT_QOS %q, using 1", qos)
+		}
+	}
+	MQTTConfig.Timeout = publishTimeout("MQTT_TIMEOUT")
+	MQTTConfig.TLS = loadTLSFiles("MQTT")
+
+	log.Printf("✅ MQTT configured: %s, client=%s, QoS=%d", MQTTConfig.Broker, MQTTConfig.ClientID, MQTTConfig.QoS)
+}
+
+// publishTimeout 读取发布超时时间，默认 10s。
+func publishTimeout(env string) time.Duration {
+	if timeout := os.Getenv(env); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			return val
+		}
+		log.Printf("⚠️ Invalid %s %q, using 10s", env, timeout)
+	}
+	return 10 * time.Second
+}
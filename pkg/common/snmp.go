@@ -0,0 +1,126 @@
+package common
+
+import (
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SNMP 版本。
+const (
+	SNMPv2c = "2c"
+	SNMPv3  = "3"
+)
+
+// SNMPTargets 存储所有可用的 SNMP trap 接收地址，key 为目标标识，value 为 host:port（默认端口 162）。
+var SNMPTargets = make(map[string]string)
+
+// SNMPConfig SNMP trap 配置。
+var SNMPConfig struct {
+	Version       string        // 2c（默认）或 3
+	Community     string        // v2c community，默认 public
+	EnterpriseOID string        // trap 定义所在的企业 OID，默认 1.3.6.1.4.1.32473.1（RFC 5612 中用于示例的企业号）
+	Inform        bool          // 是否发送 INFORM 并等待接收方确认，默认发送不需要确认的 trap
+	Timeout       time.Duration // INFORM 等待确认的超时时间
+	Retries       int           // INFORM 未确认时的重发次数
+
+	// SNMPv3 USM 参数
+	Username     string
+	AuthProtocol string // none、md5、sha、sha224、sha256、sha384 或 sha512
+	AuthPassword string
+	PrivProtocol string // none、des、aes、aes192、aes256、aes192c 或 aes256c
+	PrivPassword string
+	EngineID     []byte // 发送 trap 时 adapter 为权威引擎，接收方需要按此引擎 ID 配置用户
+}
+
+// defaultEngineID 默认的 SNMPv3 引擎 ID：企业号 32473 加上文本 alertmanager（RFC 3411 格式 4）。
+const defaultEngineID = "80007ed904616c6572746d616e61676572"
+
+// loadSNMPConfig 从环境变量加载 SNMP trap 配置。
+func loadSNMPConfig() {
+	if len(SNMPTargets) == 0 {
+		return
+	}
+
+	SNMPConfig.Version = SNMPv2c
+	if version := strings.TrimPrefix(strings.ToLower(os.Getenv("SNMP_VERSION")), "v"); version != "" {
+		switch version {
+		case SNMPv2c, SNMPv3:
+			SNMPConfig.Version = version
+		default:
+			log.Printf("⚠️ Unknown SNMP_VERSION %q, using 2c", version)
+		}
+	}
+
+	SNMPConfig.Community = os.Getenv("SNMP_COMMUNITY")
+	if SNMPConfig.Community == "" {
+		SNMPConfig.Community = "public"
+	}
+
+	SNMPConfig.EnterpriseOID = "1.3.6.1.4.1.32473.1"
+	if oid := strings.Trim(os.Getenv("SNMP_ENTERPRISE_OID"), "."); oid != "" {
+		if validOID(oid) {
+			SNMPConfig.EnterpriseOID = oid
+		} else {
+			log.Printf("⚠️ Invalid SNMP_ENTERPRISE_OID %q, using %s", oid, SNMPConfig.EnterpriseOID)
+		}
+	}
+
+	SNMPConfig.Inform = os.Getenv("SNMP_INFORM") == "true"
+	SNMPConfig.Timeout = 5 * time.Second
+	if timeout := os.Getenv("SNMP_TIMEOUT"); timeout != "" {
+		if val, err := time.ParseDuration(timeout); err == nil && val > 0 {
+			SNMPConfig.Timeout = val
+		}
+	}
+	SNMPConfig.Retries = 1
+	if retries := os.Getenv("SNMP_RETRIES"); retries != "" {
+		if val, err := strconv.Atoi(retries); err == nil && val >= 0 {
+			SNMPConfig.Retries = val
+		}
+	}
+
+	SNMPConfig.Username = os.Getenv("SNMP_USERNAME")
+	SNMPConfig.AuthProtocol = strings.ToLower(os.Getenv("SNMP_AUTH_PROTOCOL"))
+	SNMPConfig.AuthPassword = os.Getenv("SNMP_AUTH_PASSWORD")
+	SNMPConfig.PrivProtocol = strings.ToLower(os.Getenv("SNMP_PRIV_PROTOCOL"))
+	SNMPConfig.PrivPassword = os.Getenv("SNMP_PRIV_PASSWORD")
+
+	engineID := os.Getenv("SNMP_ENGINE_ID")
+	if engineID == "" {
+		engineID = defaultEngineID
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(engineID), "0x"))
+	if err != nil || len(id) < 5 || len(id) > 32 {
+		log.Printf("⚠️ Invalid SNMP_ENGINE_ID %q (5-32 bytes in hex), using %s", engineID, defaultEngineID)
+		id, _ = hex.DecodeString(defaultEngineID)
+	}
+	SNMPConfig.EngineID = id
+
+	if SNMPConfig.Version == SNMPv3 {
+		if SNMPConfig.Username == "" {
+			log.Println("⚠️ SNMP_VERSION=3 but SNMP_USERNAME is not set, traps will be rejected")
+		}
+		log.Printf("✅ SNMP traps configured: v3, user=%s, auth=%s, priv=%s, engine=%x, enterprise=%s",
+			SNMPConfig.Username, SNMPConfig.AuthProtocol, SNMPConfig.PrivProtocol, SNMPConfig.EngineID, SNMPConfig.EnterpriseOID)
+		return
+	}
+	log.Printf("✅ SNMP traps configured: v2c, enterprise=%s, inform=%v", SNMPConfig.EnterpriseOID, SNMPConfig.Inform)
+}
+
+// validOID 检查 OID 是否为点分隔的数字。
+func validOID(oid string) bool {
+	parts := strings.Split(oid, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
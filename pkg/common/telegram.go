@@ -0,0 +1,68 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// Telegram 消息格式。
+const (
+	TelegramHTML       = "HTML"
+	TelegramMarkdownV2 = "MarkdownV2"
+)
+
+// TelegramTarget Telegram 发送目标。
+type TelegramTarget struct {
+	ChatID string // 群组、频道或用户的 chat_id，频道也可以使用 @channelusername
+	Token  string // 机器人 token，未单独配置时使用 TELEGRAM_BOT_TOKEN
+}
+
+// TelegramTargets 存储所有可用的 Telegram 发送目标，key 为目标标识。
+var TelegramTargets = make(map[string]TelegramTarget)
+
+// TelegramConfig Telegram Bot API 配置。
+var TelegramConfig = struct {
+	APIBase   string // Bot API 地址，默认 https://api.telegram.org，可以指向自建的 Bot API 服务或测试桩
+	ParseMode string // 消息格式：HTML（默认）或 MarkdownV2
+}{
+	APIBase:   "https://api.telegram.org",
+	ParseMode: TelegramHTML,
+}
+
+// telegramTokens 从 TELEGRAM_TOKEN_<name> 读取的目标机器人 token，key 为目标标识。
+var telegramTokens = make(map[string]string)
+
+// loadTelegramConfig 为 Telegram 目标设置机器人 token，并加载 Bot API 地址和消息格式。
+func loadTelegramConfig() {
+	if base := os.Getenv("TELEGRAM_API_BASE"); base != "" {
+		TelegramConfig.APIBase = strings.TrimRight(base, "/")
+	}
+	if mode := os.Getenv("TELEGRAM_PARSE_MODE"); mode != "" {
+		switch strings.ToLower(mode) {
+		case "html":
+			TelegramConfig.ParseMode = TelegramHTML
+		case "markdownv2":
+			TelegramConfig.ParseMode = TelegramMarkdownV2
+		default:
+			log.Printf("⚠️ Unknown TELEGRAM_PARSE_MODE %q, using HTML", mode)
+		}
+	}
+
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	for name, target := range TelegramTargets {
+		target.Token = token
+		if t, ok := telegramTokens[name]; ok {
+			target.Token = t
+		}
+		if target.Token == "" {
+			log.Printf("⚠️ Telegram target '%s' has no bot token, set TELEGRAM_BOT_TOKEN or TELEGRAM_TOKEN_%s", name, strings.ToUpper(name))
+		}
+		TelegramTargets[name] = target
+	}
+	for name := range telegramTokens {
+		if _, ok := TelegramTargets[name]; !ok {
+			log.Printf("⚠️ TELEGRAM_TOKEN_%s is set but TELEGRAM_CHAT_%s is not, token ignored", name, name)
+		}
+	}
+}
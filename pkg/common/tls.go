@@ -0,0 +1,56 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSFiles 客户端 TLS 配置，用于 Kafka、NATS、MQTT 等非 HTTP 渠道。
+type TLSFiles struct {
+	Enabled            bool   // 是否使用 TLS 连接
+	CAFile             string // CA 证书文件，为空时使用系统证书
+	CertFile           string // 客户端证书文件（双向 TLS）
+	KeyFile            string // 客户端私钥文件（双向 TLS）
+	InsecureSkipVerify bool   // 是否跳过证书校验
+}
+
+// loadTLSFiles 从 <prefix>_TLS、<prefix>_TLS_CA_FILE、<prefix>_TLS_CERT_FILE、<prefix>_TLS_KEY_FILE
+// 和 <prefix>_TLS_INSECURE_SKIP_VERIFY 环境变量加载 TLS 配置，配置了证书文件时默认启用 TLS。
+func loadTLSFiles(prefix string) TLSFiles {
+	files := TLSFiles{
+		CAFile:             os.Getenv(prefix + "_TLS_CA_FILE"),
+		CertFile:           os.Getenv(prefix + "_TLS_CERT_FILE"),
+		KeyFile:            os.Getenv(prefix + "_TLS_KEY_FILE"),
+		InsecureSkipVerify: os.Getenv(prefix+"_TLS_INSECURE_SKIP_VERIFY") == "true",
+	}
+	files.Enabled = os.Getenv(prefix+"_TLS") == "true" || files.CAFile != "" || files.CertFile != ""
+	return files
+}
+
+// Config 读取证书文件并创建 TLS 配置。
+func (f TLSFiles) Config() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: f.InsecureSkipVerify}
+
+	if f.CAFile != "" {
+		pem, err := os.ReadFile(f.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", f.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if f.CertFile != "" || f.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// WebhookAuth 通用 webhook 目标的认证配置。
+type WebhookAuth struct {
+	Type     string `json:"type"`     // basic 或 bearer
+	Username string `json:"username"` // basic 认证用户名
+	Password string `json:"password"` // basic 认证密码
+	Token    string `json:"token"`    // bearer token
+}
+
+// WebhookTarget 通用 webhook 发送目标。
+type WebhookTarget struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`       // 请求方法，默认 POST
+	Headers     map[string]string `json:"headers"`      // 额外的请求头
+	Auth        *WebhookAuth      `json:"auth"`         // 认证配置（可选）
+	ContentType string            `json:"content_type"` // 请求体类型，默认 application/json
+	Body        string            `json:"body"`         // 请求体模板（Go text/template），为空时发送 JSON 格式的告警
+	BodyFile    string            `json:"body_file"`    // 请求体模板文件，与 body 二选一
+	Timeout     string            `json:"timeout"`      // 请求超时时间，默认 10s
+
+	timeout time.Duration
+	tmpl    *template.Template
+}
+
+// RequestTimeout 返回请求超时时间。
+func (t WebhookTarget) RequestTimeout() time.Duration {
+	if t.timeout <= 0 {
+		return 10 * time.Second
+	}
+	return t.timeout
+}
+
+// BodyTemplate 返回加载时解析的请求体模板，未配置模板时返回 nil。
+func (t WebhookTarget) BodyTemplate() *template.Template {
+	return t.tmpl
+}
+
+// webhookFuncs 请求体模板可以使用的函数。
+var webhookFuncs = template.FuncMap{
+	// json 将值编码为 JSON，字符串会带上引号并转义，可以直接嵌入 JSON 请求体
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"join":      strings.Join,
+	"replace":   strings.ReplaceAll,
+	"trimSpace": strings.TrimSpace,
+	"time": func(t time.Time, layout string) string {
+		return t.Local().Format(layout)
+	},
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+// WebhookTargets 存储所有可用的通用 webhook 发送目标，key 为目标标识。
+var WebhookTargets = make(map[string]WebhookTarget)
+
+// ParseWebhookTarget 解析 WEBHOOK_TARGET_<name> 的值：可以是 URL，也可以是 JSON 格式的完整配置。
+// 请求体模板在加载时解析，模板有误的目标不会被加载。
+func ParseWebhookTarget(name, value string) (WebhookTarget, error) {
+	var target WebhookTarget
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &target); err != nil {
+			return target, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else {
+		target.URL = value
+	}
+
+	if target.URL == "" {
+		return target, fmt.Errorf("url is required")
+	}
+	target.Method = strings.ToUpper(target.Method)
+	if target.Method == "" {
+		target.Method = http.MethodPost
+	}
+	if target.ContentType == "" {
+		target.ContentType = "application/json"
+	}
+	if target.BodyFile != "" {
+		data, err := os.ReadFile(target.BodyFile)
+		if err != nil {
+			return target, fmt.Errorf("failed to read body_file: %w", err)
+		}
+		target.Body = string(data)
+	}
+	if target.Body != "" {
+		tmpl, err := template.New(name).Funcs(webhookFuncs).Parse(target.Body)
+		if err != nil {
+			return target, fmt.Errorf("invalid body template: %w", err)
+		}
+		target.tmpl = tmpl
+	}
+	if target.Timeout != "" {
+		d, err := time.ParseDuration(target.Timeout)
+		if err != nil || d <= 0 {
+			return target, fmt.Errorf("invalid timeout %q", target.Timeout)
+		}
+		target.timeout = d
+	}
+	if target.Auth != nil {
+		switch strings.ToLower(target.Auth.Type) {
+		case "basic", "bearer":
+		default:
+			return target, fmt.Errorf("unknown auth type %q", target.Auth.Type)
+		}
+	}
+	return target, nil
+}
+
+// loadWebhookTarget 加载一个 WEBHOOK_TARGET_<name> 环境变量。
+func loadWebhookTarget(name, value string) {
+	target, err := ParseWebhookTarget(name, value)
+	if err != nil {
+		log.Printf("⚠️ Invalid WEBHOOK_TARGET_%s, target ignored: %v", strings.ToUpper(name), err)
+		return
+	}
+	WebhookTargets[name] = target
+}
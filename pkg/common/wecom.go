@@ -0,0 +1,32 @@
+package common
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// 企业微信消息类型。
+const (
+	WecomMsgText         = "text"          // 文本消息
+	WecomMsgMarkdown     = "markdown"      // markdown 消息
+	WecomMsgTemplateCard = "template_card" // 文本通知模板卡片
+)
+
+// WecomWebhook 存储所有可用的企业微信群机器人 webhook 地址，key 为目标标识。
+var WecomWebhook = make(map[string]string)
+
+// WecomMsgType 企业微信消息类型：markdown（默认）、text 或 template_card。
+var WecomMsgType = WecomMsgMarkdown
+
+// loadWecomConfig 从环境变量加载企业微信消息类型。
+func loadWecomConfig() {
+	msgType := strings.ToLower(os.Getenv("WECOM_MSG_TYPE"))
+	switch msgType {
+	case "":
+	case WecomMsgText, WecomMsgMarkdown, WecomMsgTemplateCard:
+		WecomMsgType = msgType
+	default:
+		log.Printf("⚠️ Unknown WECOM_MSG_TYPE %q, using markdown", msgType)
+	}
+}
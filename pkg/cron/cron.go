@@ -0,0 +1,168 @@
+// Package cron 解析标准 5 段 cron 表达式（分 时 日 月 周），用于静默规则、定时报表等按时间触发的功能。
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr 标准 5 段 cron 表达式（分 时 日 月 周），每段以位图表示允许的取值。
+type Expr struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // 日、周字段是否为 *，两者都有限制时任一匹配即可（与 cron 一致）
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	// WeekdayNames 星期的英文缩写，供其他按星期配置的功能复用
+	WeekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// Parse 解析 5 段 cron 表达式，支持 *、列表、范围、步长以及月份和星期的英文缩写。
+func Parse(expr string) (*Expr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var c Expr
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, WeekdayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 周日可以写作 0 或 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = strings.HasPrefix(fields[2], "*")
+	c.dowStar = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parseField 解析 cron 表达式的一段。
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(from, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, names); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range in %q", part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseValue 解析数字或英文缩写。
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// Match 判断时间点（精确到分钟）是否匹配 cron 表达式。
+func (c *Expr) Match(t time.Time) bool {
+	return c.minute&(1<<uint(t.Minute())) != 0 &&
+		c.hour&(1<<uint(t.Hour())) != 0 &&
+		c.month&(1<<uint(t.Month())) != 0 &&
+		c.matchDay(t)
+}
+
+// matchDay 判断日期是否匹配日和周字段：两者都有限制时任一匹配即可，否则两者都需要匹配。
+func (c *Expr) matchDay(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// LastBefore 返回不晚于 t 且不早于 t-lookback 的最近一次触发时间。
+// 月、日、时不匹配时直接跳到上一个月、上一天、上一小时的最后一分钟，避免逐分钟回溯。
+func (c *Expr) LastBefore(t time.Time, lookback time.Duration) (time.Time, bool) {
+	limit := t.Add(-lookback)
+	loc := t.Location()
+	for at := t.Truncate(time.Minute); !at.Before(limit); {
+		var start time.Time // 不匹配的时间段的开始时间
+		switch {
+		case c.month&(1<<uint(at.Month())) == 0:
+			start = time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(at):
+			start = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(at.Hour())) == 0:
+			start = time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), 0, 0, 0, loc)
+		case c.minute&(1<<uint(at.Minute())) == 0:
+			start = at
+		default:
+			return at, true
+		}
+		// 夏令时切换时重复的时间可能被解析为较晚的一次，此时只回退一分钟
+		if start.After(at) {
+			start = at
+		}
+		at = start.Add(-time.Minute)
+	}
+	return time.Time{}, false
+}
+
+// Next 返回晚于 t 且不晚于 t+lookahead 的下一次触发时间。
+func (c *Expr) Next(t time.Time, lookahead time.Duration) (time.Time, bool) {
+	end := t.Add(lookahead)
+	for at := t.Truncate(time.Minute).Add(time.Minute); !at.After(end); at = at.Add(time.Minute) {
+		if c.Match(at) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
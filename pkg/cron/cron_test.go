@@ -0,0 +1,135 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{"* * * * *", "*/15 9-18 * * mon-fri", "0 0 1,15 jan,jul *", "30 2 * * 7", "0 22 * * SAT"}
+	for _, expr := range valid {
+		if _, err := Parse(expr); err != nil {
+			t.Errorf("Parse(%q) error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{"* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * foo *"}
+	for _, expr := range invalid {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   string
+		want bool
+	}{
+		{"*/15 9-18 * * mon-fri", "2024-03-04T09:45:00Z", true}, // 周一
+		{"*/15 9-18 * * mon-fri", "2024-03-04T09:40:00Z", false},
+		{"*/15 9-18 * * mon-fri", "2024-03-09T10:00:00Z", false}, // 周六
+		{"0 3 * * 0", "2024-03-10T03:00:00Z", true},              // 周日写作 0
+		{"0 3 * * 7", "2024-03-10T03:00:00Z", true},              // 周日写作 7
+		{"0 0 * feb *", "2024-02-29T00:00:00Z", true},
+		// 日和周都有限制时任一匹配即可
+		{"0 0 13 * fri", "2024-09-13T00:00:00Z", true},  // 13 日且周五
+		{"0 0 13 * fri", "2024-03-13T00:00:00Z", true},  // 13 日，周三
+		{"0 0 13 * fri", "2024-03-15T00:00:00Z", true},  // 周五，15 日
+		{"0 0 13 * fri", "2024-03-14T00:00:00Z", false}, // 都不匹配
+		// 只有一个字段有限制时需要两者都匹配
+		{"0 0 13 * *", "2024-03-15T00:00:00Z", false},
+		{"0 0 * * fri", "2024-03-13T00:00:00Z", false},
+		{"0 0 */2 * *", "2024-03-13T00:00:00Z", true}, // * 开头的步长视为不限制
+		{"0 0 */2 * fri", "2024-03-15T00:00:00Z", true},
+		{"0 0 */2 * fri", "2024-03-14T00:00:00Z", false},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+		}
+		at, _ := time.Parse(time.RFC3339, tt.at)
+		if got := expr.Match(at); got != tt.want {
+			t.Errorf("%q Match(%s) = %v, want %v", tt.expr, tt.at, got, tt.want)
+		}
+	}
+}
+
+// TestMatchTimezone 按时间所在的时区匹配。
+func TestMatchTimezone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	expr, _ := Parse("0 9 * * mon")
+	at := time.Date(2024, 3, 4, 1, 0, 0, 0, time.UTC) // 北京时间周一 09:00
+	if expr.Match(at) {
+		t.Errorf("Match(%v) = true in UTC", at)
+	}
+	if !expr.Match(at.In(shanghai)) {
+		t.Errorf("Match(%v) = false in Asia/Shanghai", at.In(shanghai))
+	}
+}
+
+// lastBeforeByMinute 逐分钟回溯的参考实现。
+func lastBeforeByMinute(c *Expr, t time.Time, lookback time.Duration) (time.Time, bool) {
+	for at := t.Truncate(time.Minute); !at.Before(t.Add(-lookback)); at = at.Add(-time.Minute) {
+		if c.Match(at) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// TestLastBefore 按字段跳跃回溯的结果与逐分钟回溯一致，包括夏令时切换前后。
+func TestLastBefore(t *testing.T) {
+	locations := []*time.Location{time.UTC}
+	for _, name := range []string{"Asia/Shanghai", "America/New_York", "Asia/Kolkata"} {
+		if loc, err := time.LoadLocation(name); err == nil {
+			locations = append(locations, loc)
+		}
+	}
+	exprs := []string{
+		"* * * * *", "30 2 * * *", "*/20 22-23 * * fri", "0 0 1 * *", "0 0 13 * fri",
+		"15 1 * * sun", "0 9 29 feb *", "45 3 * mar,nov *",
+	}
+	// 包含美国 2024 年夏令时开始（3 月 10 日）和结束（11 月 3 日）的时间
+	starts := []string{
+		"2024-03-10T01:30:00", "2024-03-10T03:10:00", "2024-03-11T00:00:00",
+		"2024-11-03T01:30:00", "2024-11-03T02:30:00", "2024-11-04T12:34:56",
+		"2024-03-01T00:00:00", "2024-12-31T23:59:59",
+	}
+
+	for _, loc := range locations {
+		for _, e := range exprs {
+			expr, err := Parse(e)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", e, err)
+			}
+			for _, s := range starts {
+				at, _ := time.ParseInLocation("2006-01-02T15:04:05", s, loc)
+				for _, lookback := range []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 40 * 24 * time.Hour} {
+					got, gotOK := expr.LastBefore(at, lookback)
+					want, wantOK := lastBeforeByMinute(expr, at, lookback)
+					if gotOK != wantOK || !got.Equal(want) {
+						t.Errorf("%q LastBefore(%v, %v) = %v, %v; want %v, %v", e, at, lookback, got, gotOK, want, wantOK)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	expr, _ := Parse("0 9 * * mon-fri")
+	at := time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC) // 周五 09:00，不包含当前时间
+	got, ok := expr.Next(at, 7*24*time.Hour)
+	if want := time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, %v; want %v", at, got, ok, want)
+	}
+	if _, ok := expr.Next(at, time.Hour); ok {
+		t.Errorf("Next(%v, 1h) found a time beyond lookahead", at)
+	}
+}
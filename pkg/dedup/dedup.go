@@ -0,0 +1,125 @@
+// Package dedup 对重复的告警通知去重。
+// Alertmanager 的 repeat_interval、重试以及多副本部署都会导致同一告警被重复发送，
+// 在去重窗口内，同一告警以相同状态发送到同一目标的通知只会发送一次。
+package dedup
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// entry 某个告警在某个目标上最近一次发送（或开始发送）的状态和时间。
+type entry struct {
+	status string
+	at     time.Time
+}
+
+// Deduper 以告警指纹和目标为 key 记录最近发送的状态。
+type Deduper struct {
+	mu        sync.Mutex
+	window    time.Duration
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// defaultDeduper 全局的去重器，窗口为 0 时不去重。
+var defaultDeduper = New(0)
+
+// New 创建去重窗口为 window 的去重器。
+func New(window time.Duration) *Deduper {
+	return &Deduper{
+		window:  window,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Init 从 DEDUP_WINDOW 加载去重窗口，如 5m；未配置或为 0 时不去重。
+func Init() {
+	val := os.Getenv("DEDUP_WINDOW")
+	if val == "" {
+		return
+	}
+
+	window, err := time.ParseDuration(val)
+	if err != nil || window < 0 {
+		log.Printf("⚠️ Invalid DEDUP_WINDOW %q, deduplication disabled", val)
+		return
+	}
+
+	defaultDeduper.mu.Lock()
+	defaultDeduper.window = window
+	defaultDeduper.mu.Unlock()
+
+	if window > 0 {
+		log.Printf("✅ Notification deduplication enabled: window=%v", window)
+	}
+}
+
+// key 返回告警在目标上的去重 key。
+func key(fingerprint, channel, target string) string {
+	return fingerprint + "|" + channel + "/" + target
+}
+
+// Acquire 判断告警通知是否需要发送。返回 false 表示去重窗口内已以相同状态发送过（或正在发送），应当跳过；
+// 返回 true 时调用方需要在发送后调用 Done 记录发送结果。
+func (d *Deduper) Acquire(alert common.Alert, channel, target string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.window <= 0 || alert.Fingerprint == "" {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(d.lastSweep) >= d.window {
+		d.sweep(now)
+	}
+
+	k := key(alert.Fingerprint, channel, target)
+	if e, ok := d.entries[k]; ok && e.status == alert.Status && now.Sub(e.at) < d.window {
+		return false
+	}
+	// 发送前即记录，拦截并发到达的重复通知（如多个 Alertmanager 副本同时发送）
+	d.entries[k] = &entry{status: alert.Status, at: now}
+	return true
+}
+
+// Done 记录告警通知的发送结果。发送失败时清除记录，以便 Alertmanager 重试时能够再次发送。
+func (d *Deduper) Done(alert common.Alert, channel, target string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	k := key(alert.Fingerprint, channel, target)
+	e, ok := d.entries[k]
+	if !ok || e.status != alert.Status {
+		return
+	}
+	if err != nil {
+		delete(d.entries, k)
+		return
+	}
+	e.at = time.Now()
+}
+
+// sweep 清除超出去重窗口的记录，调用方需持有锁。
+func (d *Deduper) sweep(now time.Time) {
+	for k, e := range d.entries {
+		if now.Sub(e.at) >= d.window {
+			delete(d.entries, k)
+		}
+	}
+	d.lastSweep = now
+}
+
+// Acquire 使用全局去重器判断告警通知是否需要发送。
+func Acquire(alert common.Alert, channel, target string) bool {
+	return defaultDeduper.Acquire(alert, channel, target)
+}
+
+// Done 使用全局去重器记录告警通知的发送结果。
+func Done(alert common.Alert, channel, target string, err error) {
+	defaultDeduper.Done(alert, channel, target, err)
+}
@@ -0,0 +1,142 @@
+package dedup
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func alert(fingerprint, status string) common.Alert {
+	return common.Alert{Fingerprint: fingerprint, Status: status}
+}
+
+func TestSuppressWithinWindow(t *testing.T) {
+	d := New(time.Minute)
+	firing := alert("fp1", "firing")
+
+	if !d.Acquire(firing, "feishu", "ops") {
+		t.Fatal("first notification should be sent")
+	}
+	d.Done(firing, "feishu", "ops", nil)
+	if d.Acquire(firing, "feishu", "ops") {
+		t.Error("duplicate notification within window should be suppressed")
+	}
+
+	// 不同目标、不同告警以及状态变化都不去重
+	if !d.Acquire(firing, "feishu", "dev") {
+		t.Error("other target should not be suppressed")
+	}
+	if !d.Acquire(firing, "slack", "ops") {
+		t.Error("other channel should not be suppressed")
+	}
+	if !d.Acquire(alert("fp2", "firing"), "feishu", "ops") {
+		t.Error("other alert should not be suppressed")
+	}
+	resolved := alert("fp1", "resolved")
+	if !d.Acquire(resolved, "feishu", "ops") {
+		t.Error("status change should not be suppressed")
+	}
+	d.Done(resolved, "feishu", "ops", nil)
+	if d.Acquire(resolved, "feishu", "ops") {
+		t.Error("duplicate resolved notification should be suppressed")
+	}
+}
+
+func TestAllowAfterExpiry(t *testing.T) {
+	d := New(50 * time.Millisecond)
+	firing := alert("fp1", "firing")
+
+	if !d.Acquire(firing, "feishu", "ops") {
+		t.Fatal("first notification should be sent")
+	}
+	d.Done(firing, "feishu", "ops", nil)
+	time.Sleep(60 * time.Millisecond)
+	if !d.Acquire(firing, "feishu", "ops") {
+		t.Error("notification after window should be sent")
+	}
+	d.Done(firing, "feishu", "ops", nil)
+
+	// 过期记录会被清理
+	time.Sleep(60 * time.Millisecond)
+	d.Acquire(alert("fp2", "firing"), "feishu", "ops")
+	d.mu.Lock()
+	n := len(d.entries)
+	d.mu.Unlock()
+	if n != 1 {
+		t.Errorf("got %d entries after sweep, want 1", n)
+	}
+}
+
+func TestFailedSendNotSuppressed(t *testing.T) {
+	d := New(time.Minute)
+	firing := alert("fp1", "firing")
+
+	if !d.Acquire(firing, "feishu", "ops") {
+		t.Fatal("first notification should be sent")
+	}
+	// 发送中到达的重复通知被拦截
+	if d.Acquire(firing, "feishu", "ops") {
+		t.Error("concurrent duplicate should be suppressed while sending")
+	}
+	d.Done(firing, "feishu", "ops", errors.New("timeout"))
+	if !d.Acquire(firing, "feishu", "ops") {
+		t.Error("retry after failed send should not be suppressed")
+	}
+
+	// 旧状态的发送结果不影响新状态的记录
+	d.Done(firing, "feishu", "ops", nil)
+	resolved := alert("fp1", "resolved")
+	d.Acquire(resolved, "feishu", "ops")
+	d.Done(firing, "feishu", "ops", errors.New("timeout"))
+	if d.Acquire(resolved, "feishu", "ops") {
+		t.Error("stale Done should not clear the newer status")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	// 没有指纹的告警无法去重
+	d := New(time.Minute)
+	empty := alert("", "firing")
+	for range 3 {
+		if !d.Acquire(empty, "feishu", "ops") {
+			t.Error("alert without fingerprint should not be suppressed")
+		}
+		d.Done(empty, "feishu", "ops", nil)
+	}
+
+	// 窗口为 0 时不去重
+	d = New(0)
+	firing := alert("fp1", "firing")
+	for range 3 {
+		if !d.Acquire(firing, "feishu", "ops") {
+			t.Error("zero window should not suppress")
+		}
+		d.Done(firing, "feishu", "ops", nil)
+	}
+}
+
+func TestConcurrentAcquire(t *testing.T) {
+	d := New(time.Minute)
+	firing := alert("fp1", "firing")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d.Acquire(firing, "feishu", "ops") {
+				mu.Lock()
+				sent++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if sent != 1 {
+		t.Errorf("sent %d times, want 1", sent)
+	}
+}
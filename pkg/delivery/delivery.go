@@ -0,0 +1,81 @@
+// Package delivery 提供各通知渠道共用的告警投递流程：
+// 记录告警状态（历史、升级、抖动），按静默规则、抖动和去重筛选发送目标，
+// 带重试地发送通知，并统一记录发送结果（日志、指标和告警历史）。
+package delivery
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/dedup"
+	"alertmanagerWebhookAdapter/pkg/escalation"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/metrics"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"alertmanagerWebhookAdapter/pkg/quiet"
+	"log"
+)
+
+// Observe 记录收到的告警：写入告警历史，安排或取消告警升级，并返回告警的抖动状态。
+func Observe(alert common.Alert) flapping.Status {
+	history.Observe(alert)
+	escalation.Observe(alert)
+	return flapping.Observe(alert)
+}
+
+// Targets 从渠道的目标中筛选出需要发送的目标：
+// 静默时段内的通知按规则暂缓或改道发送；抖动中的告警每个目标只发送一次抖动提示；
+// 去重窗口内已以相同状态发送过的通知不再重复发送。
+// 事件类渠道（注册了告警发送函数的渠道，如 PagerDuty、Opsgenie）的 resolved 告警需要立即关闭对应的事件，
+// 不经过静默规则和抖动处理，并清除之前暂缓或静默的记录，以免改道、摘要或抖动恢复时再次关闭事件。
+// 返回的每个目标都需要通过 Send 发送，以便记录发送结果。
+func Targets[T any](alert common.Alert, channel string, targets map[string]T) map[string]T {
+	alertName := alert.Labels["alertname"]
+	closes := alert.Status == "resolved" && notify.SendsAlerts(channel)
+	selected := make(map[string]T, len(targets))
+	for name, target := range targets {
+		if closes {
+			// 事件已直接关闭，清除之前暂缓或静默的记录，时间窗口结束或抖动解除时不再重新发送
+			quiet.Discard(alert, channel, name)
+			flapping.Unmute(alert, channel, name)
+		} else {
+			if rule, send := quiet.Apply(alert, channel, name); !send {
+				log.Printf("🌙 Quiet rule %s applied to alert %s (%s) for %s %s", rule, alertName, alert.Status, channel, name)
+				metrics.Suppressed.Inc(channel, name, "quiet")
+				continue
+			}
+			if !flapping.Allow(alert, channel, name) {
+				log.Printf("🔇 Muted flapping alert %s (%s) to %s %s", alertName, alert.Status, channel, name)
+				metrics.Suppressed.Inc(channel, name, "flapping")
+				continue
+			}
+		}
+		if !dedup.Acquire(alert, channel, name) {
+			log.Printf("🔁 Suppressed duplicate alert %s (%s) to %s %s", alertName, alert.Status, channel, name)
+			metrics.Suppressed.Inc(channel, name, "duplicate")
+			continue
+		}
+		selected[name] = target
+	}
+	return selected
+}
+
+// Send 带重试地将告警发送到渠道中的目标，并记录发送结果。
+func Send(alert common.Alert, channel, target string, send func() error) error {
+	err := notify.Retry(send)
+	dedup.Done(alert, channel, target, err)
+	Record(alert, channel, target, err)
+	return err
+}
+
+// Record 记录告警的发送结果：日志、指标和告警历史。
+func Record(alert common.Alert, channel, target string, err error) {
+	metrics.Notifications.Inc(channel, target, metrics.Result(err))
+	history.Notified(alert, channel, target, err)
+
+	alertName := alert.Labels["alertname"]
+	if err != nil {
+		log.Printf("❌ Failed to send alert %s to %s %s: %v", alertName, channel, target, err)
+	} else {
+		log.Printf("✅ Sent alert %s to %s %s", alertName, channel, target)
+	}
+}
@@ -0,0 +1,72 @@
+package delivery
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"alertmanagerWebhookAdapter/pkg/quiet"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestResolvedEventClosesOnce 事件类渠道的 resolved 告警在静默时段和抖动期间直接关闭事件，
+// 抖动解除后不再重复关闭或重新触发事件。时间窗口结束后不再发送暂缓告警的部分见 quiet 包的 TestDiscard。
+func TestResolvedEventClosesOnce(t *testing.T) {
+	var mu sync.Mutex
+	sent := make(map[string]int) // 状态 -> 发送次数
+	notify.RegisterAlert("incident", func(target string, alert common.Alert) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent[alert.Status]++
+		return nil
+	})
+	counts := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent["firing"], sent["resolved"]
+	}
+
+	now := time.Now()
+	config := fmt.Sprintf(`{"rules": [{"name": "maintenance", "channels": ["incident"], "action": "hold", "digest": true,
+		"start": %q, "end": %q}]}`, now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339))
+	path := filepath.Join(t.TempDir(), "quiet.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("QUIET_CONFIG", path)
+	t.Setenv("FLAP_THRESHOLD", "2")
+	t.Setenv("FLAP_STABLE_PERIOD", "100ms")
+	quiet.Init()
+	flapping.Init()
+
+	alert := common.Alert{Labels: map[string]string{"alertname": "DiskFull"}, Fingerprint: "fp-close-once"}
+	resolves := 0
+	// firing 被静默规则暂缓；第二次 firing 时告警开始抖动；最后重复的 resolved 在抖动期间到达
+	for _, status := range []string{"firing", "resolved", "firing", "resolved", "resolved"} {
+		alert.Status = status
+		Observe(alert)
+		for name := range Targets(alert, "incident", map[string]bool{"ops": true}) {
+			if err := Send(alert, "incident", name, func() error {
+				return notify.SendAlert("incident", name, alert, notify.Message{})
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if status == "resolved" {
+			resolves++
+		}
+		if firing, resolved := counts(); firing != 0 || resolved != resolves {
+			t.Fatalf("after %s: firing sent %d, resolved sent %d, want 0 and %d", status, firing, resolved, resolves)
+		}
+	}
+
+	// 抖动解除后不再向事件类渠道重新发送当前状态
+	time.Sleep(300 * time.Millisecond)
+	if firing, resolved := counts(); firing != 0 || resolved != resolves {
+		t.Fatalf("after settle: firing sent %d, resolved sent %d, want 0 and %d", firing, resolved, resolves)
+	}
+}
@@ -0,0 +1,139 @@
+package delivery
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// maxBodyBytes webhook 请求体的大小上限。
+const maxBodyBytes = 16 << 20
+
+// request 等待处理的 webhook 请求。
+type request struct {
+	handler http.HandlerFunc
+	req     *http.Request
+}
+
+var (
+	// queue 等待处理的 webhook 请求队列，未调用 Init 时为 nil，请求在 HTTP 请求中同步处理。
+	queue   chan request
+	workers *sync.WaitGroup // 处理 queue 的后台任务
+)
+
+// Init 从环境变量加载投递队列配置并启动处理请求的后台任务：
+// DELIVERY_QUEUE_SIZE 为队列长度（默认 1000），DELIVERY_WORKERS 为并发处理的请求数（默认 4）。
+func Init() {
+	size := positiveEnv("DELIVERY_QUEUE_SIZE", 1000)
+	n := positiveEnv("DELIVERY_WORKERS", 4)
+
+	q, wg := make(chan request, size), &sync.WaitGroup{}
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range q {
+				r.handler(discardResponse{path: r.req.URL.Path}, r.req)
+			}
+		}()
+	}
+	queue, workers = q, wg
+	log.Printf("✅ Delivery queue enabled: size=%d, workers=%d", size, n)
+}
+
+// positiveEnv 读取正整数环境变量，未配置或无效时使用默认值。
+func positiveEnv(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		log.Printf("⚠️ Invalid %s %q, using default %d", key, val, def)
+		return def
+	}
+	return n
+}
+
+// Async 返回异步处理 webhook 请求的处理函数：校验请求体后放入投递队列并立即返回 200，
+// 由后台任务补充告警信息并发送通知。发送较慢的目标和重试不会使请求超过服务端的写超时，
+// 否则 Alertmanager 会认为通知失败并重新发送整组告警，导致其他目标收到重复的通知。
+// 队列已满时返回 503，由 Alertmanager 稍后重试。
+func Async(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if queue == nil {
+			handler(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			http.Error(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		var payload common.WebhookMessage
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		// 请求结束后 r.Context() 会被取消，后台处理使用新的请求
+		req := r.Clone(context.Background())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		select {
+		case queue <- request{handler: handler, req: req}:
+		default:
+			log.Printf("⚠️ Delivery queue is full, rejecting webhook to %s", r.URL.Path)
+			http.Error(w, "delivery queue is full", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+	}
+}
+
+// Drain 停止接收新的请求，并等待队列中已有的请求处理完成，ctx 结束时不再等待。
+// 调用方需要先停止 HTTP 服务，确保不再有请求放入队列。
+func Drain(ctx context.Context) error {
+	if queue == nil {
+		return nil
+	}
+	close(queue)
+
+	done, wg := make(chan struct{}), workers
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// discardResponse 后台处理请求时使用的响应，请求早已返回，只记录失败的状态码。
+type discardResponse struct {
+	path string
+}
+
+func (d discardResponse) Header() http.Header { return http.Header{} }
+
+func (d discardResponse) Write(b []byte) (int, error) { return len(b), nil }
+
+func (d discardResponse) WriteHeader(status int) {
+	if status >= 400 {
+		log.Printf("⚠️ Webhook to %s failed in background with status %d", d.path, status)
+	}
+}
@@ -0,0 +1,171 @@
+package delivery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPayload = `{"status":"firing","alerts":[{"status":"firing","labels":{"alertname":"HighCPU"}}]}`
+
+// startQueue 在测试期间启动投递队列，测试结束时等待队列处理完成。
+func startQueue(t *testing.T, size, workers string) {
+	t.Helper()
+	t.Setenv("DELIVERY_QUEUE_SIZE", size)
+	t.Setenv("DELIVERY_WORKERS", workers)
+	Init()
+	t.Cleanup(func() {
+		if queue != nil {
+			_ = Drain(context.Background())
+		}
+		queue = nil
+	})
+}
+
+func post(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/slack?target=ops", strings.NewReader(body)))
+	return rec
+}
+
+func TestAsyncReturnsBeforeSending(t *testing.T) {
+	startQueue(t, "10", "1")
+
+	release := make(chan struct{})
+	handled := make(chan string, 1)
+	handler := Async(func(w http.ResponseWriter, r *http.Request) {
+		<-release // 模拟发送较慢的目标
+		var body strings.Builder
+		buf := make([]byte, 1024)
+		for {
+			n, err := r.Body.Read(buf)
+			body.Write(buf[:n])
+			if err != nil {
+				break
+			}
+		}
+		if r.Context().Err() != nil {
+			t.Error("background request context should not be canceled")
+		}
+		handled <- r.URL.Query().Get("target") + " " + body.String()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	start := time.Now()
+	rec := post(handler, testPayload)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Fatalf("response = %d %q, want 200 ok", rec.Code, rec.Body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v, should return before sending", elapsed)
+	}
+
+	close(release)
+	select {
+	case got := <-handled:
+		if got != "ops "+testPayload {
+			t.Errorf("handled %q, want the original query and body", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not handled in background")
+	}
+}
+
+func TestAsyncRejects(t *testing.T) {
+	startQueue(t, "1", "1")
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	handled := 0
+	handler := Async(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+
+	if rec := post(handler, `{"alerts":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid JSON = %d, want 400", rec.Code)
+	}
+
+	// 第一个请求由后台任务处理（阻塞），第二个请求在队列中，第三个请求因队列已满被拒绝
+	if rec := post(handler, testPayload); rec.Code != http.StatusOK {
+		t.Fatalf("first = %d, want 200", rec.Code)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(queue) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if rec := post(handler, testPayload); rec.Code != http.StatusOK {
+		t.Fatalf("second = %d, want 200", rec.Code)
+	}
+	if rec := post(handler, testPayload); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("queue full = %d, want 503", rec.Code)
+	}
+
+	close(release)
+	if err := Drain(context.Background()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	queue = nil
+	if handled != 2 {
+		t.Errorf("handled %d requests, want 2", handled)
+	}
+}
+
+func TestDrainWaitsForQueuedRequests(t *testing.T) {
+	startQueue(t, "10", "2")
+
+	var mu sync.Mutex
+	handled := 0
+	handler := Async(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	for range 5 {
+		if rec := post(handler, testPayload); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+	}
+
+	if err := Drain(context.Background()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	queue = nil
+	if handled != 5 {
+		t.Errorf("handled %d requests after Drain, want 5", handled)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	startQueue(t, "10", "1")
+
+	release := make(chan struct{})
+	defer close(release)
+	handler := Async(func(w http.ResponseWriter, r *http.Request) { <-release })
+	post(handler, testPayload)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Drain = %v, want deadline exceeded", err)
+	}
+	queue = nil
+}
+
+func TestSyncWithoutQueue(t *testing.T) {
+	called := false
+	handler := Async(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusAccepted)
+	})
+	if rec := post(handler, testPayload); rec.Code != http.StatusAccepted || !called {
+		t.Errorf("without Init the handler should run in the request, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,183 @@
+// Package dingtalk 提供通过钉钉自定义机器人发送告警通知的功能。
+package dingtalk
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errcodeTooFast 钉钉机器人的限流错误码（每个机器人每分钟最多发送 20 条消息），可以重试。
+const errcodeTooFast = 130101
+
+// client 发送钉钉消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Button actionCard 消息的跳转按钮。
+type Button struct {
+	Title     string `json:"title"`
+	ActionURL string `json:"actionURL"`
+}
+
+// At 消息中需要 @ 的对象。
+type At struct {
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+// Message 定义了发送到钉钉机器人的消息结构，支持 markdown 和 actionCard 两种类型。
+type Message struct {
+	MsgType  string `json:"msgtype"`
+	Markdown *struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown,omitempty"`
+	ActionCard *struct {
+		Title          string   `json:"title"`
+		Text           string   `json:"text"`
+		BtnOrientation string   `json:"btnOrientation"`
+		Btns           []Button `json:"btns,omitempty"`
+	} `json:"actionCard,omitempty"`
+	At *At `json:"at,omitempty"`
+}
+
+// NewMarkdown 创建一个 markdown 消息，title 用于会话列表中的消息预览。
+func NewMarkdown(title, text string) *Message {
+	msg := &Message{MsgType: common.DingtalkMsgMarkdown}
+	msg.Markdown = &struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	}{Title: title, Text: text}
+	return msg
+}
+
+// NewActionCard 创建一个带跳转按钮的 actionCard 消息。
+// 钉钉要求 actionCard 消息至少有一个按钮，没有按钮时改为创建 markdown 消息。
+func NewActionCard(title, text string, buttons []Button) *Message {
+	if len(buttons) == 0 {
+		return NewMarkdown(title, text)
+	}
+	msg := &Message{MsgType: common.DingtalkMsgActionCard}
+	msg.ActionCard = &struct {
+		Title          string   `json:"title"`
+		Text           string   `json:"text"`
+		BtnOrientation string   `json:"btnOrientation"`
+		Btns           []Button `json:"btns,omitempty"`
+	}{Title: title, Text: text, BtnOrientation: "1", Btns: buttons}
+	return msg
+}
+
+// AddMentions 设置需要 @ 的手机号，并在消息正文末尾添加 @ 标记（钉钉要求正文中包含 @手机号 才会高亮显示）。
+// actionCard 消息不支持 @，此时只添加正文中的文本。
+func (m *Message) AddMentions(mobiles []string, all bool) {
+	if len(mobiles) == 0 && !all {
+		return
+	}
+
+	parts := make([]string, 0, len(mobiles)+1)
+	if all {
+		parts = append(parts, "@所有人")
+	}
+	for _, mobile := range mobiles {
+		parts = append(parts, "@"+mobile)
+	}
+	line := "\n\n**通知**: " + strings.Join(parts, " ")
+
+	switch {
+	case m.Markdown != nil:
+		m.Markdown.Text += line
+		m.At = &At{AtMobiles: mobiles, IsAtAll: all}
+	case m.ActionCard != nil:
+		m.ActionCard.Text += line
+	}
+}
+
+// sign 计算钉钉加签：以密钥对 "timestamp\nsecret" 做 HmacSHA256 后进行 Base64 编码。
+func sign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedURL 返回带 timestamp 和 sign 参数的 webhook 地址，未配置密钥时返回原地址。
+func signedURL(target common.DingtalkTarget, now time.Time) (string, error) {
+	if target.Secret == "" {
+		return target.URL, nil
+	}
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return "", err
+	}
+	timestamp := now.UnixMilli()
+	query := u.Query()
+	query.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	query.Set("sign", sign(target.Secret, timestamp))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Send 发送消息到指定的钉钉机器人，并检查响应中的 errcode。
+// 限流和服务端错误可以重试，其他错误（如签名校验失败、关键词不匹配）不再重试。
+func (m *Message) Send(name string, target common.DingtalkTarget) error {
+	webhookURL, err := signedURL(target, time.Now())
+	if err != nil {
+		return notify.Permanent(fmt.Errorf("invalid dingtalk webhook of %s: %w", name, err))
+	}
+
+	body, _ := json.Marshal(m)
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("dingtalk %s returned %s", name, resp.Status)
+	}
+
+	var result struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", name, err)
+	}
+	if result.Errcode != 0 {
+		err := fmt.Errorf("dingtalk %s returned errcode %d: %s", name, result.Errcode, result.Errmsg)
+		if result.Errcode == errcodeTooFast {
+			return err
+		}
+		return notify.Permanent(err)
+	}
+	return nil
+}
+
+// SendText 发送通用通知消息到指定名称的钉钉目标，供升级、报表等后台任务使用。
+// 通用消息中的 @ 对象为飞书 open_id，钉钉不支持，会被忽略。
+func SendText(name string, msg notify.Message) error {
+	target, ok := common.DingtalkTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("dingtalk target '%s' not found in configuration", name))
+	}
+
+	title := msg.Title
+	if title == "" {
+		title = "告警通知"
+	}
+	return NewMarkdown(title, markdownLines(msg.Text)).Send(name, target)
+}
+
+// markdownLines 将纯文本转换为钉钉 markdown，保留原有的换行。
+func markdownLines(text string) string {
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "  \n")
+}
@@ -0,0 +1,161 @@
+package dingtalk
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSign 按钉钉文档的加签算法计算：stringToSign = timestamp + "\n" + secret，
+// 以 secret 为密钥做 HmacSHA256 后 Base64 编码。
+func TestSign(t *testing.T) {
+	const secret = "SECtest"
+	const timestamp = int64(1700000000000)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1700000000000\n" + secret))
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if got := sign(secret, timestamp); got != want {
+		t.Errorf("sign = %q, want %q", got, want)
+	}
+	if got := sign(secret, timestamp); got != "aZLLrriXgn05YbwaGR7knYsLeJADjr9NwLaNNKpxh4g=" {
+		t.Errorf("sign = %q, want fixed value", got)
+	}
+}
+
+func TestSignedURL(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	const webhook = "https://oapi.dingtalk.com/robot/send?access_token=abc"
+
+	got, err := signedURL(common.DingtalkTarget{URL: webhook}, now)
+	if err != nil || got != webhook {
+		t.Errorf("without secret = %q, %v; want the original URL", got, err)
+	}
+
+	got, err = signedURL(common.DingtalkTarget{URL: webhook, Secret: "SECtest"}, now)
+	if err != nil {
+		t.Fatalf("signedURL: %v", err)
+	}
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("parse %q: %v", got, err)
+	}
+	query := u.Query()
+	if query.Get("access_token") != "abc" {
+		t.Errorf("access_token = %q, want existing query parameters kept", query.Get("access_token"))
+	}
+	if query.Get("timestamp") != "1700000000000" {
+		t.Errorf("timestamp = %q, want milliseconds", query.Get("timestamp"))
+	}
+	// sign 中的 +、/、= 需要 URL 编码，解码后与签名一致
+	if query.Get("sign") != sign("SECtest", 1700000000000) {
+		t.Errorf("sign = %q, want %q", query.Get("sign"), sign("SECtest", 1700000000000))
+	}
+	if u.Host != "oapi.dingtalk.com" || u.Path != "/robot/send" {
+		t.Errorf("URL = %s, want same host and path", got)
+	}
+
+	if _, err := signedURL(common.DingtalkTarget{URL: "://bad", Secret: "SECtest"}, now); err == nil {
+		t.Error("signedURL should fail for an invalid URL")
+	}
+}
+
+func TestSendRetry(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		requests int // 按重试策略发送的请求次数
+		ok       bool
+	}{
+		{"ok", http.StatusOK, `{"errcode":0,"errmsg":"ok"}`, 1, true},
+		{"too fast", http.StatusOK, `{"errcode":130101,"errmsg":"send too fast"}`, 3, false},
+		{"server error", http.StatusBadGateway, ``, 3, false},
+		{"keywords not in content", http.StatusOK, `{"errcode":310000,"errmsg":"keywords not in content"}`, 1, false},
+		{"token not exist", http.StatusOK, `{"errcode":300001,"errmsg":"token is not exist"}`, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			target := common.DingtalkTarget{URL: srv.URL + "/robot/send?access_token=abc"}
+			err := notify.Retry(func() error { return NewMarkdown("title", "text").Send("ops", target) })
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok=%v", err, tt.ok)
+			}
+			if requests != tt.requests {
+				t.Errorf("requests = %d, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestNewActionCardWithoutButtons(t *testing.T) {
+	msg := NewActionCard("title", "text", nil)
+	if msg.MsgType != common.DingtalkMsgMarkdown || msg.Markdown == nil || msg.ActionCard != nil {
+		t.Errorf("message = %+v, want markdown without buttons", msg)
+	}
+
+	msg = NewActionCard("title", "text", []Button{{Title: "打开 Prometheus", ActionURL: "http://prometheus"}})
+	if msg.MsgType != common.DingtalkMsgActionCard || msg.ActionCard == nil || len(msg.ActionCard.Btns) != 1 {
+		t.Errorf("message = %+v, want actionCard with buttons", msg)
+	}
+}
+
+func TestAddMentions(t *testing.T) {
+	buttons := []Button{{Title: "打开 Prometheus", ActionURL: "http://prometheus"}}
+	tests := []struct {
+		name     string
+		msg      *Message
+		mobiles  []string
+		all      bool
+		wantText string
+		wantAt   *At
+	}{
+		{"markdown", NewMarkdown("t", "text"), []string{"13800000000"}, false,
+			"text\n\n**通知**: @13800000000", &At{AtMobiles: []string{"13800000000"}}},
+		{"markdown at all", NewMarkdown("t", "text"), nil, true,
+			"text\n\n**通知**: @所有人", &At{IsAtAll: true}},
+		{"actionCard only text", NewActionCard("t", "text", buttons), []string{"13800000000"}, true,
+			"text\n\n**通知**: @所有人 @13800000000", nil},
+		{"nobody", NewMarkdown("t", "text"), nil, false, "text", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.AddMentions(tt.mobiles, tt.all)
+
+			text := ""
+			if tt.msg.Markdown != nil {
+				text = tt.msg.Markdown.Text
+			} else {
+				text = tt.msg.ActionCard.Text
+			}
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(tt.msg.At, tt.wantAt) {
+				t.Errorf("at = %+v, want %+v", tt.msg.At, tt.wantAt)
+			}
+		})
+	}
+}
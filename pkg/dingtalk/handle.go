@@ -0,0 +1,166 @@
+package dingtalk
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将告警信息发送到指定的钉钉机器人。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的钉钉机器人。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的钉钉机器人
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.DingtalkTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid dingtalk targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "dingtalk", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		msg := buildMessage(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标
+		for name, target := range sendTargets {
+			_ = delivery.Send(alert, "dingtalk", name, func() error {
+				return msg.Send(name, target)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// buildMessage 为单个告警构建钉钉消息，补充触发日志和指标趋势，并按配置 @ 相关负责人。
+func buildMessage(alert common.Alert, externalURL string, flap flapping.Status) *Message {
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "未知告警"
+	}
+
+	status := alert.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	summary := alert.Annotations["summary"]
+	if summary == "" {
+		summary = "无摘要信息"
+	}
+
+	desc := alert.Annotations["description"]
+	if desc == "" {
+		desc = "无详细描述"
+	}
+
+	severity := alert.Labels["severity"]
+
+	// 尝试从 Loki 查询实际日志内容
+	triggerLogs := common.TriggerLogs(alert, "（Loki 日志查询失败: %v）", "（查询时间范围内无匹配日志）")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	metricTrend := common.MetricTrendText(alert)
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(status), alertName)
+	if flap.Flapping {
+		title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(status), alertName)
+	}
+
+	// 钉钉 markdown 中的换行需要在行尾添加两个空格
+	lines := []string{fmt.Sprintf("**状态**: %s", status)}
+	if severity != "" {
+		lines = append(lines, fmt.Sprintf("**级别**: %s", severity))
+	}
+	lines = append(lines, fmt.Sprintf("**摘要**: %s", summary))
+	if !alert.StartsAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("**开始时间**: %s", alert.StartsAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	if status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("**恢复时间**: %s", alert.EndsAt.Local().Format("2006-01-02 15:04:05")))
+		lines = append(lines, fmt.Sprintf("**持续时间**: %s", alert.EndsAt.Sub(alert.StartsAt).Round(time.Second)))
+	}
+	lines = append(lines, fmt.Sprintf("**详情**: %s", desc))
+	if metricTrend != "" {
+		lines = append(lines, fmt.Sprintf("**指标趋势**: %s", metricTrend))
+	}
+	text := fmt.Sprintf("### %s %s\n\n%s", statusIcon(status), title, strings.Join(lines, "  \n"))
+
+	// 如果有触发日志信息，则以代码块显示
+	if triggerLogs != "" {
+		text += fmt.Sprintf("\n\n**触发日志**:\n\n```\n%s\n```", strings.TrimRight(triggerLogs, "\n"))
+	}
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		text += fmt.Sprintf("\n\n> 🔀 %s", flap)
+	}
+
+	var msg *Message
+	if common.DingtalkMsgType == common.DingtalkMsgActionCard {
+		var buttons []Button
+		if alert.GeneratorURL != "" {
+			buttons = append(buttons, Button{Title: "打开 Prometheus", ActionURL: alert.GeneratorURL})
+		}
+		if externalURL != "" {
+			buttons = append(buttons, Button{Title: "打开 Alertmanager", ActionURL: externalURL})
+		}
+		msg = NewActionCard(title, text, buttons)
+	} else {
+		msg = NewMarkdown(title, text)
+	}
+
+	// 根据告警标签和级别 @ 相关负责人
+	msg.AddMentions(mentionsFor(alert))
+	return msg
+}
+
+// statusIcon 返回告警状态对应的图标。
+func statusIcon(status string) string {
+	if status == "resolved" {
+		return "✅"
+	}
+	return "🚨"
+}
@@ -0,0 +1,50 @@
+package dingtalk
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mentionsFor 根据告警标签和级别计算需要 @ 的手机号，包括标签映射的用户和当前值班人，
+// 以及是否需要 @所有人。与飞书相同，只有 firing 状态且级别不低于 min_severity 的告警才会 @ 人。
+func mentionsFor(alert common.Alert) (mobiles []string, all bool) {
+	if (!common.MentionConfig.Enabled && common.Oncall == nil) || alert.Status != "firing" {
+		return nil, false
+	}
+
+	severity := alert.Labels["severity"]
+	if common.SeverityRank(severity) < common.SeverityRank(common.MentionConfig.MinSeverity) {
+		return nil, false
+	}
+
+	for _, s := range common.MentionConfig.AtAll {
+		if strings.EqualFold(s, severity) {
+			all = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range common.MentionConfig.Rules {
+		if value, ok := alert.Labels[rule.Label]; !ok || value != rule.Value {
+			continue
+		}
+		for _, mobile := range rule.Mobiles {
+			seen[mobile] = true
+		}
+	}
+
+	// 当前值班人
+	for _, member := range common.Oncall.OnCall(alert.Labels, time.Now()) {
+		if member.Mobile != "" {
+			seen[member.Mobile] = true
+		}
+	}
+
+	for mobile := range seen {
+		mobiles = append(mobiles, mobile)
+	}
+	sort.Strings(mobiles)
+	return mobiles, all
+}
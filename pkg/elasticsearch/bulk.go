@@ -0,0 +1,235 @@
+package elasticsearch
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// item 等待写入的一个文档，对应 _bulk 请求中的 action 行和文档行。
+type item struct {
+	target string
+	action []byte
+	source []byte
+	done   func(err error) // 文档写入成功或最终失败后调用，用于记录发送结果
+}
+
+// finish 记录文档的写入结果。
+func (i item) finish(err error) {
+	if i.done != nil {
+		i.done(err)
+	}
+}
+
+// size 返回文档在 _bulk 请求体中占用的字节数。
+func (i item) size() int {
+	return len(i.action) + len(i.source) + 2
+}
+
+var (
+	queue   chan item     // 等待写入的文档队列
+	stopped chan struct{} // run 写入队列中剩余的文档后关闭
+	closing bool          // Drain 已关闭 queue，不再接收新的文档
+	queueMu sync.RWMutex  // 保护 queue 和 closing，避免向已关闭的 queue 发送文档
+)
+
+// start 创建写入队列并启动后台写入任务。
+func start(size int) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	queue, stopped, closing = make(chan item, size), make(chan struct{}), false
+	go run(queue, stopped)
+}
+
+// Drain 停止接收新的文档，并等待队列中已有的文档写入完成，ctx 结束时不再等待。
+// 用于退出前写入剩余的文档，之后发送到 Elasticsearch 目标会失败。
+func Drain(ctx context.Context) error {
+	queueMu.Lock()
+	if queue == nil || closing {
+		queueMu.Unlock()
+		return nil
+	}
+	closing = true
+	close(queue)
+	queueMu.Unlock()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// errQueueFull 队列已满，可以稍后重试。
+var errQueueFull = errors.New("elasticsearch queue is full")
+
+// enqueue 将文档放入写入队列，不等待写入完成。
+func enqueue(it item) error {
+	queueMu.RLock()
+	defer queueMu.RUnlock()
+	if queue == nil {
+		return notify.Permanent(fmt.Errorf("elasticsearch target '%s': elasticsearch is not initialized", it.target))
+	}
+	if closing {
+		return notify.Permanent(fmt.Errorf("elasticsearch target '%s': elasticsearch is shutting down", it.target))
+	}
+	select {
+	case queue <- it:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// run 从队列中读取文档并攒批，文档数量或请求体大小达到上限、或距离上次写入超过刷新间隔时写入一批；
+// 队列关闭后写入剩余的文档并关闭 stopped。
+func run(queue <-chan item, stopped chan<- struct{}) {
+	cfg := common.ElasticsearchConfig
+	ticker := time.NewTicker(cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []item
+	size := 0
+	flushBatch := func() {
+		if len(batch) > 0 {
+			flush(batch)
+		}
+		batch, size = nil, 0
+	}
+
+	add := func(it item) {
+		if len(batch) > 0 && size+it.size() > cfg.BulkBytes {
+			flushBatch()
+		}
+		batch = append(batch, it)
+		size += it.size()
+		if len(batch) >= cfg.BulkActions {
+			flushBatch()
+		}
+	}
+
+	for {
+		select {
+		case it, ok := <-queue:
+			if !ok {
+				flushBatch()
+				close(stopped)
+				return
+			}
+			add(it)
+		case <-ticker.C:
+			flushBatch()
+		}
+	}
+}
+
+// flush 写入一批文档，按发送重试策略重试整个请求的失败和部分文档的可恢复失败（429、5xx），
+// 并记录每个文档的写入结果。
+func flush(batch []item) {
+	pending := batch
+	rejected := 0
+	err := notify.Retry(func() error {
+		retry, n, err := bulk(pending)
+		rejected += n
+		if err != nil {
+			return err
+		}
+		if len(retry) > 0 {
+			pending = retry
+			return fmt.Errorf("%d documents failed with retryable errors", len(retry))
+		}
+		pending = nil
+		return nil
+	})
+	if err != nil {
+		for _, it := range pending {
+			it.finish(err)
+		}
+		log.Printf("❌ Failed to index %d of %d documents to elasticsearch: %v", len(pending)+rejected, len(batch), err)
+		return
+	}
+	if rejected > 0 {
+		log.Printf("⚠️ Indexed %d documents to elasticsearch, %d rejected", len(batch)-rejected, rejected)
+		return
+	}
+	log.Printf("✅ Indexed %d documents to elasticsearch", len(batch))
+}
+
+// bulkResponse _bulk API 的响应，items 与请求中的文档一一对应。
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Index  string `json:"_index"`
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk 发送一次 _bulk 请求，返回需要重试的文档和被拒绝的文档数量。
+// 写入成功和不可恢复的文档错误（如字段映射冲突、索引名称无效）记录文档的写入结果，被拒绝的文档记录日志后丢弃；
+// 整个请求失败时不记录，由调用方重试。
+func bulk(batch []item) (retry []item, rejected int, err error) {
+	var body bytes.Buffer
+	for _, it := range batch {
+		body.Write(it.action)
+		body.WriteByte('\n')
+		body.Write(it.source)
+		body.WriteByte('\n')
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.ElasticsearchConfig.Timeout)
+	defer cancel()
+	resp, err := post(ctx, "/_bulk", "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, statusError(resp)
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode elasticsearch bulk response: %w", err)
+	}
+	if !result.Errors {
+		for _, it := range batch {
+			it.finish(nil)
+		}
+		return nil, 0, nil
+	}
+	if len(result.Items) != len(batch) {
+		return nil, 0, notify.Permanent(fmt.Errorf("elasticsearch bulk response has %d items for %d documents", len(result.Items), len(batch)))
+	}
+
+	for i, entry := range result.Items {
+		for _, r := range entry {
+			switch {
+			case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+				retry = append(retry, batch[i])
+			case r.Error != nil:
+				rejected++
+				log.Printf("❌ Elasticsearch rejected document %s in %s for %s: %s: %s",
+					r.ID, r.Index, batch[i].target, r.Error.Type, r.Error.Reason)
+				batch[i].finish(notify.Permanent(fmt.Errorf("elasticsearch rejected document %s in %s: %s: %s",
+					r.ID, r.Index, r.Error.Type, r.Error.Reason)))
+			default:
+				batch[i].finish(nil)
+			}
+		}
+	}
+	return retry, rejected, nil
+}
@@ -0,0 +1,226 @@
+package elasticsearch
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBulk 模拟 _bulk API：按文档 ID 返回结果，ok 写入成功，bad 返回映射错误，
+// busy 第一次返回 429、之后写入成功。
+type fakeBulk struct {
+	mu       sync.Mutex
+	requests int
+	busy     int
+	docs     []string // 写入成功的文档 ID
+}
+
+func newFakeBulk(t *testing.T) *fakeBulk {
+	f := &fakeBulk{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			http.NotFound(w, r)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests++
+
+		var items []string
+		hasErrors := false
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				t.Errorf("invalid action line: %s", scanner.Text())
+			}
+			scanner.Scan() // 文档行
+			for op, meta := range action {
+				status, errJSON := http.StatusCreated, ""
+				switch {
+				case strings.HasPrefix(meta.ID, "bad"):
+					status, errJSON = http.StatusBadRequest, `,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}`
+				case strings.HasPrefix(meta.ID, "busy") && f.busy == 0:
+					f.busy++
+					status, errJSON = http.StatusTooManyRequests, `,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}`
+				default:
+					f.docs = append(f.docs, meta.ID)
+				}
+				hasErrors = hasErrors || errJSON != ""
+				items = append(items, fmt.Sprintf(`{%q:{"_index":%q,"_id":%q,"status":%d%s}}`, op, meta.Index, meta.ID, status, errJSON))
+			}
+		}
+		fmt.Fprintf(w, `{"took":1,"errors":%v,"items":[%s]}`, hasErrors, strings.Join(items, ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	common.ElasticsearchConfig.URLs = []string{srv.URL}
+	common.ElasticsearchConfig.Timeout = 5 * time.Second
+	client = srv.Client()
+	return f
+}
+
+// results 记录每个文档的写入结果。
+type results struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (r *results) item(id string) item {
+	action, _ := json.Marshal(bulkAction("alerts", id))
+	return item{
+		target: "search",
+		action: action,
+		source: []byte(`{"doc":{},"doc_as_upsert":true}`),
+		done: func(err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if _, ok := r.errs[id]; ok {
+				panic("document result recorded twice: " + id)
+			}
+			r.errs[id] = err
+		},
+	}
+}
+
+// TestFlushReportsPerDocument 每个文档的写入结果在写入完成后单独记录：写入成功、被拒绝，或重试后写入成功。
+func TestFlushReportsPerDocument(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+	f := newFakeBulk(t)
+
+	r := &results{errs: make(map[string]error)}
+	flush([]item{r.item("ok-1"), r.item("bad-1"), r.item("busy-1"), r.item("ok-2")})
+
+	if f.requests != 2 {
+		t.Errorf("requests = %d, want 2 (retry only the 429 document)", f.requests)
+	}
+	if got := strings.Join(f.docs, ","); got != "ok-1,ok-2,busy-1" {
+		t.Errorf("indexed %s, want ok-1,ok-2,busy-1", got)
+	}
+	if len(r.errs) != 4 {
+		t.Fatalf("recorded %d results, want 4", len(r.errs))
+	}
+	for _, id := range []string{"ok-1", "ok-2", "busy-1"} {
+		if r.errs[id] != nil {
+			t.Errorf("%s: err = %v, want nil", id, r.errs[id])
+		}
+	}
+	if err := r.errs["bad-1"]; err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("bad-1: err = %v, want mapping error", err)
+	}
+}
+
+// TestFlushRequestFailure 整个请求重试后仍然失败时，所有文档记录失败。
+func TestFlushRequestFailure(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "2")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, `{"error":"unavailable"}`, http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	common.ElasticsearchConfig.URLs = []string{srv.URL}
+	common.ElasticsearchConfig.Timeout = 5 * time.Second
+	client = srv.Client()
+
+	r := &results{errs: make(map[string]error)}
+	flush([]item{r.item("a"), r.item("b")})
+
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+	if len(r.errs) != 2 || r.errs["a"] == nil || r.errs["b"] == nil {
+		t.Errorf("results = %v, want both documents failed", r.errs)
+	}
+}
+
+// TestHandlerWritesEveryAlert 每个告警都写入，包括相同状态的重复通知。
+func TestHandlerWritesEveryAlert(t *testing.T) {
+	f := newFakeBulk(t)
+	common.ElasticsearchTargets = map[string]string{"search": "alerts-%{+yyyy.MM.dd}"}
+	common.ElasticsearchConfig.BulkActions = 100
+	common.ElasticsearchConfig.BulkBytes = 1 << 20
+	common.ElasticsearchConfig.FlushInterval = 10 * time.Millisecond
+	common.ElasticsearchConfig.QueueSize = 10
+	start(common.ElasticsearchConfig.QueueSize)
+
+	payload := `{"receiver":"es","status":"firing","alerts":[{"status":"firing",
+		"labels":{"alertname":"HighCPU","severity":"critical"},
+		"startsAt":"2026-10-19T06:00:00Z","fingerprint":"ok"}]}`
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("POST", "/elasticsearch", strings.NewReader(payload)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.mu.Lock()
+		n := len(f.docs)
+		f.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("indexed %d documents, want 2", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if want := "ok-1792389600000"; f.docs[0] != want {
+		t.Errorf("document ID = %q, want %q", f.docs[0], want)
+	}
+}
+
+// TestDrainFlushesQueue 退出时写入队列中还没有达到批量条件的文档，之后不再接收新的文档。
+func TestDrainFlushesQueue(t *testing.T) {
+	f := newFakeBulk(t)
+	common.ElasticsearchConfig.BulkActions = 100
+	common.ElasticsearchConfig.BulkBytes = 1 << 20
+	common.ElasticsearchConfig.FlushInterval = time.Hour
+	start(10)
+
+	r := &results{errs: make(map[string]error)}
+	for _, id := range []string{"ok-1", "ok-2", "ok-3"} {
+		if err := enqueue(r.item(id)); err != nil {
+			t.Fatalf("enqueue %s: %v", id, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if got := strings.Join(f.docs, ","); got != "ok-1,ok-2,ok-3" || f.requests != 1 {
+		t.Errorf("indexed %q in %d requests, want ok-1,ok-2,ok-3 in one request", got, f.requests)
+	}
+	if len(r.errs) != 3 {
+		t.Errorf("recorded %d results, want 3", len(r.errs))
+	}
+
+	if err := enqueue(r.item("late")); err == nil || !strings.Contains(err.Error(), "shutting down") {
+		t.Errorf("enqueue after Drain = %v, want shutting down error", err)
+	}
+	if err := Drain(ctx); err != nil {
+		t.Errorf("second Drain = %v, want nil", err)
+	}
+}
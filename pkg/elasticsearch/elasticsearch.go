@@ -0,0 +1,84 @@
+// Package elasticsearch 提供将告警写入 Elasticsearch/OpenSearch 索引的功能，供检索和制作仪表盘。
+// 告警先进入内存队列，由后台任务按数量、大小和时间攒批后通过 _bulk API 写入；
+// 文档 ID 由告警指纹和开始时间生成，resolved 告警更新 firing 时写入的同一文档。
+package elasticsearch
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// client 访问集群使用的 HTTP 客户端，未配置 Elasticsearch 目标或创建失败时为 nil。
+var client *http.Client
+
+// next 下一次请求首先尝试的节点序号，请求轮流发送到各个节点。
+var next atomic.Uint32
+
+// Init 按 Elasticsearch 配置创建 HTTP 客户端，并启动后台批量写入任务。
+func Init() {
+	cfg := common.ElasticsearchConfig
+	if len(common.ElasticsearchTargets) == 0 || len(cfg.URLs) == 0 {
+		return
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLS.Enabled {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			log.Printf("❌ Invalid elasticsearch TLS config, elasticsearch disabled: %v", err)
+			return
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	client = &http.Client{Timeout: cfg.Timeout, Transport: transport}
+
+	start(cfg.QueueSize)
+}
+
+// post 发送 POST 请求到集群，节点无法连接时依次尝试其他节点。
+// 返回的响应可能是错误状态码，由调用方处理。
+func post(ctx context.Context, path, contentType string, body []byte) (*http.Response, error) {
+	cfg := common.ElasticsearchConfig
+	start := int(next.Add(1)) - 1
+	var lastErr error
+	for i := range cfg.URLs {
+		base := cfg.URLs[(start+i)%len(cfg.URLs)]
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, notify.Permanent(fmt.Errorf("failed to create elasticsearch request: %w", err))
+		}
+		req.Header.Set("Content-Type", contentType)
+		if cfg.APIKey != "" {
+			req.Header.Set("Authorization", "ApiKey "+cfg.APIKey)
+		} else if cfg.Username != "" {
+			req.SetBasicAuth(cfg.Username, cfg.Password)
+		}
+
+		resp, err := client.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("failed to reach elasticsearch: %w", lastErr)
+}
+
+// statusError 根据响应状态码返回错误：429 和 5xx 可以重试，其他错误（认证失败、请求无效等）不再重试。
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("elasticsearch returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return notify.Permanent(err)
+}
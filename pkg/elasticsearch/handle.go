@@ -0,0 +1,110 @@
+package elasticsearch
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将每个告警作为一个文档放入写入队列，由后台任务批量写入指定目标的索引。
+// 如果请求中包含 target 参数，则只写入指定的目标；
+// 如果没有指定，则默认写入所有已配置的 Elasticsearch 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只写入指定目标，否则写入所有配置的 Elasticsearch 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.ElasticsearchTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid elasticsearch targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警，每个告警都写入，不经过静默规则、抖动和去重筛选
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		doc := newDocument(common.NewAlertEvent(payload, alert, flap.Flapping))
+		source, err := upsertSource(doc)
+		if err != nil {
+			err = notify.Permanent(fmt.Errorf("failed to encode elasticsearch document: %w", err))
+			for name := range targets {
+				delivery.Record(alert, "elasticsearch", name, err)
+			}
+			continue
+		}
+
+		// 写入所有目标
+		for name, pattern := range targets {
+			action, err := json.Marshal(bulkAction(indexName(pattern, doc.EventTime), documentID(alert)))
+			if err != nil {
+				delivery.Record(alert, "elasticsearch", name, notify.Permanent(fmt.Errorf("failed to encode elasticsearch action: %w", err)))
+				continue
+			}
+			write(alert, item{target: name, action: action, source: source})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// write 将告警的文档放入写入队列，文档写入成功或最终失败后记录发送结果（日志、指标和告警历史）；
+// 队列已满时按发送重试策略重试，仍无法放入队列时记录失败。
+func write(alert common.Alert, it item) {
+	it.done = func(err error) {
+		delivery.Record(alert, "elasticsearch", it.target, err)
+	}
+	if err := notify.Retry(func() error { return enqueue(it) }); err != nil {
+		it.done(err)
+	}
+}
+
+// bulkAction 返回文档的 _bulk action：有 ID 的文档以 update 方式写入，不存在时插入（upsert），
+// 同一告警的 resolved 通知更新 firing 时写入的文档；没有 ID 的文档以 index 方式写入新文档。
+func bulkAction(index, id string) map[string]interface{} {
+	if id == "" {
+		return map[string]interface{}{"index": map[string]interface{}{"_index": index}}
+	}
+	return map[string]interface{}{"update": map[string]interface{}{
+		"_index":            index,
+		"_id":               id,
+		"retry_on_conflict": 3,
+	}}
+}
+
+// upsertSource 返回文档在 _bulk 请求中的文档行：update 操作为 {"doc": ..., "doc_as_upsert": true}，
+// 没有指纹的文档（index 操作）直接使用文档本身。
+func upsertSource(doc document) ([]byte, error) {
+	if documentID(doc.Alert) == "" {
+		return json.Marshal(doc)
+	}
+	return json.Marshal(map[string]interface{}{"doc": doc, "doc_as_upsert": true})
+}
@@ -0,0 +1,59 @@
+package elasticsearch
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// datePattern 索引名称中的日期占位符，如 %{+yyyy.MM.dd}。
+var datePattern = regexp.MustCompile(`%\{\+([^}]+)\}`)
+
+// dateLayout 将日期占位符中的格式转换为 Go 的时间格式。
+var dateLayout = strings.NewReplacer("yyyy", "2006", "yy", "06", "MM", "01", "dd", "02", "HH", "15")
+
+// indexName 按时间（UTC）替换索引名称中的日期占位符，索引名称必须为小写。
+func indexName(pattern string, t time.Time) string {
+	name := datePattern.ReplaceAllStringFunc(pattern, func(m string) string {
+		layout := datePattern.FindStringSubmatch(m)[1]
+		return t.UTC().Format(dateLayout.Replace(layout))
+	})
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// documentID 返回告警文档的 ID。Alertmanager 对同一告警的多次触发使用相同的指纹和不同的 startsAt，
+// 因此同一次触发的 firing 和 resolved 通知写入同一文档，再次触发时写入新文档。没有指纹的告警返回空字符串。
+func documentID(alert common.Alert) string {
+	if alert.Fingerprint == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", alert.Fingerprint, alert.StartsAt.UnixMilli())
+}
+
+// document 告警文档：告警事件的字段，加上便于检索和制作仪表盘的字段。
+type document struct {
+	common.AlertEvent
+	EventTime       time.Time `json:"@timestamp"` // 告警开始时间，作为 Kibana/OpenSearch Dashboards 的时间字段
+	AlertName       string    `json:"alertname,omitempty"`
+	Severity        string    `json:"severity,omitempty"`
+	DurationSeconds float64   `json:"durationSeconds,omitempty"` // resolved 告警的持续时间
+}
+
+// newDocument 从告警事件创建文档。
+func newDocument(event common.AlertEvent) document {
+	doc := document{
+		AlertEvent: event,
+		EventTime:  event.StartsAt,
+		AlertName:  event.Labels["alertname"],
+		Severity:   event.Labels["severity"],
+	}
+	if doc.EventTime.IsZero() {
+		doc.EventTime = event.Timestamp
+	}
+	if event.Status == "resolved" && !event.StartsAt.IsZero() && event.EndsAt.After(event.StartsAt) {
+		doc.DurationSeconds = event.EndsAt.Sub(event.StartsAt).Seconds()
+	}
+	return doc
+}
@@ -0,0 +1,193 @@
+// Package email 提供通过 SMTP 发送告警邮件的功能。
+// 邮件同时包含纯文本和 HTML 正文，同一告警的邮件通过 Message-ID 和 In-Reply-To 归入同一会话。
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// recipients 返回目标的收件人：邮箱地址直接使用，label:<标签名> 从告警的标签中读取（多个用逗号分隔）。
+// alert 为 nil 时（后台任务发送的通用消息）忽略标签收件人。
+func recipients(target []string, alert *common.Alert) []string {
+	seen := make(map[string]bool)
+	var result []string
+	add := func(addr string) {
+		addr = strings.TrimSpace(addr)
+		if addr != "" && !seen[strings.ToLower(addr)] {
+			seen[strings.ToLower(addr)] = true
+			result = append(result, addr)
+		}
+	}
+
+	for _, r := range target {
+		label, ok := strings.CutPrefix(r, "label:")
+		if !ok {
+			add(r)
+			continue
+		}
+		if alert == nil {
+			continue
+		}
+		for _, addr := range strings.Split(alert.Labels[label], ",") {
+			add(addr)
+		}
+	}
+	return result
+}
+
+// sendMessage 补全发件人、收件人和日期后发送邮件。
+func sendMessage(name string, to []string, msg *Message) error {
+	if len(to) == 0 {
+		return notify.Permanent(fmt.Errorf("no recipients for email target '%s'", name))
+	}
+	rcpt, err := addresses(to)
+	if err != nil {
+		return notify.Permanent(err)
+	}
+
+	msg.From = common.SMTPConfig.From
+	msg.To = to
+	msg.Date = time.Now()
+	data, err := msg.Bytes()
+	if err != nil {
+		return notify.Permanent(fmt.Errorf("failed to build email: %w", err))
+	}
+
+	from, err := addresses([]string{msg.From})
+	if err != nil {
+		return notify.Permanent(err)
+	}
+	return send(from[0], rcpt, data)
+}
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将每个告警作为一封邮件发送到指定目标的收件人。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的邮件目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的邮件目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.EmailTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid email targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "email", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		data := newTemplateData(alert, payload.ExternalURL)
+		if flap.Flapping {
+			data.Flapping = flap.String()
+		}
+		subject, text, html, renderErr := render(data)
+
+		// 发送到所有目标
+		for name, target := range sendTargets {
+			msg := &Message{Subject: subject, Text: text, HTML: html}
+			thread(msg, alert, name)
+			err := delivery.Send(alert, "email", name, func() error {
+				if renderErr != nil {
+					return notify.Permanent(renderErr)
+				}
+				return sendMessage(name, recipients(target, &alert), msg)
+			})
+			if err != nil {
+				release(msg)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// newTemplateData 获取告警的字段值，提供默认值，并补充触发日志和指标趋势。
+func newTemplateData(alert common.Alert, externalURL string) TemplateData {
+	data := TemplateData{
+		Alert:        alert,
+		AlertName:    alert.Labels["alertname"],
+		Status:       alert.Status,
+		Severity:     alert.Labels["severity"],
+		Summary:      alert.Annotations["summary"],
+		Description:  alert.Annotations["description"],
+		ExternalURL:  externalURL,
+		GeneratorURL: alert.GeneratorURL,
+	}
+	if data.AlertName == "" {
+		data.AlertName = "未知告警"
+	}
+	if data.Status == "" {
+		data.Status = "unknown"
+	}
+	if data.Summary == "" {
+		data.Summary = "无摘要信息"
+	}
+	if data.Description == "" {
+		data.Description = "无详细描述"
+	}
+	if data.Status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		data.Duration = alert.EndsAt.Sub(alert.StartsAt).Round(time.Second)
+	}
+
+	// 尝试从 Loki 查询实际日志内容
+	data.TriggerLogs = common.TriggerLogs(alert, "（Loki 日志查询失败: %v）", "（查询时间范围内无匹配日志）")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	data.MetricTrend = common.MetricTrendText(alert)
+	return data
+}
+
+// SendText 发送通用通知消息到指定名称的邮件目标，供升级、报表等后台任务使用。
+// 只发送给目标中配置的邮箱地址，label:<标签名> 形式的收件人会被忽略。
+func SendText(name string, msg notify.Message) error {
+	target, ok := common.EmailTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("email target '%s' not found in configuration", name))
+	}
+
+	subject := msg.Title
+	if subject == "" {
+		subject = "告警通知"
+	}
+	return sendMessage(name, recipients(target, nil), &Message{Subject: subject, Text: msg.Text, MessageID: uniqueID()})
+}
@@ -0,0 +1,136 @@
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// parseMail 解析测试服务器收到的邮件，返回邮件头和各部分的 Content-Type 及解码后的正文。
+func parseMail(t *testing.T, data string) (mail.Header, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type: %v", err)
+	}
+	parts := make(map[string]string)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		parts[mediaType] = string(body)
+		return msg.Header, parts
+	}
+	if mediaType != "multipart/alternative" {
+		t.Errorf("Content-Type = %s, want multipart/alternative", mediaType)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var order []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		// multipart.Reader 会自动解码 quoted-printable 正文
+		body, _ := io.ReadAll(p)
+		parts[partType] = string(body)
+		order = append(order, partType)
+	}
+	if strings.Join(order, ",") != "text/plain,text/html" {
+		t.Errorf("parts = %v, want text/plain before text/html", order)
+	}
+	return msg.Header, parts
+}
+
+const firingPayload = `{"receiver":"mail","status":"firing","externalURL":"http://am:9093","alerts":[{
+	"status":"firing",
+	"labels":{"alertname":"HighCPU","severity":"critical","instance":"node-1","owner_email":"owner@example.com"},
+	"annotations":{"summary":"CPU 使用率过高","description":"CPU > 90% <script>"},
+	"startsAt":"2026-10-19T06:00:00Z","fingerprint":"abc123"}]}`
+
+const resolvedPayload = `{"receiver":"mail","status":"resolved","alerts":[{
+	"status":"resolved",
+	"labels":{"alertname":"HighCPU","severity":"critical","instance":"node-1","owner_email":"owner@example.com"},
+	"annotations":{"summary":"CPU 使用率过高","description":"CPU > 90% <script>"},
+	"startsAt":"2026-10-19T06:00:00Z","endsAt":"2026-10-19T06:30:00Z","fingerprint":"abc123"}]}`
+
+func TestHandlerMultipartAndThreading(t *testing.T) {
+	stub := newSMTPStub(t, common.SMTPStartTLS)
+	common.EmailTargets = map[string][]string{"ops": {"ops@example.com", "label:owner_email"}}
+
+	for _, payload := range []string{firingPayload, resolvedPayload} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("POST", "/email?target=ops", strings.NewReader(payload)))
+		if rec.Code != 200 {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+
+	mails := stub.received()
+	if len(mails) != 2 {
+		t.Fatalf("received %d mails, want 2", len(mails))
+	}
+	if got := strings.Join(mails[0].To, ","); got != "ops@example.com,owner@example.com" {
+		t.Errorf("recipients = %s, want target and label recipients", got)
+	}
+
+	firing, firingParts := parseMail(t, mails[0].Data)
+	resolved, resolvedParts := parseMail(t, mails[1].Data)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(firing.Get("Subject"))
+	if err != nil || subject != "[FIRING][critical] HighCPU" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if !strings.Contains(firingParts["text/plain"], "摘要: CPU 使用率过高") {
+		t.Errorf("text part = %q", firingParts["text/plain"])
+	}
+	if html := firingParts["text/html"]; !strings.Contains(html, "&lt;script&gt;") || strings.Contains(html, "<script>") {
+		t.Errorf("html part should escape annotations: %q", html)
+	}
+	if !strings.Contains(resolvedParts["text/plain"], "持续时间: 30m0s") {
+		t.Errorf("resolved text part = %q", resolvedParts["text/plain"])
+	}
+
+	// 恢复邮件回复告警的首封邮件，归入同一会话
+	root := firing.Get("Message-ID")
+	if !strings.HasPrefix(root, "<alert.abc123.") || !strings.HasSuffix(root, ".ops@example.com>") {
+		t.Errorf("firing Message-ID = %q, want thread root", root)
+	}
+	if firing.Get("In-Reply-To") != "" {
+		t.Errorf("firing mail should not reply to anything, In-Reply-To = %q", firing.Get("In-Reply-To"))
+	}
+	if resolved.Get("In-Reply-To") != root || resolved.Get("References") != root {
+		t.Errorf("resolved In-Reply-To = %q, References = %q, want %q",
+			resolved.Get("In-Reply-To"), resolved.Get("References"), root)
+	}
+	if id := resolved.Get("Message-ID"); id == "" || id == root {
+		t.Errorf("resolved Message-ID = %q, want a new unique ID", id)
+	}
+}
+
+func TestMessageTextOnly(t *testing.T) {
+	msg := &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "测试", Text: "line1\nline2"}
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	header, parts := parseMail(t, string(data))
+	if header.Get("Message-ID") != "" || header.Get("In-Reply-To") != "" {
+		t.Error("empty IDs should not produce headers")
+	}
+	if parts["text/plain"] != "line1\r\nline2" {
+		t.Errorf("body = %q", parts["text/plain"])
+	}
+}
@@ -0,0 +1,105 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message 一封邮件，包含纯文本和 HTML 两种格式的正文。
+type Message struct {
+	From      string
+	To        []string
+	Subject   string
+	Text      string // 纯文本正文
+	HTML      string // HTML 正文，为空时只发送纯文本
+	MessageID string // 邮件的 Message-ID，包含尖括号
+	InReplyTo string // 回复的邮件 Message-ID，用于将恢复通知归入告警邮件所在的会话
+	Date      time.Time
+}
+
+// Bytes 生成 RFC 5322 格式的邮件内容，同时包含纯文本和 HTML 正文时使用 multipart/alternative。
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	if m.MessageID != "" {
+		header("Message-ID", m.MessageID)
+	}
+	if m.InReplyTo != "" {
+		header("In-Reply-To", m.InReplyTo)
+		header("References", m.InReplyTo)
+	}
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable 以 quoted-printable 编码写入正文，换行统一为 CRLF。
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// addresses 解析收件人列表中的邮箱地址，返回用于 RCPT TO 的地址。
+func addresses(list []string) ([]string, error) {
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		addr, err := mail.ParseAddress(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", item, err)
+		}
+		result = append(result, addr.Address)
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// loginAuth 实现 LOGIN 认证方式（net/smtp 只提供 PLAIN 和 CRAM-MD5），部分 Exchange 服务器只支持该方式。
+type loginAuth struct {
+	username, password string
+}
+
+// Start 开始 LOGIN 认证。
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next 按服务器的提示依次返回用户名和密码。
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch string(fromServer) {
+	case "Username:", "User Name\x00":
+		return []byte(a.username), nil
+	case "Password:", "Password\x00":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %q", fromServer)
+	}
+}
+
+// isLocalhost 判断是否为本机地址，与 net/smtp 相同，本机连接允许不加密认证。
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// auth 返回 SMTP 认证方式，未配置用户名时不认证。
+func auth() smtp.Auth {
+	cfg := common.SMTPConfig
+	if cfg.Username == "" {
+		return nil
+	}
+	if cfg.Auth == "login" {
+		return &loginAuth{username: cfg.Username, password: cfg.Password}
+	}
+	return smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+}
+
+// send 通过配置的 SMTP 服务器发送邮件。
+// SMTP 服务器返回 5xx 永久错误（如收件人不存在、认证失败）时不再重试，连接失败和 4xx 临时错误可以重试。
+func send(from string, to []string, msg []byte) error {
+	cfg := common.SMTPConfig
+	if cfg.Host == "" {
+		return notify.Permanent(errors.New("SMTP_HOST is not configured"))
+	}
+
+	err := deliver(from, to, msg)
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return notify.Permanent(err)
+	}
+	return err
+}
+
+// deliver 建立 SMTP 连接并完成一次邮件投递。
+func deliver(from string, to []string, msg []byte) error {
+	cfg := common.SMTPConfig
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == common.SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(cfg.Timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create SMTP client for %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if cfg.TLS == common.SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return notify.Permanent(fmt.Errorf("SMTP server %s does not support STARTTLS", addr))
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if a := auth(); a != nil {
+		if err := c.Auth(a); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
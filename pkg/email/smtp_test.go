@@ -0,0 +1,290 @@
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubMail 模拟 SMTP 服务器收到的一封邮件。
+type stubMail struct {
+	TLS      bool   // 发送 MAIL FROM 时连接是否已加密
+	AuthMech string // 认证方式
+	Username string
+	Password string
+	From     string
+	To       []string
+	Data     string
+}
+
+// smtpStub 本地 SMTP 测试服务器，支持 STARTTLS、隐式 TLS 和 PLAIN/LOGIN 认证。
+type smtpStub struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool // 隐式 TLS
+	startTLS  bool // 是否支持 STARTTLS
+	rcptReply string
+
+	mu    sync.Mutex
+	mails []stubMail
+}
+
+// testCertificate 返回 httptest 内置的自签名证书。
+func testCertificate(t *testing.T) tls.Certificate {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	return srv.TLS.Certificates[0]
+}
+
+// newSMTPStub 启动 SMTP 测试服务器，并将 SMTP 配置指向该服务器。
+func newSMTPStub(t *testing.T, mode string) *smtpStub {
+	t.Helper()
+	s := &smtpStub{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{testCertificate(t)}},
+		implicit:  mode == common.SMTPTLS,
+		startTLS:  mode == common.SMTPStartTLS,
+	}
+
+	var err error
+	if s.implicit {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { s.listener.Close() })
+	go s.serve()
+
+	port, _ := strconv.Atoi(strings.TrimPrefix(s.listener.Addr().String(), "127.0.0.1:"))
+	common.SMTPConfig.Host = "127.0.0.1"
+	common.SMTPConfig.Port = port
+	common.SMTPConfig.TLS = mode
+	common.SMTPConfig.Auth = "plain"
+	common.SMTPConfig.Username = ""
+	common.SMTPConfig.Password = ""
+	common.SMTPConfig.From = "Alertmanager <alert@example.com>"
+	common.SMTPConfig.Timeout = 5 * time.Second
+	common.SMTPConfig.InsecureSkipVerify = true
+	return s
+}
+
+func (s *smtpStub) received() []stubMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubMail(nil), s.mails...)
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	_, secure := conn.(*tls.Conn)
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+
+	var mail stubMail
+	reply("220 stub ESMTP")
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		arg := strings.TrimSpace(strings.TrimPrefix(line, strings.SplitN(line, " ", 2)[0]))
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-stub")
+			if s.startTLS && !secure {
+				reply("250-STARTTLS")
+			}
+			reply("250-AUTH PLAIN LOGIN")
+			reply("250 8BITMIME")
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			r = bufio.NewReader(conn)
+		case "AUTH":
+			parts := strings.Fields(arg)
+			mail.AuthMech = strings.ToUpper(parts[0])
+			switch mail.AuthMech {
+			case "PLAIN":
+				resp := ""
+				if len(parts) > 1 {
+					resp = parts[1]
+				} else {
+					reply("334 ")
+					resp, _ = readLine()
+				}
+				fields := strings.Split(decode(resp), "\x00")
+				if len(fields) == 3 {
+					mail.Username, mail.Password = fields[1], fields[2]
+				}
+			case "LOGIN":
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := readLine()
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := readLine()
+				mail.Username, mail.Password = decode(user), decode(pass)
+			}
+			if mail.Password != "secret" {
+				reply("535 5.7.8 authentication failed")
+				continue
+			}
+			reply("235 2.7.0 authenticated")
+		case "MAIL":
+			mail.TLS = secure
+			mail.From = path(arg)
+			reply("250 ok")
+		case "RCPT":
+			if s.rcptReply != "" {
+				reply(s.rcptReply)
+				continue
+			}
+			mail.To = append(mail.To, path(arg))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, ok := readLine()
+				if !ok || l == "." {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, ".") + "\r\n")
+			}
+			mail.Data = data.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// path 返回 MAIL FROM:<addr> 和 RCPT TO:<addr> 命令中的地址。
+func path(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+func TestSendStartTLSPlain(t *testing.T) {
+	stub := newSMTPStub(t, common.SMTPStartTLS)
+	common.SMTPConfig.Username = "adapter"
+	common.SMTPConfig.Password = "secret"
+
+	if err := send("alert@example.com", []string{"ops@example.com"}, []byte("Subject: hi\r\n\r\nbody\r\n")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	mails := stub.received()
+	if len(mails) != 1 {
+		t.Fatalf("received %d mails, want 1", len(mails))
+	}
+	m := mails[0]
+	if !m.TLS {
+		t.Error("mail should be sent after STARTTLS")
+	}
+	if m.AuthMech != "PLAIN" || m.Username != "adapter" || m.Password != "secret" {
+		t.Errorf("auth = %s %s/%s, want PLAIN adapter/secret", m.AuthMech, m.Username, m.Password)
+	}
+	if m.From != "alert@example.com" || len(m.To) != 1 || m.To[0] != "ops@example.com" {
+		t.Errorf("envelope = %s -> %v", m.From, m.To)
+	}
+}
+
+func TestSendImplicitTLSLogin(t *testing.T) {
+	stub := newSMTPStub(t, common.SMTPTLS)
+	common.SMTPConfig.Auth = "login"
+	common.SMTPConfig.Username = "adapter"
+	common.SMTPConfig.Password = "secret"
+
+	if err := send("alert@example.com", []string{"a@example.com", "b@example.com"}, []byte("Subject: hi\r\n\r\nbody\r\n")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	m := stub.received()[0]
+	if !m.TLS || m.AuthMech != "LOGIN" || m.Username != "adapter" {
+		t.Errorf("TLS=%v auth=%s user=%s, want implicit TLS with LOGIN", m.TLS, m.AuthMech, m.Username)
+	}
+	if len(m.To) != 2 {
+		t.Errorf("recipients = %v, want 2", m.To)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		setup    func(*smtpStub)
+		attempts int
+	}{
+		// 认证失败和收件人被拒绝（5xx）不重试
+		{"auth failure", func(s *smtpStub) { common.SMTPConfig.Username, common.SMTPConfig.Password = "adapter", "wrong" }, 1},
+		{"recipient rejected", func(s *smtpStub) { s.rcptReply = "550 5.1.1 no such user" }, 1},
+		// 临时错误（4xx）可以重试
+		{"mailbox busy", func(s *smtpStub) { s.rcptReply = "451 4.3.0 try again later" }, 3},
+		// 服务器不支持 STARTTLS 时不降级为明文
+		{"no starttls", func(s *smtpStub) { s.startTLS = false }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newSMTPStub(t, common.SMTPStartTLS)
+			tt.setup(stub)
+			attempts := 0
+			err := notify.Retry(func() error {
+				attempts++
+				return send("alert@example.com", []string{"ops@example.com"}, []byte("\r\nbody\r\n"))
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d (err: %v)", attempts, tt.attempts, err)
+			}
+			if len(stub.received()) != 0 {
+				t.Error("no mail should be accepted")
+			}
+		})
+	}
+}
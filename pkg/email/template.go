@@ -0,0 +1,229 @@
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData 邮件模板的数据。
+type TemplateData struct {
+	Alert        common.Alert // 当前告警
+	AlertName    string
+	Status       string
+	Severity     string
+	Summary      string
+	Description  string
+	TriggerLogs  string // 触发日志
+	MetricTrend  string // 指标趋势
+	Flapping     string // 抖动提示，告警未抖动时为空
+	Duration     time.Duration
+	ExternalURL  string // Alertmanager 地址
+	GeneratorURL string // Prometheus 查询地址
+}
+
+// defaultSubject 默认的邮件主题模板。
+const defaultSubject = `[{{ upper .Status }}]{{ if .Severity }}[{{ .Severity }}]{{ end }} {{ .AlertName }}`
+
+// defaultText 默认的纯文本正文模板。
+const defaultText = `告警: {{ .AlertName }}
+状态: {{ .Status }}
+{{- if .Severity }}
+级别: {{ .Severity }}
+{{- end }}
+摘要: {{ .Summary }}
+详情: {{ .Description }}
+{{- if not .Alert.StartsAt.IsZero }}
+开始时间: {{ time .Alert.StartsAt }}
+{{- end }}
+{{- if .Duration }}
+恢复时间: {{ time .Alert.EndsAt }}
+持续时间: {{ .Duration }}
+{{- end }}
+{{- if .MetricTrend }}
+指标趋势: {{ .MetricTrend }}
+{{- end }}
+{{- if .Flapping }}
+🔀 {{ .Flapping }}
+{{- end }}
+
+标签:
+{{- range $k, $v := .Alert.Labels }}
+  {{ $k }} = {{ $v }}
+{{- end }}
+{{- if .TriggerLogs }}
+
+触发日志:
+{{ .TriggerLogs }}
+{{- end }}
+{{- if .GeneratorURL }}
+
+Prometheus: {{ .GeneratorURL }}
+{{- end }}
+{{- if .ExternalURL }}
+Alertmanager: {{ .ExternalURL }}
+{{- end }}
+`
+
+// defaultHTML 默认的 HTML 正文模板。
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, 'Segoe UI', 'PingFang SC', 'Microsoft YaHei', sans-serif; font-size: 14px; color: #333;">
+<table cellpadding="0" cellspacing="0" style="width: 100%; max-width: 720px; border: 1px solid #ddd;">
+  <tr><td style="background: {{ color .Status .Severity }}; color: #fff; padding: 12px 16px; font-size: 18px; font-weight: bold;">
+    [{{ upper .Status }}] {{ .AlertName }}
+  </td></tr>
+  <tr><td style="padding: 16px;">
+    <table cellpadding="4" cellspacing="0">
+      <tr><td style="color: #888;">状态</td><td>{{ .Status }}</td></tr>
+      {{- if .Severity }}
+      <tr><td style="color: #888;">级别</td><td>{{ .Severity }}</td></tr>
+      {{- end }}
+      <tr><td style="color: #888;">摘要</td><td>{{ .Summary }}</td></tr>
+      <tr><td style="color: #888;">详情</td><td>{{ .Description }}</td></tr>
+      {{- if not .Alert.StartsAt.IsZero }}
+      <tr><td style="color: #888;">开始时间</td><td>{{ time .Alert.StartsAt }}</td></tr>
+      {{- end }}
+      {{- if .Duration }}
+      <tr><td style="color: #888;">恢复时间</td><td>{{ time .Alert.EndsAt }}</td></tr>
+      <tr><td style="color: #888;">持续时间</td><td>{{ .Duration }}</td></tr>
+      {{- end }}
+      {{- if .MetricTrend }}
+      <tr><td style="color: #888;">指标趋势</td><td>{{ .MetricTrend }}</td></tr>
+      {{- end }}
+    </table>
+    {{- if .Flapping }}
+    <p style="color: #888;">🔀 {{ .Flapping }}</p>
+    {{- end }}
+    <h4>标签</h4>
+    <table cellpadding="4" cellspacing="0" style="border-collapse: collapse;">
+      {{- range $k, $v := .Alert.Labels }}
+      <tr><td style="border: 1px solid #eee; color: #888;">{{ $k }}</td><td style="border: 1px solid #eee;">{{ $v }}</td></tr>
+      {{- end }}
+    </table>
+    {{- if .TriggerLogs }}
+    <h4>触发日志</h4>
+    <pre style="background: #f6f8fa; padding: 8px; white-space: pre-wrap; word-break: break-all;">{{ .TriggerLogs }}</pre>
+    {{- end }}
+    <p>
+      {{- if .GeneratorURL }}<a href="{{ .GeneratorURL }}">打开 Prometheus</a>{{ end }}
+      {{- if and .GeneratorURL .ExternalURL }} | {{ end }}
+      {{- if .ExternalURL }}<a href="{{ .ExternalURL }}">打开 Alertmanager</a>{{ end }}
+    </p>
+  </td></tr>
+</table>
+</body>
+</html>
+`
+
+// funcs 邮件模板可以使用的函数。
+var funcs = map[string]any{
+	"time": func(t time.Time) string {
+		return t.Local().Format("2006-01-02 15:04:05")
+	},
+	"upper": strings.ToUpper,
+	"color": func(status, severity string) string {
+		if status == "resolved" {
+			return "#2e7d32"
+		}
+		switch strings.ToLower(severity) {
+		case "critical":
+			return "#c62828"
+		case "warning":
+			return "#ef6c00"
+		case "info":
+			return "#1565c0"
+		default:
+			return "#616161"
+		}
+	},
+}
+
+// templates 邮件主题、纯文本正文和 HTML 正文模板。
+var templates struct {
+	Subject *template.Template
+	Text    *template.Template
+	HTML    *htmltemplate.Template
+}
+
+func init() {
+	templates.Subject = template.Must(template.New("subject").Funcs(funcs).Parse(defaultSubject))
+	templates.Text = template.Must(template.New("text").Funcs(funcs).Parse(defaultText))
+	templates.HTML = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(defaultHTML))
+}
+
+// Init 加载自定义邮件模板：EMAIL_SUBJECT_TEMPLATE 为主题模板，
+// EMAIL_TEXT_TEMPLATE 和 EMAIL_HTML_TEMPLATE 为纯文本和 HTML 正文模板文件。
+// 模板无效时使用默认模板；EMAIL_HTML_TEMPLATE 设置为 none 时只发送纯文本邮件。
+func Init() {
+	if text := os.Getenv("EMAIL_SUBJECT_TEMPLATE"); text != "" {
+		if tmpl, err := template.New("subject").Funcs(funcs).Parse(text); err == nil {
+			templates.Subject = tmpl
+		} else {
+			log.Printf("⚠️ Invalid EMAIL_SUBJECT_TEMPLATE, using default template: %v", err)
+		}
+	}
+
+	if path := os.Getenv("EMAIL_TEXT_TEMPLATE"); path != "" {
+		if text, err := readTemplate(path); err != nil {
+			log.Printf("⚠️ Invalid EMAIL_TEXT_TEMPLATE %s, using default template: %v", path, err)
+		} else if tmpl, err := template.New("text").Funcs(funcs).Parse(text); err != nil {
+			log.Printf("⚠️ Invalid EMAIL_TEXT_TEMPLATE %s, using default template: %v", path, err)
+		} else {
+			templates.Text = tmpl
+		}
+	}
+
+	switch path := os.Getenv("EMAIL_HTML_TEMPLATE"); path {
+	case "":
+	case "none":
+		templates.HTML = nil
+	default:
+		if text, err := readTemplate(path); err != nil {
+			log.Printf("⚠️ Invalid EMAIL_HTML_TEMPLATE %s, using default template: %v", path, err)
+		} else if tmpl, err := htmltemplate.New("html").Funcs(funcs).Parse(text); err != nil {
+			log.Printf("⚠️ Invalid EMAIL_HTML_TEMPLATE %s, using default template: %v", path, err)
+		} else {
+			templates.HTML = tmpl
+		}
+	}
+}
+
+// readTemplate 读取模板文件。
+func readTemplate(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// render 渲染邮件主题和正文。
+func render(data TemplateData) (subject, text, html string, err error) {
+	var builder strings.Builder
+	if err := templates.Subject.Execute(&builder, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email subject: %w", err)
+	}
+	// 主题中不能包含换行
+	subject = strings.Join(strings.Fields(builder.String()), " ")
+
+	builder.Reset()
+	if err := templates.Text.Execute(&builder, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render email text: %w", err)
+	}
+	text = builder.String()
+
+	if templates.HTML != nil {
+		builder.Reset()
+		if err := templates.HTML.Execute(&builder, data); err != nil {
+			return "", "", "", fmt.Errorf("failed to render email html: %w", err)
+		}
+		html = builder.String()
+	}
+	return subject, text, html, nil
+}
@@ -0,0 +1,80 @@
+package email
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// threadTTL 会话记录的保留时间，超过该时间仍未恢复的告警不再记录。
+const threadTTL = 7 * 24 * time.Hour
+
+// threads 已发送过首封邮件的会话，key 为会话的 Message-ID，value 为发送时间。
+var threads sync.Map
+
+// domain 返回生成 Message-ID 使用的域名，取自发件人地址。
+func domain() string {
+	if addr, err := mail.ParseAddress(common.SMTPConfig.From); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i >= 0 {
+			return addr.Address[i+1:]
+		}
+	}
+	return "alertmanager-webhook-adapter"
+}
+
+// threadID 返回告警在指定目标上的会话 Message-ID。同一告警（指纹和开始时间相同）的所有邮件属于同一会话。
+func threadID(alert common.Alert, target string) string {
+	return fmt.Sprintf("<alert.%s.%d.%s@%s>", alert.Fingerprint, alert.StartsAt.Unix(), target, domain())
+}
+
+// uniqueID 生成一个随机的 Message-ID。
+func uniqueID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s.%d@%s>", hex.EncodeToString(b), time.Now().UnixNano(), domain())
+}
+
+// thread 为告警邮件设置 Message-ID 和 In-Reply-To，使同一告警的邮件在邮件客户端中归入同一会话：
+// 告警第一次 firing 的邮件以会话 ID 作为 Message-ID，之后的重复通知和恢复通知都回复该邮件。
+// 没有指纹的告警不归入会话。
+func thread(msg *Message, alert common.Alert, target string) {
+	if alert.Fingerprint == "" {
+		msg.MessageID = uniqueID()
+		return
+	}
+
+	root := threadID(alert, target)
+	if alert.Status == "firing" {
+		if _, started := threads.LoadOrStore(root, time.Now()); !started {
+			msg.MessageID = root
+			pruneThreads()
+			return
+		}
+	} else {
+		threads.Delete(root)
+	}
+	msg.MessageID = uniqueID()
+	msg.InReplyTo = root
+}
+
+// pruneThreads 清理超过保留时间的会话记录。
+func pruneThreads() {
+	threads.Range(func(key, value any) bool {
+		if time.Since(value.(time.Time)) > threadTTL {
+			threads.Delete(key)
+		}
+		return true
+	})
+}
+
+// release 在邮件发送失败时撤销会话记录，下一封 firing 邮件重新作为会话的首封邮件。
+func release(msg *Message) {
+	if msg.InReplyTo == "" && msg.MessageID != "" {
+		threads.Delete(msg.MessageID)
+	}
+}
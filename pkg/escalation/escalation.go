@@ -0,0 +1,320 @@
+// Package escalation 提供告警升级功能：持续 firing 且在指定时间内未被认领的告警，
+// 按升级策略依次通知其他目标（如另一个飞书群、@ 负责人、更高级别的 syslog）。
+package escalation
+
+import (
+	"alertmanagerWebhookAdapter/pkg/ack"
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step 升级步骤。
+type Step struct {
+	After    string   `json:"after"`    // 告警首次到达后多久执行，如 10m
+	Channel  string   `json:"channel"`  // 通知渠道，如 feishu、syslog
+	Target   string   `json:"target"`   // 渠道中的目标名称
+	Mentions []string `json:"mentions"` // 需要 @ 的用户 open_id（飞书）
+	Severity string   `json:"severity"` // 通知的严重级别，syslog 使用，如 emerg、alert、crit
+
+	after time.Duration
+}
+
+// Policy 升级策略，匹配的告警按顺序执行升级步骤。
+type Policy struct {
+	Name        string            `json:"name"`
+	Matchers    map[string]string `json:"matchers"`     // 需要完全匹配的告警标签
+	MinSeverity string            `json:"min_severity"` // 最低告警级别（可选）
+	Steps       []Step            `json:"steps"`
+}
+
+// Config 升级配置。
+type Config struct {
+	StateTTL string    `json:"state_ttl"` // 告警状态在未再次收到时的保留时间，默认 24h
+	Policies []*Policy `json:"policies"`
+}
+
+// alertState 单个告警的跟踪状态。
+type alertState struct {
+	alert     common.Alert
+	policy    *Policy
+	firstSeen time.Time
+	lastSeen  time.Time
+	next      int // 下一个待执行的升级步骤
+	timer     *time.Timer
+}
+
+// Tracker 以告警指纹为 key 跟踪 firing 告警，并在到期时执行升级步骤。
+type Tracker struct {
+	mu        sync.Mutex
+	policies  []*Policy
+	ttl       time.Duration
+	states    map[string]*alertState
+	lastSweep time.Time
+}
+
+// defaultTracker 全局的告警跟踪器，未配置升级策略时只跟踪状态不升级。
+var defaultTracker = NewTracker(nil, 24*time.Hour)
+
+// NewTracker 创建告警跟踪器，并注册认领回调以取消升级。
+func NewTracker(policies []*Policy, ttl time.Duration) *Tracker {
+	t := &Tracker{
+		policies: policies,
+		ttl:      ttl,
+		states:   make(map[string]*alertState),
+	}
+	ack.OnAcknowledge(func(fingerprint string, info ack.Info) {
+		t.cancel(fingerprint, fmt.Sprintf("acknowledged by %s", info.By))
+	})
+	return t
+}
+
+// Init 从 ESCALATION_CONFIG 指定的 JSON 文件加载升级策略。
+func Init() {
+	path := os.Getenv("ESCALATION_CONFIG")
+	if path == "" {
+		return
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to load ESCALATION_CONFIG %s, escalation disabled: %v", path, err)
+		return
+	}
+
+	ttl := 24 * time.Hour
+	if cfg.StateTTL != "" {
+		if val, err := time.ParseDuration(cfg.StateTTL); err == nil && val > 0 {
+			ttl = val
+		}
+	}
+
+	defaultTracker.mu.Lock()
+	defaultTracker.policies = cfg.Policies
+	defaultTracker.ttl = ttl
+	defaultTracker.mu.Unlock()
+
+	log.Printf("✅ Escalation policies loaded: %d policies, state ttl=%v", len(cfg.Policies), ttl)
+}
+
+// Load 从 JSON 文件加载并校验升级配置。
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read escalation config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse escalation config: %w", err)
+	}
+
+	for _, p := range cfg.Policies {
+		if len(p.Steps) == 0 {
+			return nil, fmt.Errorf("policy %q: at least one step is required", p.Name)
+		}
+		var prev time.Duration
+		for i := range p.Steps {
+			step := &p.Steps[i]
+			after, err := time.ParseDuration(step.After)
+			if err != nil || after <= 0 {
+				return nil, fmt.Errorf("policy %q step %d: invalid after %q", p.Name, i+1, step.After)
+			}
+			if after < prev {
+				return nil, fmt.Errorf("policy %q step %d: steps must be in ascending order", p.Name, i+1)
+			}
+			if step.Channel == "" || step.Target == "" {
+				return nil, fmt.Errorf("policy %q step %d: channel and target are required", p.Name, i+1)
+			}
+			step.after, prev = after, after
+		}
+	}
+	return &cfg, nil
+}
+
+// Observe 更新告警状态：firing 告警开始跟踪并按策略安排升级，resolved 告警取消升级并清除状态。
+func Observe(alert common.Alert) {
+	defaultTracker.Observe(alert)
+}
+
+// Observe 更新告警状态。
+func (t *Tracker) Observe(alert common.Alert) {
+	if alert.Fingerprint == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) >= time.Minute {
+		t.sweep(now)
+	}
+
+	if alert.Status == "resolved" {
+		if s, ok := t.states[alert.Fingerprint]; ok {
+			t.stop(s)
+			delete(t.states, alert.Fingerprint)
+		}
+		ack.Clear(alert.Fingerprint)
+		return
+	}
+
+	if s, ok := t.states[alert.Fingerprint]; ok {
+		s.alert = alert
+		s.lastSeen = now
+		return
+	}
+
+	s := &alertState{
+		alert:     alert,
+		policy:    t.match(alert),
+		firstSeen: now,
+		lastSeen:  now,
+	}
+	t.states[alert.Fingerprint] = s
+
+	if s.policy != nil {
+		if _, acked := ack.Get(alert.Fingerprint); !acked {
+			t.schedule(s)
+		}
+	}
+}
+
+// match 返回第一个匹配告警的升级策略。
+func (t *Tracker) match(alert common.Alert) *Policy {
+	for _, p := range t.policies {
+		if p.MinSeverity != "" &&
+			common.SeverityRank(alert.Labels["severity"]) < common.SeverityRank(p.MinSeverity) {
+			continue
+		}
+		matched := true
+		for k, v := range p.Matchers {
+			if alert.Labels[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return p
+		}
+	}
+	return nil
+}
+
+// schedule 为下一个升级步骤设置定时器，调用方需持有锁。
+func (t *Tracker) schedule(s *alertState) {
+	if s.next >= len(s.policy.Steps) {
+		return
+	}
+	step := s.policy.Steps[s.next]
+	delay := time.Until(s.firstSeen.Add(step.after))
+	fingerprint := s.alert.Fingerprint
+	s.timer = time.AfterFunc(delay, func() { t.fire(fingerprint) })
+}
+
+// fire 执行到期的升级步骤，并安排下一个步骤。
+func (t *Tracker) fire(fingerprint string) {
+	t.mu.Lock()
+	s, ok := t.states[fingerprint]
+	if !ok || s.policy == nil || s.next >= len(s.policy.Steps) {
+		t.mu.Unlock()
+		return
+	}
+	if _, acked := ack.Get(fingerprint); acked {
+		t.mu.Unlock()
+		return
+	}
+
+	step := s.policy.Steps[s.next]
+	policy := s.policy.Name
+	alert := s.alert
+	firing := time.Since(s.firstSeen).Round(time.Minute)
+	level := s.next + 1
+	s.next++
+	t.schedule(s)
+	t.mu.Unlock()
+
+	// 在锁外发送通知，避免网络请求阻塞其他告警的状态更新
+	msg := buildMessage(alert, policy, level, firing, step)
+	err := notify.Send(step.Channel, step.Target, msg)
+	history.Notified(alert, step.Channel, step.Target, err)
+	if err != nil {
+		log.Printf("❌ Failed to escalate alert %s (level %d) to %s/%s: %v",
+			alert.Labels["alertname"], level, step.Channel, step.Target, err)
+		return
+	}
+	log.Printf("⏫ Escalated alert %s (level %d) to %s/%s",
+		alert.Labels["alertname"], level, step.Channel, step.Target)
+}
+
+// cancel 取消告警后续的升级步骤，保留告警状态。
+func (t *Tracker) cancel(fingerprint, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.states[fingerprint]
+	if !ok || s.timer == nil {
+		return
+	}
+	t.stop(s)
+	log.Printf("🛑 Escalation of alert %s cancelled: %s", s.alert.Labels["alertname"], reason)
+}
+
+// stop 停止告警的升级定时器，调用方需持有锁。
+func (t *Tracker) stop(s *alertState) {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// sweep 清除长时间未再收到的告警状态（例如未发送 resolved 通知的告警），调用方需持有锁。
+func (t *Tracker) sweep(now time.Time) {
+	for fingerprint, s := range t.states {
+		if now.Sub(s.lastSeen) > t.ttl {
+			t.stop(s)
+			delete(t.states, fingerprint)
+		}
+	}
+	t.lastSweep = now
+}
+
+// buildMessage 构建升级通知消息。
+func buildMessage(alert common.Alert, policy string, level int, firing time.Duration, step Step) notify.Message {
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "⏫ 告警升级（%s 第 %d 级）: %s 已持续 firing %s 且未被认领\n", policy, level, alertName, firing)
+	if severity := alert.Labels["severity"]; severity != "" {
+		fmt.Fprintf(&builder, "级别: %s\n", severity)
+	}
+	if summary := alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&builder, "摘要: %s\n", summary)
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&builder, "详情: %s\n", desc)
+	}
+	fmt.Fprintf(&builder, "指纹: %s", alert.Fingerprint)
+
+	severity := step.Severity
+	if severity == "" {
+		severity = alert.Labels["severity"]
+	}
+	return notify.Message{
+		Title:    fmt.Sprintf("[ESCALATED] %s", alertName),
+		Text:     builder.String(),
+		Severity: severity,
+		Mentions: step.Mentions,
+	}
+}
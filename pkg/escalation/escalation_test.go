@@ -0,0 +1,193 @@
+package escalation
+
+import (
+	"alertmanagerWebhookAdapter/pkg/ack"
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder 记录发送到测试渠道的升级通知。
+type recorder struct {
+	mu      sync.Mutex
+	targets []string
+}
+
+func (r *recorder) send(target string, msg notify.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets = append(r.targets, target)
+	return nil
+}
+
+func (r *recorder) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.targets...)
+}
+
+// newTestTracker 创建两级升级策略的跟踪器：第一级 30ms 后通知 lead，第二级 60ms 后通知 manager。
+func newTestTracker(t *testing.T, channel string, ttl time.Duration) (*Tracker, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	notify.Register(channel, rec.send)
+	t.Setenv("SEND_RETRY_ATTEMPTS", "1")
+	notify.Init()
+
+	policy := &Policy{
+		Name:        "critical",
+		MinSeverity: "critical",
+		Steps: []Step{
+			{Channel: channel, Target: "lead", after: 30 * time.Millisecond},
+			{Channel: channel, Target: "manager", after: 60 * time.Millisecond},
+		},
+	}
+	return NewTracker([]*Policy{policy}, ttl), rec
+}
+
+func newAlert(fingerprint, status, severity string) common.Alert {
+	return common.Alert{
+		Status:      status,
+		Fingerprint: fingerprint,
+		Labels:      map[string]string{"alertname": "DiskFull", "severity": severity},
+	}
+}
+
+// waitFor 等待条件满足，超时后终止测试。
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for escalation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestEscalationSteps 未认领的告警按步骤依次升级，重复收到的 firing 不会重新计时或重复升级。
+func TestEscalationSteps(t *testing.T) {
+	tr, rec := newTestTracker(t, "esc-steps", time.Hour)
+	alert := newAlert("esc-steps", "firing", "critical")
+	tr.Observe(alert)
+	tr.Observe(alert)
+
+	waitFor(t, func() bool { return len(rec.sent()) == 2 })
+	time.Sleep(50 * time.Millisecond)
+	if got := rec.sent(); len(got) != 2 || got[0] != "lead" || got[1] != "manager" {
+		t.Errorf("escalated to %v, want [lead manager]", got)
+	}
+}
+
+// TestEscalationNoPolicy 不匹配任何策略的告警不升级。
+func TestEscalationNoPolicy(t *testing.T) {
+	tr, rec := newTestTracker(t, "esc-nopolicy", time.Hour)
+	tr.Observe(newAlert("esc-nopolicy", "firing", "warning"))
+
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.sent(); len(got) != 0 {
+		t.Errorf("escalated to %v, want none", got)
+	}
+}
+
+// TestEscalationCancelOnResolve 告警恢复后取消后续的升级步骤。
+func TestEscalationCancelOnResolve(t *testing.T) {
+	tr, rec := newTestTracker(t, "esc-resolve", time.Hour)
+	tr.Observe(newAlert("esc-resolve", "firing", "critical"))
+
+	waitFor(t, func() bool { return len(rec.sent()) == 1 })
+	tr.Observe(newAlert("esc-resolve", "resolved", "critical"))
+
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.sent(); len(got) != 1 || got[0] != "lead" {
+		t.Errorf("escalated to %v, want [lead]", got)
+	}
+	tr.mu.Lock()
+	_, tracked := tr.states["esc-resolve"]
+	tr.mu.Unlock()
+	if tracked {
+		t.Error("resolved alert is still tracked")
+	}
+}
+
+// TestEscalationCancelOnAck 告警被认领后取消升级，已认领的告警再次触发时不安排升级。
+func TestEscalationCancelOnAck(t *testing.T) {
+	tr, rec := newTestTracker(t, "esc-ack", time.Hour)
+	tr.Observe(newAlert("esc-ack", "firing", "critical"))
+	ack.Acknowledge("esc-ack", "alice")
+
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.sent(); len(got) != 0 {
+		t.Errorf("escalated to %v after acknowledge, want none", got)
+	}
+
+	// 已认领的告警被重新跟踪时（如状态过期后再次收到）不再升级
+	tr.mu.Lock()
+	delete(tr.states, "esc-ack")
+	tr.mu.Unlock()
+	tr.Observe(newAlert("esc-ack", "firing", "critical"))
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.sent(); len(got) != 0 {
+		t.Errorf("escalated to %v for acknowledged alert, want none", got)
+	}
+	ack.Clear("esc-ack")
+}
+
+// TestEscalationStateTTL 长时间未再收到的告警状态被清除，并停止后续的升级。
+func TestEscalationStateTTL(t *testing.T) {
+	tr, rec := newTestTracker(t, "esc-ttl", 24*time.Hour)
+	tr.Observe(newAlert("esc-ttl", "firing", "critical"))
+
+	// 未超过状态保留时间时保留状态
+	tr.mu.Lock()
+	tr.sweep(time.Now().Add(23 * time.Hour))
+	_, tracked := tr.states["esc-ttl"]
+	tr.mu.Unlock()
+	if !tracked {
+		t.Fatal("alert state removed before ttl")
+	}
+
+	tr.mu.Lock()
+	tr.sweep(time.Now().Add(25 * time.Hour))
+	_, tracked = tr.states["esc-ttl"]
+	tr.mu.Unlock()
+	if tracked {
+		t.Fatal("alert state kept after ttl")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.sent(); len(got) != 0 {
+		t.Errorf("escalated to %v after state expired, want none", got)
+	}
+}
+
+// TestLoadValidation 升级步骤需要按时间升序排列，并配置渠道和目标。
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		ok     bool
+	}{
+		{"valid", `{"policies": [{"name": "p", "steps": [{"after": "10m", "channel": "feishu", "target": "ops"}, {"after": "30m", "channel": "syslog", "target": "noc"}]}]}`, true},
+		{"no steps", `{"policies": [{"name": "p", "steps": []}]}`, false},
+		{"bad after", `{"policies": [{"name": "p", "steps": [{"after": "soon", "channel": "feishu", "target": "ops"}]}]}`, false},
+		{"descending", `{"policies": [{"name": "p", "steps": [{"after": "30m", "channel": "feishu", "target": "ops"}, {"after": "10m", "channel": "feishu", "target": "ops"}]}]}`, false},
+		{"missing target", `{"policies": [{"name": "p", "steps": [{"after": "10m", "channel": "feishu"}]}]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "escalation.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if (err == nil) != tt.ok {
+				t.Errorf("Load error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
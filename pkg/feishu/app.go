@@ -0,0 +1,305 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshAhead 在 tenant_access_token 过期前提前刷新的时间。
+const tokenRefreshAhead = 5 * time.Minute
+
+// 飞书开放平台中表示 tenant_access_token 无效或过期的错误码。
+const (
+	codeTokenInvalid = 99991663
+	codeTokenExpired = 99991677
+)
+
+// AppClient 飞书自建应用 API 客户端。
+// 使用 app_id / app_secret 获取 tenant_access_token 并在有效期内缓存复用。
+type AppClient struct {
+	BaseURL   string        // 开放平台地址，如 https://open.feishu.cn
+	AppID     string        // 应用 App ID
+	AppSecret string        // 应用 App Secret
+	Timeout   time.Duration // HTTP 请求超时时间
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// apiResponse 飞书开放平台通用响应结构。
+type apiResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+var (
+	appOnce   sync.Once
+	appClient *AppClient
+)
+
+// App 返回根据 FEISHU_APP_ID / FEISHU_APP_SECRET 创建的应用客户端。
+// 未配置应用凭证时返回 nil。
+func App() *AppClient {
+	appOnce.Do(func() {
+		if common.FeishuApp.AppID == "" || common.FeishuApp.AppSecret == "" {
+			return
+		}
+		appClient = &AppClient{
+			BaseURL:   common.FeishuApp.BaseURL,
+			AppID:     common.FeishuApp.AppID,
+			AppSecret: common.FeishuApp.AppSecret,
+			Timeout:   10 * time.Second,
+		}
+	})
+	return appClient
+}
+
+// TenantAccessToken 返回有效的 tenant_access_token，过期前自动刷新。
+func (a *AppClient) TenantAccessToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Now().Add(tokenRefreshAhead).Before(a.expiresAt) {
+		return a.token, nil
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"app_id":     a.AppID,
+		"app_secret": a.AppSecret,
+	})
+	req, err := http.NewRequest("POST", a.url("/open-apis/auth/v3/tenant_access_token/internal"), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var tokenResp struct {
+		Code              int    `json:"code"`
+		Msg               string `json:"msg"`
+		TenantAccessToken string `json:"tenant_access_token"`
+		Expire            int    `json:"expire"` // 有效期（秒）
+	}
+	if err := a.send(req, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to get tenant_access_token: %w", err)
+	}
+	if tokenResp.Code != 0 {
+		return "", fmt.Errorf("failed to get tenant_access_token: code=%d msg=%s", tokenResp.Code, tokenResp.Msg)
+	}
+
+	a.token = tokenResp.TenantAccessToken
+	a.expiresAt = time.Now().Add(time.Duration(tokenResp.Expire) * time.Second)
+	log.Printf("🔑 Feishu tenant_access_token refreshed, expires at %s", a.expiresAt.Format(time.RFC3339))
+	return a.token, nil
+}
+
+// invalidateToken 清除缓存的 tenant_access_token，下次调用时重新获取。
+func (a *AppClient) invalidateToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// UploadImage 上传图片到飞书，返回可在消息和卡片中使用的 image_key。
+func (a *AppClient) UploadImage(image []byte, filename string) (string, error) {
+	newRequest := func() (*http.Request, error) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		if err := writer.WriteField("image_type", "message"); err != nil {
+			return nil, fmt.Errorf("failed to write image_type: %w", err)
+		}
+		part, err := writer.CreateFormFile("image", filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create form file: %w", err)
+		}
+		if _, err := part.Write(image); err != nil {
+			return nil, fmt.Errorf("failed to write image: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+		}
+
+		req, err := http.NewRequest("POST", a.url("/open-apis/im/v1/images"), &buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create upload request: %w", err)
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req, nil
+	}
+
+	var data struct {
+		ImageKey string `json:"image_key"`
+	}
+	if err := a.call(newRequest, &data); err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	return data.ImageKey, nil
+}
+
+// SendMessage 通过消息 API 发送消息到群聊，返回 message_id。
+// content 为消息内容对象（如 {"text": "..."} 或卡片），会被序列化为 JSON 字符串。
+func (a *AppClient) SendMessage(chatID, msgType string, content interface{}) (string, error) {
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal content: %w", err)
+	}
+	body, _ := json.Marshal(map[string]string{
+		"receive_id": chatID,
+		"msg_type":   msgType,
+		"content":    string(contentJSON),
+	})
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", a.url("/open-apis/im/v1/messages?receive_id_type=chat_id"), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req, nil
+	}
+
+	var data struct {
+		MessageID string `json:"message_id"`
+	}
+	if err := a.call(newRequest, &data); err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	return data.MessageID, nil
+}
+
+// UpdateCard 更新已发送的卡片消息内容。
+func (a *AppClient) UpdateCard(messageID string, card interface{}) error {
+	contentJSON, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("failed to marshal card: %w", err)
+	}
+	body, _ := json.Marshal(map[string]string{
+		"content": string(contentJSON),
+	})
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest("PATCH", a.url("/open-apis/im/v1/messages/"+url.PathEscape(messageID)), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create update request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req, nil
+	}
+
+	if err := a.call(newRequest, nil); err != nil {
+		return fmt.Errorf("failed to update card: %w", err)
+	}
+	return nil
+}
+
+// OpenIDsByEmail 通过通讯录接口将邮箱批量解析为 open_id，返回 邮箱 → open_id 的映射。
+// 需要应用开通 contact:user.id:readonly 权限。
+func (a *AppClient) OpenIDsByEmail(emails []string) (map[string]string, error) {
+	body, _ := json.Marshal(map[string][]string{"emails": emails})
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", a.url("/open-apis/contact/v3/users/batch_get_id?user_id_type=open_id"), bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req, nil
+	}
+
+	var data struct {
+		UserList []struct {
+			Email  string `json:"email"`
+			UserID string `json:"user_id"`
+		} `json:"user_list"`
+	}
+	if err := a.call(newRequest, &data); err != nil {
+		return nil, fmt.Errorf("failed to get users by email: %w", err)
+	}
+
+	ids := make(map[string]string, len(data.UserList))
+	for _, u := range data.UserList {
+		ids[u.Email] = u.UserID
+	}
+	return ids, nil
+}
+
+// call 携带 tenant_access_token 调用开放平台接口，并将响应中的 data 解析到 out。
+// token 失效时会刷新 token 并重试一次，因此需要通过 newRequest 重新构建请求。
+func (a *AppClient) call(newRequest func() (*http.Request, error), out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := a.TenantAccessToken()
+		if err != nil {
+			return err
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		var resp apiResponse
+		if err := a.send(req, &resp); err != nil {
+			return err
+		}
+
+		if (resp.Code == codeTokenInvalid || resp.Code == codeTokenExpired) && attempt == 0 {
+			log.Printf("⚠️ Feishu tenant_access_token rejected (code=%d), refreshing", resp.Code)
+			a.invalidateToken()
+			continue
+		}
+		if resp.Code != 0 {
+			return fmt.Errorf("feishu API error: code=%d msg=%s", resp.Code, resp.Msg)
+		}
+
+		if out != nil && len(resp.Data) > 0 {
+			if err := json.Unmarshal(resp.Data, out); err != nil {
+				return fmt.Errorf("failed to decode response data: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// send 发送请求并将 JSON 响应解析到 out。
+// 飞书接口在业务错误时也可能返回非 2xx 状态码，此时仍尝试解析响应体中的错误码。
+func (a *AppClient) send(req *http.Request, out interface{}) error {
+	client := &http.Client{
+		Timeout: a.Timeout,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			log.Printf("failed to close response body: %v", cerr)
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// url 拼接开放平台接口地址。
+func (a *AppClient) url(path string) string {
+	return strings.TrimRight(a.BaseURL, "/") + path
+}
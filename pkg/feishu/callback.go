@@ -0,0 +1,336 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/ack"
+	"alertmanagerWebhookAdapter/pkg/amapi"
+	"alertmanagerWebhookAdapter/pkg/common"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1" //nolint:gosec // 飞书卡片回调签名算法要求使用 SHA1
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// callbackMaxSkew 回调请求时间戳与本地时间允许的最大偏差，用于防止重放。
+const callbackMaxSkew = 5 * time.Minute
+
+// callbackRequest 飞书卡片回调请求，兼容 URL 校验、旧版卡片回调和 2.0 版回调。
+type callbackRequest struct {
+	// URL 校验
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Token     string `json:"token"`
+
+	// 加密的请求体（配置了 Encrypt Key 时）
+	Encrypt string `json:"encrypt"`
+
+	// 旧版卡片回调
+	OpenID        string     `json:"open_id"`
+	OpenMessageID string     `json:"open_message_id"`
+	Action        cardAction `json:"action"`
+
+	// 2.0 版卡片回调（card.action.trigger）
+	Schema string `json:"schema"`
+	Header struct {
+		Token string `json:"token"`
+	} `json:"header"`
+	Event struct {
+		Operator struct {
+			OpenID string `json:"open_id"`
+		} `json:"operator"`
+		Action cardAction `json:"action"`
+	} `json:"event"`
+}
+
+// cardAction 回调中的按钮动作。
+type cardAction struct {
+	Tag   string            `json:"tag"`
+	Value map[string]string `json:"value"`
+}
+
+// CallbackHandler 处理飞书卡片按钮回调。
+// 校验请求签名和 Verification Token 后，根据按钮动作创建静默或认领告警，
+// 并返回更新后的卡片，在卡片上展示操作人和操作结果。
+func CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	token := common.FeishuApp.VerificationToken
+	if token == "" {
+		http.Error(w, "card callback not enabled", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	var req callbackRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 配置了 Encrypt Key 时请求体为加密内容
+	if req.Encrypt != "" {
+		plain, err := decrypt(req.Encrypt, common.FeishuApp.EncryptKey)
+		if err != nil {
+			log.Printf("❌ Failed to decrypt feishu callback: %v", err)
+			http.Error(w, "Invalid encrypted body", http.StatusBadRequest)
+			return
+		}
+		req = callbackRequest{}
+		if err := json.Unmarshal(plain, &req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// URL 校验请求只需校验 token
+	if req.Type == "url_verification" {
+		if !equalToken(req.Token, token) {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]string{"challenge": req.Challenge})
+		return
+	}
+
+	if err := verifySignature(r.Header, body, token, common.FeishuApp.EncryptKey); err != nil {
+		log.Printf("❌ Rejected feishu callback: %v", err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	v2 := req.Schema == "2.0"
+	requestToken, operator, value := req.Token, req.OpenID, req.Action.Value
+	if v2 {
+		requestToken, operator, value = req.Header.Token, req.Event.Operator.OpenID, req.Event.Action.Value
+	}
+	if !equalToken(requestToken, token) {
+		log.Println("❌ Rejected feishu callback: invalid verification token")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	// 回调只来自通过应用发送的共享卡片，返回的卡片同样声明为共享卡片
+	card, toast, ok := handleAction(operator, value)
+	card = card.shared()
+
+	if v2 {
+		toastType := "success"
+		if !ok {
+			toastType = "error"
+		}
+		writeJSON(w, map[string]interface{}{
+			"toast": map[string]string{"type": toastType, "content": toast},
+			"card":  map[string]interface{}{"type": "raw", "data": card.Card},
+		})
+		return
+	}
+	writeJSON(w, card.Card)
+}
+
+// handleAction 执行按钮动作，返回更新后的卡片、提示文本以及动作是否成功。
+func handleAction(operator string, value map[string]string) (*CardMessage, string, bool) {
+	fingerprint := value["fingerprint"]
+	alertName := value["alertname"]
+	card := actionCard(fingerprint, alertName)
+	user := fmt.Sprintf("<at id=%s></at>", operator)
+	now := time.Now()
+
+	switch value["action"] {
+	case actionSilence:
+		silenceID, duration, err := createSilence(operator, alertName, value)
+		if err != nil {
+			log.Printf("❌ Failed to silence alert %s from feishu: %v", alertName, err)
+			card.AddNote(fmt.Sprintf("❌ %s 静默失败：%v", user, err))
+			return card, "静默失败", false
+		}
+		log.Printf("🔕 Alert %s silenced for %s by %s: silence_id=%s", alertName, value["duration"], operator, silenceID)
+		card.removeButtons(actionSilence)
+		card.AddNote(fmt.Sprintf("🔕 %s 于 %s 静默了该告警 %s（至 %s，silence ID: %s）",
+			user, now.Format("01-02 15:04"), value["duration"], now.Add(duration).Format("01-02 15:04"), silenceID))
+		cards.Put(fingerprint, card, common.FeishuApp.MessageTTL)
+		return card, fmt.Sprintf("已静默 %s", value["duration"]), true
+
+	case actionAck:
+		info, first := ack.Acknowledge(fingerprint, operator)
+		card.removeButtons(actionAck)
+		if first {
+			log.Printf("✅ Alert %s acknowledged by %s", alertName, operator)
+			card.AddNote(fmt.Sprintf("✅ %s 于 %s 认领了该告警", user, info.At.Format("01-02 15:04")))
+		} else {
+			card.AddNote(fmt.Sprintf("ℹ️ 该告警已由 <at id=%s></at> 于 %s 认领", info.By, info.At.Format("01-02 15:04")))
+		}
+		cards.Put(fingerprint, card, common.FeishuApp.MessageTTL)
+		return card, "已认领", true
+
+	default:
+		return card, "未知操作", false
+	}
+}
+
+// createSilence 根据按钮携带的告警标签在 Alertmanager 中创建静默规则。
+func createSilence(operator, alertName string, value map[string]string) (string, time.Duration, error) {
+	duration, err := time.ParseDuration(value["duration"])
+	if err != nil || duration <= 0 {
+		return "", 0, fmt.Errorf("invalid silence duration %q", value["duration"])
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(value["labels"]), &labels); err != nil || len(labels) == 0 {
+		return "", 0, fmt.Errorf("alert labels missing in callback")
+	}
+
+	// 只向配置的 Alertmanager 创建静默，不使用回调请求中携带的地址
+	client := common.AlertmanagerClient
+	if client == nil {
+		return "", 0, fmt.Errorf("ALERTMANAGER_URL not configured")
+	}
+
+	now := time.Now()
+	silenceID, err := client.CreateSilence(amapi.Silence{
+		Matchers:  amapi.MatchersFromLabels(labels),
+		StartsAt:  now,
+		EndsAt:    now.Add(duration),
+		CreatedBy: "feishu:" + operator,
+		Comment:   fmt.Sprintf("Silenced %s for %s from Feishu card", alertName, value["duration"]),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return silenceID, duration, nil
+}
+
+// actionCard 返回告警最近发送的卡片副本；找不到时创建只包含标题的卡片。
+func actionCard(fingerprint, alertName string) *CardMessage {
+	if original, ok := cards.Get(fingerprint); ok {
+		card := &CardMessage{MsgType: original.MsgType}
+		card.Card.Header = original.Card.Header
+		card.Card.Elements = append(card.Card.Elements, original.Card.Elements...)
+		return card
+	}
+	return NewCardMessage(fmt.Sprintf("[FIRING] %s", alertName), "red", "**告警**: "+alertName, "", "")
+}
+
+// removeButtons 移除指定动作的回调按钮，按钮全部移除时同时移除按钮区块。
+func (c *CardMessage) removeButtons(action string) {
+	elements := make([]map[string]interface{}, 0, len(c.Card.Elements))
+	for _, element := range c.Card.Elements {
+		actions, ok := element["actions"].([]map[string]interface{})
+		if element["tag"] != "action" || !ok {
+			elements = append(elements, element)
+			continue
+		}
+
+		kept := make([]map[string]interface{}, 0, len(actions))
+		for _, a := range actions {
+			if value, ok := a["value"].(map[string]string); ok && value["action"] == action {
+				continue
+			}
+			kept = append(kept, a)
+		}
+		if len(kept) > 0 {
+			elements = append(elements, map[string]interface{}{"tag": "action", "actions": kept})
+		}
+	}
+	c.Card.Elements = elements
+}
+
+// verifySignature 校验飞书请求签名。
+// 旧版卡片回调：sha1(timestamp + nonce + verification_token + body)；
+// 配置 Encrypt Key 的事件回调：sha256(timestamp + nonce + encrypt_key + body)。
+// 飞书只在应用配置了 Encrypt Key 后才对请求签名，未配置 FEISHU_ENCRYPT_KEY 且请求没有签名时只校验 token。
+func verifySignature(header http.Header, body []byte, token, encryptKey string) error {
+	timestamp := header.Get("X-Lark-Request-Timestamp")
+	nonce := header.Get("X-Lark-Request-Nonce")
+	signature := header.Get("X-Lark-Signature")
+	if signature == "" && encryptKey == "" {
+		return nil
+	}
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing signature headers")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > callbackMaxSkew || skew < -callbackMaxSkew {
+		return fmt.Errorf("timestamp out of range: %s", timestamp)
+	}
+
+	sha1Sum := sha1.Sum([]byte(timestamp + nonce + token + string(body))) //nolint:gosec // 签名算法要求
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sha1Sum[:])), []byte(signature)) == 1 {
+		return nil
+	}
+	if encryptKey != "" {
+		sha256Sum := sha256.Sum256([]byte(timestamp + nonce + encryptKey + string(body)))
+		if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sha256Sum[:])), []byte(signature)) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature mismatch")
+}
+
+// decrypt 解密飞书加密的回调内容（AES-256-CBC，密钥为 Encrypt Key 的 SHA256）。
+func decrypt(encrypted, encryptKey string) ([]byte, error) {
+	if encryptKey == "" {
+		return nil, fmt.Errorf("FEISHU_ENCRYPT_KEY not configured")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(data) < aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid ciphertext length %d", len(data))
+	}
+
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	iv, ciphertext := data[:aes.BlockSize], data[aes.BlockSize:]
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+
+	// 去除 PKCS7 填充
+	if len(plain) == 0 {
+		return nil, fmt.Errorf("empty plaintext")
+	}
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(plain) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+// equalToken 以常量时间比较 token。
+func equalToken(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// writeJSON 以 JSON 格式写入响应。
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,403 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/ack"
+	"alertmanagerWebhookAdapter/pkg/amapi"
+	"alertmanagerWebhookAdapter/pkg/common"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1" //nolint:gosec // 飞书卡片回调签名算法要求使用 SHA1
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testToken      = "v-token"
+	testEncryptKey = "encrypt-key"
+)
+
+// encrypt 按飞书的方式加密回调内容：AES-256-CBC，密钥为 Encrypt Key 的 SHA256，IV 放在密文前。
+// plain 已按块长度对齐时不再填充，用于构造错误的填充。
+func encrypt(t *testing.T, plain []byte, encryptKey string, pad bool) string {
+	t.Helper()
+	if pad {
+		n := aes.BlockSize - len(plain)%aes.BlockSize
+		plain = append(plain, bytes.Repeat([]byte{byte(n)}, n)...)
+	}
+	key := sha256.Sum256([]byte(encryptKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := []byte("0123456789abcdef")
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return base64.StdEncoding.EncodeToString(append(iv, out...))
+}
+
+// signedHeader 返回带有签名的请求头，sha256 为 true 时使用 Encrypt Key 签名，否则使用 token 的 sha1 签名。
+func signedHeader(at time.Time, secret string, body []byte, sha256Sig bool) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	nonce := "nonce"
+	var signature string
+	if sha256Sig {
+		sum := sha256.Sum256([]byte(timestamp + nonce + secret + string(body)))
+		signature = hex.EncodeToString(sum[:])
+	} else {
+		sum := sha1.Sum([]byte(timestamp + nonce + secret + string(body))) //nolint:gosec // 签名算法要求
+		signature = hex.EncodeToString(sum[:])
+	}
+	h := http.Header{}
+	h.Set("X-Lark-Request-Timestamp", timestamp)
+	h.Set("X-Lark-Request-Nonce", nonce)
+	h.Set("X-Lark-Signature", signature)
+	return h
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"action":{"value":{"action":"ack"}}}`)
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		header     http.Header
+		encryptKey string
+		wantErr    bool
+	}{
+		{"sha1 with token", signedHeader(now, testToken, body, false), "", false},
+		{"sha256 with encrypt key", signedHeader(now, testEncryptKey, body, true), testEncryptKey, false},
+		{"sha1 with encrypt key configured", signedHeader(now, testToken, body, false), testEncryptKey, false},
+		{"sha256 without encrypt key configured", signedHeader(now, testEncryptKey, body, true), "", true},
+		{"wrong secret", signedHeader(now, "other", body, true), testEncryptKey, true},
+		{"timestamp too old", signedHeader(now.Add(-6*time.Minute), testEncryptKey, body, true), testEncryptKey, true},
+		{"timestamp in future", signedHeader(now.Add(6*time.Minute), testEncryptKey, body, true), testEncryptKey, true},
+		{"small skew", signedHeader(now.Add(-4*time.Minute), testEncryptKey, body, true), testEncryptKey, false},
+		{"unsigned without encrypt key", http.Header{}, "", false},
+		{"unsigned with encrypt key", http.Header{}, testEncryptKey, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.header, body, testToken, tt.encryptKey)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 签名覆盖请求体
+	header := signedHeader(now, testEncryptKey, body, true)
+	if err := verifySignature(header, []byte(`{"action":{}}`), testToken, testEncryptKey); err == nil {
+		t.Error("signature of another body should not match")
+	}
+	header.Set("X-Lark-Request-Timestamp", "yesterday")
+	if err := verifySignature(header, body, testToken, testEncryptKey); err == nil {
+		t.Error("invalid timestamp should be rejected")
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	plain := []byte(`{"challenge":"abc","token":"v-token","type":"url_verification"}`)
+
+	got, err := decrypt(encrypt(t, plain, testEncryptKey, true), testEncryptKey)
+	if err != nil || string(got) != string(plain) {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+	// 正好一个块长度的内容需要填充一整个块
+	block := []byte("0123456789abcdef")
+	if got, err := decrypt(encrypt(t, block, testEncryptKey, true), testEncryptKey); err != nil || string(got) != string(block) {
+		t.Errorf("decrypt full block = %q, %v", got, err)
+	}
+
+	tests := []struct {
+		name       string
+		encrypted  string
+		encryptKey string
+	}{
+		{"no encrypt key", encrypt(t, plain, testEncryptKey, true), ""},
+		{"invalid base64", "not base64!", testEncryptKey},
+		{"short ciphertext", base64.StdEncoding.EncodeToString([]byte("short")), testEncryptKey},
+		{"unaligned ciphertext", base64.StdEncoding.EncodeToString(make([]byte, aes.BlockSize+3)), testEncryptKey},
+		{"zero padding", encrypt(t, []byte("0123456789abcde\x00"), testEncryptKey, false), testEncryptKey},
+		{"padding too large", encrypt(t, []byte("0123456789abcde\x11"), testEncryptKey, false), testEncryptKey},
+		{"inconsistent padding", encrypt(t, []byte("0123456789abc\x01\x02\x03"), testEncryptKey, false), testEncryptKey},
+		{"wrong key", encrypt(t, []byte("0123456789abcdef"), "other-key", false), testEncryptKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := decrypt(tt.encrypted, tt.encryptKey); err == nil {
+				t.Errorf("decrypt = %q, want error", got)
+			}
+		})
+	}
+}
+
+// fakeAlertmanager 模拟 Alertmanager 的静默接口。
+type fakeAlertmanager struct {
+	mu       sync.Mutex
+	silences []amapi.Silence
+}
+
+func newFakeAlertmanager(t *testing.T) *fakeAlertmanager {
+	t.Helper()
+	am := &fakeAlertmanager{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/silences" {
+			http.NotFound(w, r)
+			return
+		}
+		var s amapi.Silence
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		am.mu.Lock()
+		am.silences = append(am.silences, s)
+		n := len(am.silences)
+		am.mu.Unlock()
+		fmt.Fprintf(w, `{"silenceID":"silence-%d"}`, n)
+	}))
+	t.Cleanup(srv.Close)
+
+	saved := common.AlertmanagerClient
+	t.Cleanup(func() { common.AlertmanagerClient = saved })
+	common.AlertmanagerClient = &amapi.Client{URL: srv.URL, Timeout: 5 * time.Second}
+	return am
+}
+
+// useCallback 在测试期间启用卡片回调。
+func useCallback(t *testing.T, token, encryptKey string) {
+	t.Helper()
+	saved := common.FeishuApp
+	t.Cleanup(func() { common.FeishuApp = saved })
+	common.FeishuApp.VerificationToken = token
+	common.FeishuApp.EncryptKey = encryptKey
+	common.FeishuApp.MessageTTL = time.Hour
+}
+
+// callback 发送回调请求，返回响应状态码和 JSON 响应。
+func callback(t *testing.T, body []byte, header http.Header) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/feishu/callback", bytes.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	CallbackHandler(rec, req)
+
+	var resp map[string]interface{}
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %s: %v", rec.Body, err)
+		}
+	}
+	return rec.Code, resp
+}
+
+// legacyBody 返回旧版卡片回调的请求体。
+func legacyBody(token, action, fingerprint string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"open_id": "ou_alice",
+		"token":   token,
+		"action": map[string]interface{}{
+			"tag": "button",
+			"value": map[string]string{
+				"action": action, "duration": "1h", "fingerprint": fingerprint, "alertname": "HighCPU",
+				"labels": `{"alertname":"HighCPU","instance":"db-1"}`,
+			},
+		},
+	})
+	return body
+}
+
+// v2Body 返回 2.0 版卡片回调的请求体。
+func v2Body(token, action, fingerprint string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"schema": "2.0",
+		"header": map[string]string{"token": token, "event_type": "card.action.trigger"},
+		"event": map[string]interface{}{
+			"operator": map[string]string{"open_id": "ou_bob"},
+			"action": map[string]interface{}{
+				"tag": "button",
+				"value": map[string]string{
+					"action": action, "duration": "4h", "fingerprint": fingerprint, "alertname": "HighCPU",
+					"labels": `{"alertname":"HighCPU","instance":"db-1"}`,
+				},
+			},
+		},
+	})
+	return body
+}
+
+// cardText 返回卡片 JSON 中的所有文本，用于检查备注内容。
+func cardText(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestCallbackURLVerification(t *testing.T) {
+	useCallback(t, testToken, "")
+
+	body := []byte(`{"type":"url_verification","challenge":"c-123","token":"v-token"}`)
+	if code, resp := callback(t, body, nil); code != http.StatusOK || resp["challenge"] != "c-123" {
+		t.Errorf("url verification = %d %v", code, resp)
+	}
+	body = []byte(`{"type":"url_verification","challenge":"c-123","token":"wrong"}`)
+	if code, _ := callback(t, body, nil); code != http.StatusUnauthorized {
+		t.Errorf("url verification with wrong token = %d, want 401", code)
+	}
+
+	// 配置了 Encrypt Key 时校验请求也是加密的
+	useCallback(t, testToken, testEncryptKey)
+	encrypted, _ := json.Marshal(map[string]string{
+		"encrypt": encrypt(t, []byte(`{"type":"url_verification","challenge":"c-456","token":"v-token"}`), testEncryptKey, true),
+	})
+	if code, resp := callback(t, encrypted, nil); code != http.StatusOK || resp["challenge"] != "c-456" {
+		t.Errorf("encrypted url verification = %d %v", code, resp)
+	}
+
+	useCallback(t, "", "")
+	if code, _ := callback(t, body, nil); code != http.StatusNotFound {
+		t.Errorf("callback without token configured = %d, want 404", code)
+	}
+}
+
+func TestCallbackRejects(t *testing.T) {
+	am := newFakeAlertmanager(t)
+
+	t.Run("token mismatch", func(t *testing.T) {
+		useCallback(t, testToken, "")
+		if code, _ := callback(t, legacyBody("wrong", actionAck, "fp-reject-1"), nil); code != http.StatusUnauthorized {
+			t.Errorf("legacy = %d, want 401", code)
+		}
+		if code, _ := callback(t, v2Body("wrong", actionAck, "fp-reject-1"), nil); code != http.StatusUnauthorized {
+			t.Errorf("v2 = %d, want 401", code)
+		}
+		if _, ok := ack.Get("fp-reject-1"); ok {
+			t.Error("alert should not be acknowledged")
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		useCallback(t, testToken, "")
+		body := legacyBody(testToken, actionSilence, "fp-reject-2")
+		if code, _ := callback(t, body, signedHeader(time.Now(), "other", body, false)); code != http.StatusUnauthorized {
+			t.Errorf("bad signature = %d, want 401", code)
+		}
+	})
+
+	t.Run("unsigned with encrypt key", func(t *testing.T) {
+		useCallback(t, testToken, testEncryptKey)
+		body := legacyBody(testToken, actionSilence, "fp-reject-3")
+		if code, _ := callback(t, body, nil); code != http.StatusUnauthorized {
+			t.Errorf("unsigned = %d, want 401", code)
+		}
+	})
+
+	t.Run("invalid encrypted body", func(t *testing.T) {
+		useCallback(t, testToken, testEncryptKey)
+		body := []byte(`{"encrypt":"not base64!"}`)
+		if code, _ := callback(t, body, signedHeader(time.Now(), testEncryptKey, body, true)); code != http.StatusBadRequest {
+			t.Errorf("invalid encrypted body = %d, want 400", code)
+		}
+	})
+
+	if len(am.silences) != 0 {
+		t.Errorf("rejected callbacks created %d silences", len(am.silences))
+	}
+}
+
+func TestCallbackSilence(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	useCallback(t, testToken, "")
+
+	// 未配置 Encrypt Key 时飞书不签名，只校验 token
+	code, resp := callback(t, legacyBody(testToken, actionSilence, "fp-silence"), nil)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(am.silences) != 1 {
+		t.Fatalf("created %d silences, want 1", len(am.silences))
+	}
+	s := am.silences[0]
+	if len(s.Matchers) != 2 || s.Matchers[0] != (amapi.Matcher{Name: "alertname", Value: "HighCPU", IsEqual: true}) ||
+		s.Matchers[1] != (amapi.Matcher{Name: "instance", Value: "db-1", IsEqual: true}) {
+		t.Errorf("matchers = %+v", s.Matchers)
+	}
+	if d := s.EndsAt.Sub(s.StartsAt); d != time.Hour {
+		t.Errorf("silence duration = %v, want 1h", d)
+	}
+	if s.CreatedBy != "feishu:ou_alice" {
+		t.Errorf("createdBy = %q", s.CreatedBy)
+	}
+	text := cardText(resp)
+	if !strings.Contains(text, "silence-1") || strings.Contains(text, `"action":"silence"`) {
+		t.Errorf("card should show the silence ID without silence buttons: %s", text)
+	}
+	if !strings.Contains(text, `"update_multi":true`) {
+		t.Errorf("card should be shared: %s", text)
+	}
+
+	// 未配置 ALERTMANAGER_URL 时静默失败，不使用回调中的地址
+	common.AlertmanagerClient = nil
+	code, resp = callback(t, v2Body(testToken, actionSilence, "fp-silence"), nil)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if toast, _ := resp["toast"].(map[string]interface{}); toast["type"] != "error" {
+		t.Errorf("toast = %v, want error", resp["toast"])
+	}
+	if !strings.Contains(cardText(resp["card"]), "ALERTMANAGER_URL not configured") {
+		t.Errorf("card should show the failure: %s", cardText(resp["card"]))
+	}
+	if len(am.silences) != 1 {
+		t.Errorf("created %d silences, want 1", len(am.silences))
+	}
+}
+
+func TestCallbackAck(t *testing.T) {
+	useCallback(t, testToken, testEncryptKey)
+
+	// 配置了 Encrypt Key 时请求体加密并以 Encrypt Key 签名
+	encrypted, _ := json.Marshal(map[string]string{
+		"encrypt": encrypt(t, v2Body(testToken, actionAck, "fp-ack"), testEncryptKey, true),
+	})
+	code, resp := callback(t, encrypted, signedHeader(time.Now(), testEncryptKey, encrypted, true))
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if toast, _ := resp["toast"].(map[string]interface{}); toast["type"] != "success" || toast["content"] != "已认领" {
+		t.Errorf("toast = %v", resp["toast"])
+	}
+	info, ok := ack.Get("fp-ack")
+	if !ok || info.By != "ou_bob" {
+		t.Fatalf("ack = %+v, %v, want acknowledged by ou_bob", info, ok)
+	}
+	card := cardText(resp["card"])
+	if !strings.Contains(card, "认领了该告警") || strings.Contains(card, `"action":"ack"`) {
+		t.Errorf("card should show the acknowledgement without ack button: %s", card)
+	}
+
+	// 重复认领时展示最初的认领人
+	body := legacyBody(testToken, actionAck, "fp-ack")
+	code, resp = callback(t, body, signedHeader(time.Now(), testToken, body, false))
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if text := cardText(resp); !strings.Contains(text, "该告警已由") || !strings.Contains(text, "ou_bob") {
+		t.Errorf("card should show the first acknowledger: %s", text)
+	}
+	if info, _ := ack.Get("fp-ack"); info.By != "ou_bob" {
+		t.Errorf("acknowledged by %s, want ou_bob", info.By)
+	}
+}
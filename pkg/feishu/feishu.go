@@ -1,13 +1,32 @@
 package feishu
 
 import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// 飞书机器人 webhook 的限流错误码，可以重试。
+const (
+	codeTooManyRequests  = 9499  // 请求过于频繁
+	codeFrequencyLimited = 11232 // 机器人发送频率超出限制（每分钟 100 次、每秒 5 次）
+)
+
+// client 发送飞书机器人 webhook 消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// sender 飞书消息的发送接口，文本消息和卡片消息均实现了该接口。
+type sender interface {
+	// Send 按目标类型发送消息，app 类型的目标会返回创建的 message_id。
+	Send(name string, target common.FeishuTarget) (string, error)
+}
+
 // Message 定义了发送到飞书的消息结构。
 type Message struct {
 	MsgType string `json:"msg_type"`
@@ -31,26 +50,31 @@ func NewMessage(text string) *Message {
 // SendToFeishu 发送消息到指定的飞书 webhook URL。
 func (f *Message) SendToFeishu(webhookURL string, target string) error {
 	body, _ := json.Marshal(f)
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("failed to send to %s: %w", target, err)
+	if err := postWebhook(webhookURL, target, body); err != nil {
+		return err
 	}
+	log.Printf("✅ Sent to %s", target)
+	return nil
+}
 
-	// 检查 resp.Body.Close() 的错误
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			log.Printf("failed to close response body: %v", cerr)
-		}
-	}()
+// Send 按目标类型发送文本消息。
+func (f *Message) Send(name string, target common.FeishuTarget) (string, error) {
+	if target.Type == common.FeishuTargetApp {
+		return sendByApp(name, target.ChatID, f.MsgType, f.Content)
+	}
+	return "", f.SendToFeishu(target.URL, name)
+}
 
-	log.Printf("✅ Sent to %s: %s", target, resp.Status)
-	return nil
+// CardConfig 卡片的全局配置。
+type CardConfig struct {
+	UpdateMulti bool `json:"update_multi,omitempty"` // 共享卡片，飞书只允许更新声明了该配置的卡片
 }
 
 // CardMessage 定义了飞书富文本卡片消息结构。
 type CardMessage struct {
 	MsgType string `json:"msg_type"`
 	Card    struct {
+		Config *CardConfig `json:"config,omitempty"`
 		Header struct {
 			Title struct {
 				Tag     string `json:"tag"`
@@ -117,21 +141,231 @@ func NewCardMessage(title, color, infoText, descriptionText, logsText string) *C
 	return msg
 }
 
+// AddImage 在卡片末尾添加图片区块。
+// imageKey: 通过飞书图片上传接口获得的 image_key
+// alt: 图片的替代文本
+func (c *CardMessage) AddImage(imageKey, alt string) {
+	c.Card.Elements = append(c.Card.Elements, map[string]interface{}{
+		"tag":     "img",
+		"img_key": imageKey,
+		"alt": map[string]string{
+			"tag":     "plain_text",
+			"content": alt,
+		},
+	})
+}
+
+// 卡片回调按钮的动作类型。
+const (
+	actionSilence = "silence"
+	actionAck     = "ack"
+)
+
+// silenceDurations 卡片上提供的静默时长选项。
+var silenceDurations = []string{"1h", "4h", "24h"}
+
+// AddActions 在卡片末尾添加操作按钮。
+// 启用回调时，firing 状态的告警会带有认领按钮，配置了 ALERTMANAGER_URL 时还会带有静默按钮；
+// GeneratorURL 和 externalURL 不为空时添加跳转到 Prometheus 和 Alertmanager 的链接按钮。
+func (c *CardMessage) AddActions(alert common.Alert, alertName, externalURL string, callback bool) {
+	var actions []map[string]interface{}
+
+	if callback && alert.Status == "firing" && alert.Fingerprint != "" {
+		labels, _ := json.Marshal(alert.Labels)
+		value := func(action, duration string) map[string]string {
+			return map[string]string{
+				"action":      action,
+				"duration":    duration,
+				"fingerprint": alert.Fingerprint,
+				"alertname":   alertName,
+				"labels":      string(labels),
+			}
+		}
+		if common.AlertmanagerClient != nil {
+			for _, d := range silenceDurations {
+				actions = append(actions, button("静默 "+d, "default", value(actionSilence, d)))
+			}
+		}
+		actions = append(actions, button("认领", "primary", value(actionAck, "")))
+	}
+
+	if alert.GeneratorURL != "" {
+		actions = append(actions, linkButton("打开 Prometheus", alert.GeneratorURL))
+	}
+	if externalURL != "" {
+		actions = append(actions, linkButton("打开 Alertmanager", externalURL))
+	}
+
+	if len(actions) == 0 {
+		return
+	}
+	c.Card.Elements = append(c.Card.Elements, map[string]interface{}{
+		"tag":     "action",
+		"actions": actions,
+	})
+}
+
+// AddMentions 在卡片末尾添加 @ 提醒区块。
+// content 为 lark_md 格式的 at 标记，如 <at id=ou_xxx></at>。
+func (c *CardMessage) AddMentions(content string) {
+	c.Card.Elements = append(c.Card.Elements, map[string]interface{}{
+		"tag": "div",
+		"text": map[string]string{
+			"tag":     "lark_md",
+			"content": "**通知**: " + content,
+		},
+	})
+}
+
+// AddNote 在卡片末尾添加备注区块（lark_md 格式）。
+func (c *CardMessage) AddNote(content string) {
+	c.Card.Elements = append(c.Card.Elements, map[string]interface{}{
+		"tag": "note",
+		"elements": []map[string]string{
+			{"tag": "lark_md", "content": content},
+		},
+	})
+}
+
+// button 创建回调按钮。
+func button(text, buttonType string, value map[string]string) map[string]interface{} {
+	return map[string]interface{}{
+		"tag":   "button",
+		"text":  map[string]string{"tag": "plain_text", "content": text},
+		"type":  buttonType,
+		"value": value,
+	}
+}
+
+// linkButton 创建跳转链接按钮。
+func linkButton(text, url string) map[string]interface{} {
+	return map[string]interface{}{
+		"tag":  "button",
+		"text": map[string]string{"tag": "plain_text", "content": text},
+		"type": "default",
+		"url":  url,
+	}
+}
+
+// CardColor 根据告警状态和级别返回卡片标题颜色。
+func CardColor(status, severity string) string {
+	if status == "resolved" {
+		return "green"
+	}
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "red"
+	case "warning":
+		return "orange"
+	case "info":
+		return "blue"
+	default:
+		return "grey"
+	}
+}
+
+// Send 按目标类型发送卡片消息。
+// 通过应用发送的卡片声明为共享卡片，以便告警恢复时原地更新。
+func (c *CardMessage) Send(name string, target common.FeishuTarget) (string, error) {
+	if target.Type == common.FeishuTargetApp {
+		return sendByApp(name, target.ChatID, c.MsgType, c.shared().Card)
+	}
+	return "", c.SendToFeishu(target.URL, name)
+}
+
+// shared 返回声明为共享卡片（update_multi）的副本，卡片本身可能被缓存，不直接修改。
+func (c *CardMessage) shared() *CardMessage {
+	card := *c
+	card.Card.Config = &CardConfig{UpdateMulti: true}
+	return &card
+}
+
 // SendToFeishu 发送卡片消息到指定的飞书 webhook URL。
 func (c *CardMessage) SendToFeishu(webhookURL string, target string) error {
 	body, _ := json.Marshal(c)
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(body))
+	if err := postWebhook(webhookURL, target, body); err != nil {
+		return err
+	}
+	log.Printf("✅ Sent card to %s", target)
+	return nil
+}
+
+// postWebhook 发送消息到飞书机器人 webhook，并检查响应中的 code。
+// 飞书在签名校验失败、关键词不匹配等业务错误时同样返回 HTTP 200，只能通过 code 判断是否发送成功。
+// 限流和服务端错误可以重试，其他错误不再重试。
+func postWebhook(webhookURL, target string, body []byte) error {
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to send card to %s: %w", target, err)
+		return fmt.Errorf("failed to send to %s: %w", target, err)
 	}
+	defer resp.Body.Close()
 
-	// 检查 resp.Body.Close() 的错误
-	defer func() {
-		if cerr := resp.Body.Close(); cerr != nil {
-			log.Printf("failed to close response body: %v", cerr)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("feishu %s returned %s", target, resp.Status)
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return notify.Permanent(fmt.Errorf("feishu %s returned %s with invalid body: %w", target, resp.Status, err))
+	}
+	if result.Code != 0 {
+		err := fmt.Errorf("feishu %s returned code %d: %s", target, result.Code, result.Msg)
+		if result.Code == codeTooManyRequests || result.Code == codeFrequencyLimited {
+			return err
 		}
-	}()
+		return notify.Permanent(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return notify.Permanent(fmt.Errorf("feishu %s returned %s", target, resp.Status))
+	}
+	return nil
+}
 
-	log.Printf("✅ Sent card to %s: %s", target, resp.Status)
+// sendByApp 通过飞书应用的消息 API 发送消息到指定群聊，返回 message_id。
+func sendByApp(name, chatID, msgType string, content interface{}) (string, error) {
+	app := App()
+	if app == nil {
+		return "", fmt.Errorf("failed to send to %s: feishu app credentials not configured", name)
+	}
+
+	messageID, err := app.SendMessage(chatID, msgType, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+
+	log.Printf("✅ Sent %s message to %s: message_id=%s", msgType, name, messageID)
+	return messageID, nil
+}
+
+// updateByApp 通过飞书应用的消息 API 更新已发送的卡片消息。
+func updateByApp(name, messageID string, card *CardMessage) error {
+	app := App()
+	if app == nil {
+		return fmt.Errorf("failed to update message on %s: feishu app credentials not configured", name)
+	}
+
+	if err := app.UpdateCard(messageID, card.shared().Card); err != nil {
+		return fmt.Errorf("failed to update message on %s: %w", name, err)
+	}
+
+	log.Printf("✅ Updated card message on %s: message_id=%s", name, messageID)
 	return nil
 }
+
+// SendText 发送通用通知消息到指定名称的飞书目标，供升级等后台任务使用。
+func SendText(name string, msg notify.Message) error {
+	target, ok := common.FeishuTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("feishu target '%s' not found in configuration", name))
+	}
+
+	text := msg.Text
+	if len(msg.Mentions) > 0 {
+		text += "\n通知: " + textMentions(mentions{OpenIDs: msg.Mentions})
+	}
+	_, err := NewMessage(text).Send(name, target)
+	return err
+}
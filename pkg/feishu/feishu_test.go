@@ -0,0 +1,53 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostWebhook(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantErr  bool
+		attempts int // 按重试策略发送的次数，可以重试的错误为 3 次
+	}{
+		{"ok", http.StatusOK, `{"code":0,"msg":"success","data":{}}`, false, 1},
+		{"sign failure", http.StatusOK, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`, true, 1},
+		{"keyword mismatch", http.StatusOK, `{"code":19024,"msg":"Key Words Not Found"}`, true, 1},
+		{"rate limited", http.StatusOK, `{"code":11232,"msg":"frequency limited"}`, true, 3},
+		{"too many requests code", http.StatusBadRequest, `{"code":9499,"msg":"too many request"}`, true, 3},
+		{"http 429", http.StatusTooManyRequests, ``, true, 3},
+		{"server error", http.StatusBadGateway, `<html>bad gateway</html>`, true, 3},
+		{"not found", http.StatusNotFound, `404 page not found`, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			err := notify.Retry(func() error {
+				return NewMessage("hello").SendToFeishu(srv.URL, "ops")
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.attempts {
+				t.Errorf("attempts = %d, want %d", calls, tt.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/chart"
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/prometheus"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// graphPoints 图表期望的数据点数量，用于计算查询步长。
+const graphPoints = 120
+
+// maxGraphSeries Loki 指标查询最多绘制的序列数。
+const maxGraphSeries = 5
+
+// buildGraph 查询告警对应的指标数据，渲染为 PNG 并上传到飞书，返回 image_key。
+// 优先使用注释中的 log_metric_query 查询 Loki，否则查询 Prometheus。
+// 没有可用的查询语句时返回空字符串。
+func buildGraph(alert common.Alert, alertName string) (string, error) {
+	app := App()
+	if !common.GraphConfig.Enabled || app == nil {
+		return "", nil
+	}
+
+	end := time.Now()
+	if alert.Status == "resolved" && !alert.EndsAt.IsZero() && alert.EndsAt.Before(end) {
+		end = alert.EndsAt
+	}
+	start := end.Add(-common.GraphConfig.Range)
+	step := max(common.GraphConfig.Range/graphPoints, time.Second)
+
+	var (
+		series []chart.Series
+		err    error
+	)
+	switch {
+	case alert.Annotations["log_metric_query"] != "" && common.LokiConfig.Enabled && common.LokiClient != nil:
+		series, err = lokiGraphSeries(alert.Annotations["log_metric_query"], start, end, step)
+	case common.PrometheusConfig.Enabled && common.PrometheusClient != nil && common.MetricQuery(alert) != "":
+		series, err = prometheusGraphSeries(common.MetricQuery(alert), alert.Labels, start, end, step)
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(series) == 0 {
+		return "", nil
+	}
+
+	c := &chart.Chart{
+		Title:  alertName,
+		Width:  common.GraphConfig.Width,
+		Height: common.GraphConfig.Height,
+		Series: series,
+		Marker: alert.StartsAt,
+	}
+	image, err := c.RenderPNG()
+	if err != nil {
+		return "", fmt.Errorf("failed to render graph: %w", err)
+	}
+
+	return app.UploadImage(image, alertName+".png")
+}
+
+// prometheusGraphSeries 查询 Prometheus 区间数据，只保留与告警标签匹配的序列。
+func prometheusGraphSeries(query string, labels map[string]string, start, end time.Time,
+	step time.Duration,
+) ([]chart.Series, error) {
+	ctx, cancel := common.EnrichContext()
+	defer cancel()
+	result, err := common.PrometheusClient.QueryRange(ctx, query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := prometheus.MatchSeries(result, labels)
+	if !ok || len(s.Samples) == 0 {
+		return nil, nil
+	}
+
+	points := make([]chart.Point, 0, len(s.Samples))
+	for _, sample := range s.Samples {
+		points = append(points, chart.Point{Time: sample.Time, Value: sample.Value})
+	}
+	return []chart.Series{{Name: seriesName(s.Metric), Points: points}}, nil
+}
+
+// lokiGraphSeries 执行 Loki 指标查询，最多返回 maxGraphSeries 条序列。
+func lokiGraphSeries(query string, start, end time.Time, step time.Duration) ([]chart.Series, error) {
+	result, err := common.LokiClient.QueryMetric(query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]chart.Series, 0, min(len(result), maxGraphSeries))
+	for _, s := range result {
+		if len(series) >= maxGraphSeries {
+			break
+		}
+		points := make([]chart.Point, 0, len(s.Points))
+		for _, p := range s.Points {
+			points = append(points, chart.Point{Time: p.Time, Value: p.Value})
+		}
+		series = append(series, chart.Series{Name: seriesName(s.Metric), Points: points})
+	}
+	return series, nil
+}
+
+// seriesName 将序列标签格式化为 {k="v", ...} 形式的图例名称。
+func seriesName(metric map[string]string) string {
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return metric["__name__"] + "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -3,12 +3,14 @@ package feishu
 
 import (
 	"alertmanagerWebhookAdapter/pkg/common"
-	"alertmanagerWebhookAdapter/pkg/loki"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Handler 处理来自 Alertmanager 的 webhook 请求。
@@ -33,25 +35,8 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 处理每个告警 - 单独发送到飞书，避免消息合并
-	targetParam := r.URL.Query().Get("target")
-	var targetWebhooks map[string]string
-
-	if targetParam != "" {
-		// 解析指定的目标
-		targetWebhooks = make(map[string]string)
-		targets := strings.Split(targetParam, ",")
-		for _, t := range targets {
-			t = strings.TrimSpace(strings.ToLower(t))
-			if url, exists := common.FeishuWebhook[t]; exists {
-				targetWebhooks[t] = url
-			} else {
-				log.Printf("⚠️ Target '%s' not found in configuration", t)
-			}
-		}
-	} else {
-		// 广播到所有配置的飞书
-		targetWebhooks = common.FeishuWebhook
-	}
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的飞书
+	targetWebhooks := common.SelectTargets(r.URL.Query().Get("target"), common.FeishuTargets)
 
 	// 如果没有有效的目标，直接返回
 	if len(targetWebhooks) == 0 {
@@ -65,6 +50,15 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 
 	// 逐个处理告警
 	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "feishu", targetWebhooks)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
 		// 为每个告警构建消息
 		var builder strings.Builder
 
@@ -89,36 +83,11 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			desc = "无详细描述"
 		}
 
-		triggerLogs := alert.Annotations["trigger_logs"]
-
 		// 尝试从 Loki 查询实际日志内容
-		if common.LokiConfig.Enabled && common.LokiClient != nil {
-			logQuery := alert.Annotations["log_query"]
-			if logQuery != "" {
-				logs, err := common.LokiClient.QueryLogs(
-					logQuery,
-					common.LokiConfig.LogLimit,
-					common.LokiConfig.QueryRange,
-				)
-				if err != nil {
-					log.Printf("⚠️ Failed to query Loki for alert %s: %v", alertName, err)
-					// 查询失败时保留原有的 trigger_logs 或添加错误提示
-					if triggerLogs == "" {
-						triggerLogs = fmt.Sprintf("（Loki 日志查询失败: %v）", err)
-					}
-				} else if len(logs) > 0 {
-					// 查询成功，格式化日志内容
-					formattedLogs := loki.FormatLogs(logs, common.LokiConfig.LogLimit)
-					triggerLogs = formattedLogs
-					log.Printf("✅ Queried %d logs from Loki for alert %s", len(logs), alertName)
-				} else {
-					// 查询成功但没有日志
-					if triggerLogs == "" {
-						triggerLogs = "（查询时间范围内无匹配日志）"
-					}
-				}
-			}
-		}
+		triggerLogs := common.TriggerLogs(alert, "（Loki 日志查询失败: %v）", "（查询时间范围内无匹配日志）")
+
+		// 尝试从 Prometheus 查询指标的当前值和近期趋势
+		metricTrend := common.MetricTrendText(alert)
 
 		builder.WriteString(fmt.Sprintf("🚨 *%s*\n状态: %s\n摘要: %s\n详情: %s\n",
 			alertName, status, summary, desc))
@@ -128,16 +97,31 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			builder.WriteString(fmt.Sprintf("触发日志:\n%s\n", triggerLogs))
 		}
 
-		text := builder.String()
-		msg := NewMessage(text)
+		// 如果有指标趋势信息，则添加显示
+		if metricTrend != "" {
+			builder.WriteString(fmt.Sprintf("指标趋势: %s\n", metricTrend))
+		}
 
-		// 发送到所有目标
-		for name, webhookURL := range targetWebhooks {
-			if err := msg.SendToFeishu(webhookURL, name); err != nil {
-				log.Printf("❌ Failed to send alert %s to %s: %v", alertName, name, err)
-			} else {
-				log.Printf("✅ Sent alert %s to feishu %s", alertName, name)
+		var msg sender
+		if common.FeishuMsgType == "card" {
+			msg = buildCard(alert, payload.ExternalURL, alertName, status, summary, desc, triggerLogs, metricTrend, flap)
+		} else {
+			// 抖动中的告警附带抖动提示
+			if flap.Flapping {
+				builder.WriteString(fmt.Sprintf("🔀 %s\n", flap))
 			}
+			// 根据告警标签和级别 @ 相关负责人
+			if mention := mentionsFor(alert); !mention.empty() {
+				builder.WriteString(fmt.Sprintf("通知: %s\n", textMentions(mention)))
+			}
+			msg = NewMessage(builder.String())
+		}
+
+		// 发送到所有目标
+		for name, target := range sendTargets {
+			_ = delivery.Send(alert, "feishu", name, func() error {
+				return sendAlert(msg, alert, name, target)
+			})
 		}
 	}
 
@@ -146,3 +130,94 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		log.Printf("❌ Failed to write response: %v", err)
 	}
 }
+
+// buildCard 为单个告警构建飞书卡片消息，启用图表时附带指标折线图。
+func buildCard(alert common.Alert, externalURL, alertName, status, summary, desc, triggerLogs,
+	metricTrend string, flap flapping.Status,
+) *CardMessage {
+	severity := alert.Labels["severity"]
+
+	var info strings.Builder
+	fmt.Fprintf(&info, "**状态**: %s\n", status)
+	if severity != "" {
+		fmt.Fprintf(&info, "**级别**: %s\n", severity)
+	}
+	fmt.Fprintf(&info, "**摘要**: %s\n", summary)
+	if !alert.StartsAt.IsZero() {
+		fmt.Fprintf(&info, "**开始时间**: %s\n", alert.StartsAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		fmt.Fprintf(&info, "**恢复时间**: %s\n", alert.EndsAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(&info, "**持续时间**: %s\n", alert.EndsAt.Sub(alert.StartsAt).Round(time.Second))
+	}
+	if metricTrend != "" {
+		fmt.Fprintf(&info, "**指标趋势**: %s\n", metricTrend)
+	}
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(status), alertName)
+	if flap.Flapping {
+		title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(status), alertName)
+	}
+	card := NewCardMessage(title, CardColor(status, severity), info.String(), desc, triggerLogs)
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		card.AddNote("🔀 " + flap.String())
+	}
+
+	// 根据告警标签和级别 @ 相关负责人
+	if mention := mentionsFor(alert); !mention.empty() {
+		card.AddMentions(cardMentions(mention))
+	}
+
+	imageKey, err := buildGraph(alert, alertName)
+	if err != nil {
+		log.Printf("⚠️ Failed to build metric graph for alert %s: %v", alertName, err)
+	} else if imageKey != "" {
+		card.AddImage(imageKey, alertName)
+	}
+
+	callback := common.FeishuApp.VerificationToken != ""
+	card.AddActions(alert, alertName, externalURL, callback)
+
+	// 记录卡片内容，按钮回调时在原卡片基础上更新
+	if callback && alert.Fingerprint != "" {
+		if status == "firing" {
+			cards.Put(alert.Fingerprint, card, common.FeishuApp.MessageTTL)
+		} else {
+			cards.Delete(alert.Fingerprint)
+		}
+	}
+
+	return card
+}
+
+// sendAlert 发送单个告警到指定目标。
+// 对于 app 类型目标的卡片消息，会记录告警对应的 message_id；
+// 同一告警恢复时直接将原卡片更新为 RESOLVED，更新失败时再发送新消息。
+func sendAlert(msg sender, alert common.Alert, name string, target common.FeishuTarget) error {
+	card, isCard := msg.(*CardMessage)
+	trackable := isCard && target.Type == common.FeishuTargetApp && alert.Fingerprint != ""
+	key := messageKey(alert.Fingerprint, name)
+
+	if trackable && alert.Status == "resolved" {
+		if messageID, ok := messages.Get(key); ok {
+			err := updateByApp(name, messageID, card)
+			if err == nil {
+				messages.Delete(key)
+				return nil
+			}
+			log.Printf("⚠️ Failed to update message %s for resolved alert, sending a new one: %v", messageID, err)
+		}
+	}
+
+	messageID, err := msg.Send(name, target)
+	if err != nil {
+		return err
+	}
+
+	if trackable && alert.Status == "firing" && messageID != "" {
+		messages.Put(key, messageID, common.FeishuApp.MessageTTL)
+	}
+	return nil
+}
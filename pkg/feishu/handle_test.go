@@ -0,0 +1,233 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFeishu 模拟飞书开放平台的 token、发送消息、更新消息和按邮箱查询用户接口。
+type fakeFeishu struct {
+	mu         sync.Mutex
+	sent       []string          // 发送的消息类型
+	updated    []string          // 更新的 message_id
+	failUpdate map[string]bool   // 更新时返回错误的 message_id
+	shared     map[string]bool   // 声明了 update_multi 的卡片消息，只有这些消息可以更新
+	users      map[string]string // 通讯录中的用户，邮箱 → open_id
+	lookups    int               // 按邮箱查询用户的次数
+	failLookup bool              // 查询用户时返回错误
+}
+
+// updateMulti 判断消息内容是否为声明了 update_multi 的卡片。
+func updateMulti(content string) bool {
+	var card struct {
+		Config struct {
+			UpdateMulti bool `json:"update_multi"`
+		} `json:"config"`
+	}
+	return json.Unmarshal([]byte(content), &card) == nil && card.Config.UpdateMulti
+}
+
+// newFakeFeishu 启动模拟服务器，并让 App() 返回指向该服务器的客户端。
+func newFakeFeishu(t *testing.T) *fakeFeishu {
+	t.Helper()
+	f := &fakeFeishu{failUpdate: make(map[string]bool), shared: make(map[string]bool), users: make(map[string]string)}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+
+	appOnce.Do(func() {})
+	appClient = &AppClient{BaseURL: srv.URL, AppID: "cli_test", AppSecret: "secret", Timeout: 5 * time.Second}
+	common.FeishuApp.MessageTTL = time.Hour
+	return f
+}
+
+func (f *fakeFeishu) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/open-apis/auth/v3/tenant_access_token/internal":
+		fmt.Fprint(w, `{"code":0,"msg":"ok","tenant_access_token":"t-test","expire":7200}`)
+	case r.Header.Get("Authorization") != "Bearer t-test":
+		fmt.Fprint(w, `{"code":99991663,"msg":"invalid token"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/open-apis/im/v1/messages":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.sent = append(f.sent, body["msg_type"])
+		id := fmt.Sprintf("om_%d", len(f.sent))
+		f.shared[id] = body["msg_type"] == "interactive" && updateMulti(body["content"])
+		fmt.Fprintf(w, `{"code":0,"msg":"ok","data":{"message_id":%q}}`, id)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/open-apis/im/v1/messages/"):
+		id := strings.TrimPrefix(r.URL.Path, "/open-apis/im/v1/messages/")
+		if f.failUpdate[id] {
+			fmt.Fprint(w, `{"code":230020,"msg":"message has been recalled"}`)
+			return
+		}
+		// 飞书只允许更新原卡片和新卡片都声明了 update_multi 的共享卡片
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if !f.shared[id] || !updateMulti(body["content"]) {
+			fmt.Fprint(w, `{"code":230099,"msg":"card is not shared, update_multi is required"}`)
+			return
+		}
+		f.updated = append(f.updated, id)
+		fmt.Fprint(w, `{"code":0,"msg":"ok"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/open-apis/contact/v3/users/batch_get_id":
+		f.lookups++
+		if f.failLookup {
+			fmt.Fprint(w, `{"code":99991672,"msg":"no permission"}`)
+			return
+		}
+		var body struct {
+			Emails []string `json:"emails"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		users := make([]string, 0, len(body.Emails))
+		for _, email := range body.Emails {
+			// 未找到的用户同样返回，但没有 user_id
+			users = append(users, fmt.Sprintf(`{"email":%q,"user_id":%q}`, email, f.users[email]))
+		}
+		fmt.Fprintf(w, `{"code":0,"msg":"ok","data":{"user_list":[%s]}}`, strings.Join(users, ","))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeFeishu) counts() (sent, updated int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.sent), len(f.updated)
+}
+
+var appTarget = common.FeishuTarget{Type: common.FeishuTargetApp, ChatID: "oc_test"}
+
+func testAlert(fingerprint, status string) common.Alert {
+	return common.Alert{
+		Status:      status,
+		Labels:      map[string]string{"alertname": "HighCPU"},
+		Fingerprint: fingerprint,
+		StartsAt:    time.Now(),
+	}
+}
+
+func testCard(status string) *CardMessage {
+	return NewCardMessage("["+strings.ToUpper(status)+"] HighCPU", CardColor(status, "critical"), "info", "desc", "")
+}
+
+func TestSendAlertUpdatesCardOnResolve(t *testing.T) {
+	f := newFakeFeishu(t)
+
+	if err := sendAlert(testCard("firing"), testAlert("fp-update", "firing"), "oncall", appTarget); err != nil {
+		t.Fatalf("firing: %v", err)
+	}
+	messageID, ok := messages.Get(messageKey("fp-update", "oncall"))
+	if !ok || messageID != "om_1" {
+		t.Fatalf("stored message_id = %q, %v; want om_1", messageID, ok)
+	}
+
+	if err := sendAlert(testCard("resolved"), testAlert("fp-update", "resolved"), "oncall", appTarget); err != nil {
+		t.Fatalf("resolved: %v", err)
+	}
+	if sent, updated := f.counts(); sent != 1 || updated != 1 {
+		t.Errorf("sent=%d updated=%d, want 1 message updated in place", sent, updated)
+	}
+	if len(f.updated) > 0 && f.updated[0] != "om_1" {
+		t.Errorf("updated %s, want om_1", f.updated[0])
+	}
+	if _, ok := messages.Get(messageKey("fp-update", "oncall")); ok {
+		t.Error("message mapping should be removed after resolve")
+	}
+}
+
+func TestSendAlertFallsBackWhenUpdateFails(t *testing.T) {
+	f := newFakeFeishu(t)
+
+	if err := sendAlert(testCard("firing"), testAlert("fp-fallback", "firing"), "oncall", appTarget); err != nil {
+		t.Fatalf("firing: %v", err)
+	}
+	f.failUpdate["om_1"] = true
+
+	if err := sendAlert(testCard("resolved"), testAlert("fp-fallback", "resolved"), "oncall", appTarget); err != nil {
+		t.Fatalf("resolved: %v", err)
+	}
+	if sent, updated := f.counts(); sent != 2 || updated != 0 {
+		t.Errorf("sent=%d updated=%d, want a new message after the failed update", sent, updated)
+	}
+}
+
+func TestSendAlertResolvedWithoutMapping(t *testing.T) {
+	f := newFakeFeishu(t)
+
+	// 原消息记录已过期（或 adapter 重启过）时发送新消息
+	messages.Put(messageKey("fp-expired", "oncall"), "om_old", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if err := sendAlert(testCard("resolved"), testAlert("fp-expired", "resolved"), "oncall", appTarget); err != nil {
+		t.Fatalf("resolved: %v", err)
+	}
+	if sent, updated := f.counts(); sent != 1 || updated != 0 {
+		t.Errorf("sent=%d updated=%d, want a new message", sent, updated)
+	}
+}
+
+func TestSendAlertUntrackedMessages(t *testing.T) {
+	f := newFakeFeishu(t)
+
+	// 文本消息和没有指纹的告警不记录 message_id
+	if err := sendAlert(NewMessage("text"), testAlert("fp-text", "firing"), "oncall", appTarget); err != nil {
+		t.Fatalf("text: %v", err)
+	}
+	if _, ok := messages.Get(messageKey("fp-text", "oncall")); ok {
+		t.Error("text message should not be tracked")
+	}
+	if err := sendAlert(testCard("firing"), testAlert("", "firing"), "oncall", appTarget); err != nil {
+		t.Fatalf("no fingerprint: %v", err)
+	}
+	if _, ok := messages.Get(messageKey("", "oncall")); ok {
+		t.Error("alert without fingerprint should not be tracked")
+	}
+	if sent, _ := f.counts(); sent != 2 {
+		t.Errorf("sent=%d, want 2", sent)
+	}
+	if f.sent[0] != "text" || f.sent[1] != "interactive" {
+		t.Errorf("msg types = %v, want [text interactive]", f.sent)
+	}
+}
+
+func TestSendAlertRejectsUnsharedCardUpdate(t *testing.T) {
+	f := newFakeFeishu(t)
+
+	// 原卡片没有声明 update_multi（如更早版本发送的卡片）时无法更新，改为发送新消息
+	if _, err := App().SendMessage(appTarget.ChatID, "interactive", testCard("firing").Card); err != nil {
+		t.Fatalf("send unshared card: %v", err)
+	}
+	messages.Put(messageKey("fp-unshared", "oncall"), "om_1", time.Hour)
+
+	if err := sendAlert(testCard("resolved"), testAlert("fp-unshared", "resolved"), "oncall", appTarget); err != nil {
+		t.Fatalf("resolved: %v", err)
+	}
+	if sent, updated := f.counts(); sent != 2 || updated != 0 {
+		t.Errorf("sent=%d updated=%d, want a new message after the rejected update", sent, updated)
+	}
+}
+
+func TestCardSharedOnlyForApp(t *testing.T) {
+	card := testCard("firing")
+	if shared := card.shared(); shared.Card.Config == nil || !shared.Card.Config.UpdateMulti {
+		t.Error("shared card should declare update_multi")
+	}
+	if card.Card.Config != nil {
+		t.Error("shared should not modify the original card")
+	}
+
+	body, _ := json.Marshal(card)
+	if strings.Contains(string(body), "config") {
+		t.Errorf("webhook card should not have config: %s", body)
+	}
+}
@@ -0,0 +1,147 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mentions 单个告警需要 @ 的对象。
+type mentions struct {
+	OpenIDs []string // 飞书用户 open_id
+	Emails  []string // 无法解析为 open_id 的邮箱
+	All     bool     // 是否 @所有人
+}
+
+// empty 判断是否没有需要 @ 的对象。
+func (m mentions) empty() bool {
+	return len(m.OpenIDs) == 0 && len(m.Emails) == 0 && !m.All
+}
+
+// 邮箱解析结果的缓存时间。open_id 很少变化；未找到用户和请求失败只缓存较短时间，
+// 既避免每条告警都请求通讯录接口，也能在用户加入企业或接口恢复后及时生效。
+const (
+	emailResolvedTTL = 24 * time.Hour
+	emailMissTTL     = 10 * time.Minute
+	emailErrorTTL    = time.Minute
+)
+
+// emailOpenIDs 邮箱到 open_id 的解析缓存，空字符串表示未找到用户或请求失败。
+var emailOpenIDs = newTTLStore[string]()
+
+// mentionsFor 根据告警标签和级别计算需要 @ 的对象，包括标签映射的用户和当前值班人。
+// 只有 firing 状态且级别不低于 min_severity 的告警才会 @ 人。
+func mentionsFor(alert common.Alert) mentions {
+	var m mentions
+	if (!common.MentionConfig.Enabled && common.Oncall == nil) || alert.Status != "firing" {
+		return m
+	}
+
+	severity := alert.Labels["severity"]
+	if common.SeverityRank(severity) < common.SeverityRank(common.MentionConfig.MinSeverity) {
+		return m
+	}
+
+	for _, s := range common.MentionConfig.AtAll {
+		if strings.EqualFold(s, severity) {
+			m.All = true
+		}
+	}
+
+	openIDs := make(map[string]bool)
+	var emails []string
+	for _, rule := range common.MentionConfig.Rules {
+		if value, ok := alert.Labels[rule.Label]; !ok || value != rule.Value {
+			continue
+		}
+		for _, id := range rule.Users {
+			openIDs[id] = true
+		}
+		emails = append(emails, rule.Emails...)
+	}
+
+	// 当前值班人
+	for _, member := range common.Oncall.OnCall(alert.Labels, time.Now()) {
+		switch {
+		case member.OpenID != "":
+			openIDs[member.OpenID] = true
+		case member.Email != "":
+			emails = append(emails, member.Email)
+		}
+	}
+
+	// 通过飞书应用将邮箱解析为 open_id，解析失败的保留邮箱
+	for _, email := range emails {
+		if id := resolveEmail(email); id != "" {
+			openIDs[id] = true
+		} else {
+			m.Emails = append(m.Emails, email)
+		}
+	}
+
+	for id := range openIDs {
+		m.OpenIDs = append(m.OpenIDs, id)
+	}
+	sort.Strings(m.OpenIDs)
+	return m
+}
+
+// resolveEmail 将邮箱解析为 open_id，未配置飞书应用或解析失败时返回空字符串。
+func resolveEmail(email string) string {
+	if id, ok := emailOpenIDs.Get(email); ok {
+		return id
+	}
+
+	app := App()
+	if app == nil {
+		return ""
+	}
+	ids, err := app.OpenIDsByEmail([]string{email})
+	if err != nil {
+		log.Printf("⚠️ Failed to resolve feishu user by email %s: %v", email, err)
+		emailOpenIDs.Put(email, "", emailErrorTTL)
+		return ""
+	}
+	id := ids[email]
+	if id == "" {
+		log.Printf("⚠️ Feishu user not found by email %s, mention by email instead", email)
+		emailOpenIDs.Put(email, "", emailMissTTL)
+		return ""
+	}
+	emailOpenIDs.Put(email, id, emailResolvedTTL)
+	return id
+}
+
+// textMentions 生成文本消息中的 @ 标记。
+// 文本消息不支持按邮箱 @，未能解析为 open_id 的邮箱会被忽略。
+func textMentions(m mentions) string {
+	parts := make([]string, 0, len(m.OpenIDs)+1)
+	if m.All {
+		parts = append(parts, `<at user_id="all">所有人</at>`)
+	}
+	for _, id := range m.OpenIDs {
+		parts = append(parts, fmt.Sprintf(`<at user_id="%s"></at>`, id))
+	}
+	if len(m.Emails) > 0 {
+		log.Printf("⚠️ Text message cannot mention by email, skipped: %v", m.Emails)
+	}
+	return strings.Join(parts, " ")
+}
+
+// cardMentions 生成卡片 lark_md 中的 @ 标记。
+func cardMentions(m mentions) string {
+	parts := make([]string, 0, len(m.OpenIDs)+len(m.Emails)+1)
+	if m.All {
+		parts = append(parts, "<at id=all></at>")
+	}
+	for _, id := range m.OpenIDs {
+		parts = append(parts, fmt.Sprintf("<at id=%s></at>", id))
+	}
+	for _, email := range m.Emails {
+		parts = append(parts, fmt.Sprintf("<at email=%s></at>", email))
+	}
+	return strings.Join(parts, " ")
+}
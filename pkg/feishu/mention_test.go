@@ -0,0 +1,142 @@
+package feishu
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// useMentionConfig 在测试期间使用指定的 @ 提醒配置，并清空邮箱解析缓存。
+func useMentionConfig(t *testing.T, minSeverity string, atAll []string, rules ...common.MentionRule) {
+	t.Helper()
+	saved, savedOncall := common.MentionConfig, common.Oncall
+	t.Cleanup(func() { common.MentionConfig, common.Oncall = saved, savedOncall })
+
+	common.MentionConfig.Enabled = true
+	common.MentionConfig.MinSeverity = minSeverity
+	common.MentionConfig.AtAll = atAll
+	common.MentionConfig.Rules = rules
+	common.Oncall = nil
+	emailOpenIDs = newTTLStore[string]()
+}
+
+func mentionAlert(status string, labels map[string]string) common.Alert {
+	return common.Alert{Status: status, Labels: labels}
+}
+
+func TestMentionsFor(t *testing.T) {
+	useMentionConfig(t, "warning", []string{"critical"},
+		common.MentionRule{Label: "team", Value: "db", Users: []string{"ou_dba", "ou_lead"}},
+		common.MentionRule{Label: "team", Value: "web", Users: []string{"ou_web"}},
+		common.MentionRule{Label: "owner", Value: "alice", Users: []string{"ou_lead"}},
+	)
+
+	tests := []struct {
+		name  string
+		alert common.Alert
+		want  mentions
+	}{
+		{"matching rules merged", mentionAlert("firing", map[string]string{"severity": "warning", "team": "db", "owner": "alice"}),
+			mentions{OpenIDs: []string{"ou_dba", "ou_lead"}}},
+		{"value must match", mentionAlert("firing", map[string]string{"severity": "warning", "team": "dba"}),
+			mentions{}},
+		{"at_all severity", mentionAlert("firing", map[string]string{"severity": "Critical", "team": "web"}),
+			mentions{OpenIDs: []string{"ou_web"}, All: true}},
+		{"below min_severity", mentionAlert("firing", map[string]string{"severity": "info", "team": "db"}),
+			mentions{}},
+		{"unknown severity", mentionAlert("firing", map[string]string{"team": "db"}),
+			mentions{}},
+		{"resolved", mentionAlert("resolved", map[string]string{"severity": "critical", "team": "db"}),
+			mentions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mentionsFor(tt.alert); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mentionsFor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentionsForDisabled(t *testing.T) {
+	useMentionConfig(t, "info", []string{"critical"},
+		common.MentionRule{Label: "team", Value: "db", Users: []string{"ou_dba"}})
+	common.MentionConfig.Enabled = false
+
+	alert := mentionAlert("firing", map[string]string{"severity": "critical", "team": "db"})
+	if got := mentionsFor(alert); !got.empty() {
+		t.Errorf("mentionsFor = %+v, want no mentions when disabled", got)
+	}
+}
+
+func TestMentionsForEmailFallback(t *testing.T) {
+	f := newFakeFeishu(t)
+	f.users["alice@example.com"] = "ou_alice"
+	useMentionConfig(t, "critical", nil,
+		common.MentionRule{Label: "team", Value: "db", Emails: []string{"alice@example.com", "bob@example.com"}})
+
+	alert := mentionAlert("firing", map[string]string{"severity": "critical", "team": "db"})
+	want := mentions{OpenIDs: []string{"ou_alice"}, Emails: []string{"bob@example.com"}}
+	for i := 0; i < 2; i++ {
+		if got := mentionsFor(alert); !reflect.DeepEqual(got, want) {
+			t.Errorf("mentionsFor = %+v, want %+v", got, want)
+		}
+	}
+	// 解析成功和未找到的用户都会缓存，第二次不再查询
+	if f.lookups != 2 {
+		t.Errorf("lookups = %d, want 2", f.lookups)
+	}
+
+	if got := cardMentions(want); got != "<at id=ou_alice></at> <at email=bob@example.com></at>" {
+		t.Errorf("cardMentions = %q", got)
+	}
+	if got := textMentions(want); got != `<at user_id="ou_alice"></at>` {
+		t.Errorf("textMentions = %q, emails cannot be mentioned in text", got)
+	}
+}
+
+func TestResolveEmailCache(t *testing.T) {
+	f := newFakeFeishu(t)
+	useMentionConfig(t, "critical", nil)
+	f.users["alice@example.com"] = "ou_alice"
+
+	tests := []struct {
+		name  string
+		email string
+		fail  bool
+		want  string
+		ttl   time.Duration
+	}{
+		{"resolved", "alice@example.com", false, "ou_alice", emailResolvedTTL},
+		{"not found", "bob@example.com", false, "", emailMissTTL},
+		{"request failed", "carol@example.com", true, "", emailErrorTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.failLookup = tt.fail
+			lookups := f.lookups
+			for i := 0; i < 3; i++ {
+				if got := resolveEmail(tt.email); got != tt.want {
+					t.Errorf("resolveEmail = %q, want %q", got, tt.want)
+				}
+			}
+			if n := f.lookups - lookups; n != 1 {
+				t.Errorf("lookups = %d, want 1 (result cached)", n)
+			}
+
+			entry := emailOpenIDs.entries[tt.email]
+			if ttl := time.Until(entry.expiresAt); ttl > tt.ttl || ttl < tt.ttl-5*time.Second {
+				t.Errorf("cached for %v, want %v", ttl, tt.ttl)
+			}
+		})
+	}
+
+	// 缓存过期后重新查询，用户加入企业或接口恢复后生效
+	f.failLookup = false
+	f.users["carol@example.com"] = "ou_carol"
+	emailOpenIDs.Put("carol@example.com", "", -time.Second)
+	if got := resolveEmail("carol@example.com"); got != "ou_carol" {
+		t.Errorf("resolveEmail after expiry = %q, want ou_carol", got)
+	}
+}
@@ -0,0 +1,89 @@
+package feishu
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理过期记录的最小间隔。
+const sweepInterval = time.Minute
+
+// ttlStore 带过期时间的内存存储，记录在 TTL 到期后被清除。
+type ttlStore[V any] struct {
+	mu        sync.Mutex
+	entries   map[string]ttlEntry[V]
+	lastSweep time.Time
+}
+
+// ttlEntry 单条记录。
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// messages 记录告警与已发送飞书消息的对应关系，用于告警恢复时更新原消息。
+// key 为 告警指纹/目标名称，value 为 message_id。
+var messages = newTTLStore[string]()
+
+// cards 记录告警最近一次发送的卡片内容，用于处理卡片按钮回调时更新卡片。
+// key 为告警指纹。
+var cards = newTTLStore[*CardMessage]()
+
+// newTTLStore 创建一个空的存储。
+func newTTLStore[V any]() *ttlStore[V] {
+	return &ttlStore[V]{
+		entries: make(map[string]ttlEntry[V]),
+	}
+}
+
+// messageKey 生成告警指纹与目标对应的存储 key。
+func messageKey(fingerprint, target string) string {
+	return fingerprint + "/" + target
+}
+
+// Put 写入记录，已存在的记录会被覆盖。
+func (s *ttlStore[V]) Put(key string, value V, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.entries[key] = ttlEntry[V]{value: value, expiresAt: now.Add(ttl)}
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+}
+
+// Get 返回未过期的记录。
+func (s *ttlStore[V]) Get(key string) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero V
+	entry, ok := s.entries[key]
+	if !ok {
+		return zero, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Delete 删除记录。
+func (s *ttlStore[V]) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// sweep 清除所有过期记录，调用方需持有锁。
+func (s *ttlStore[V]) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,71 @@
+package feishu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLStoreGet(t *testing.T) {
+	s := newTTLStore[string]()
+	s.Put("a", "1", time.Hour)
+	s.Put("b", "2", 10*time.Millisecond)
+
+	if v, ok := s.Get("a"); !ok || v != "1" {
+		t.Errorf("Get(a) = %q, %v; want 1, true", v, ok)
+	}
+	if v, ok := s.Get("b"); !ok || v != "2" {
+		t.Errorf("Get(b) = %q, %v; want 2, true", v, ok)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := s.Get("b"); ok {
+		t.Error("Get(b) after TTL should miss")
+	}
+	if _, ok := s.entries["b"]; ok {
+		t.Error("expired entry should be removed on Get")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Error("Get(a) should still hit")
+	}
+}
+
+func TestTTLStoreOverwriteAndDelete(t *testing.T) {
+	s := newTTLStore[int]()
+	s.Put("k", 1, 10*time.Millisecond)
+	s.Put("k", 2, time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	if v, ok := s.Get("k"); !ok || v != 2 {
+		t.Errorf("Get(k) = %d, %v; want 2, true (overwrite extends TTL)", v, ok)
+	}
+
+	s.Delete("k")
+	if _, ok := s.Get("k"); ok {
+		t.Error("Get(k) after Delete should miss")
+	}
+	s.Delete("missing")
+}
+
+func TestTTLStoreSweep(t *testing.T) {
+	s := newTTLStore[string]()
+	s.Put("old1", "x", time.Millisecond)
+	s.Put("old2", "x", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// 距离上次清理不足 sweepInterval 时，Put 不清理其他过期记录
+	s.Put("new", "y", time.Hour)
+	if len(s.entries) != 3 {
+		t.Fatalf("entries = %d before sweep interval, want 3", len(s.entries))
+	}
+
+	// 超过 sweepInterval 后的 Put 清除所有过期记录
+	s.lastSweep = time.Now().Add(-sweepInterval)
+	s.Put("newer", "z", time.Hour)
+	if len(s.entries) != 2 {
+		t.Errorf("entries = %d after sweep, want 2", len(s.entries))
+	}
+	for _, key := range []string{"new", "newer"} {
+		if _, ok := s.entries[key]; !ok {
+			t.Errorf("entry %s should survive sweep", key)
+		}
+	}
+}
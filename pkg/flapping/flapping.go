@@ -0,0 +1,292 @@
+// Package flapping 检测频繁在 firing 和 resolved 之间切换的告警（抖动）。
+// 告警在检测窗口内的状态变化次数达到阈值后被标记为抖动：每个目标只收到一次抖动提示，
+// 之后的通知被静默，直到告警在稳定期内不再变化，再发送一条当前状态的通知。
+package flapping
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status 告警的抖动状态。
+type Status struct {
+	Flapping    bool          // 是否处于抖动状态
+	Transitions int           // 检测窗口内的状态变化次数
+	Window      time.Duration // 检测窗口
+	Stable      time.Duration // 解除抖动需要的稳定时长
+}
+
+// String 返回抖动状态的中文描述，未抖动时返回空字符串。
+func (s Status) String() string {
+	if !s.Flapping {
+		return ""
+	}
+	return fmt.Sprintf("告警抖动：%v 内状态变化 %d 次，状态稳定 %v 后恢复通知", s.Window, s.Transitions, s.Stable)
+}
+
+// alertState 单个告警的抖动跟踪状态。
+type alertState struct {
+	alert       common.Alert
+	status      string
+	transitions []time.Time // 检测窗口内的状态变化时间
+	lastSeen    time.Time
+	flapping    bool
+	noticed     map[string]bool // 已收到抖动提示的目标（channel/target）
+	muted       map[string]bool // 抖动期间被静默过的目标
+	timer       *time.Timer     // 稳定期定时器
+}
+
+// Detector 以告警指纹为 key 跟踪状态变化。
+type Detector struct {
+	mu        sync.Mutex
+	threshold int           // 标记为抖动的状态变化次数，0 表示不检测
+	window    time.Duration // 检测窗口
+	stable    time.Duration // 解除抖动需要的稳定时长
+	states    map[string]*alertState
+	lastSweep time.Time
+}
+
+// defaultDetector 全局的抖动检测器，阈值为 0 时不检测。
+var defaultDetector = New(0, 30*time.Minute, 30*time.Minute)
+
+// New 创建抖动检测器。
+func New(threshold int, window, stable time.Duration) *Detector {
+	return &Detector{
+		threshold: threshold,
+		window:    window,
+		stable:    stable,
+		states:    make(map[string]*alertState),
+	}
+}
+
+// Init 从环境变量加载抖动检测配置：
+// FLAP_THRESHOLD 为检测窗口内的状态变化次数阈值（未配置时不检测），
+// FLAP_WINDOW 为检测窗口（默认 30m），FLAP_STABLE_PERIOD 为解除抖动需要的稳定时长（默认 30m）。
+func Init() {
+	val := os.Getenv("FLAP_THRESHOLD")
+	if val == "" {
+		return
+	}
+
+	threshold, err := strconv.Atoi(val)
+	if err != nil || threshold < 2 {
+		log.Printf("⚠️ Invalid FLAP_THRESHOLD %q (must be >= 2), flapping detection disabled", val)
+		return
+	}
+
+	d := defaultDetector
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.threshold = threshold
+	d.window = durationEnv("FLAP_WINDOW", d.window)
+	d.stable = durationEnv("FLAP_STABLE_PERIOD", d.stable)
+
+	log.Printf("✅ Flapping detection enabled: threshold=%d, window=%v, stable period=%v",
+		d.threshold, d.window, d.stable)
+}
+
+// durationEnv 读取时长类型的环境变量，未配置或无效时返回默认值。
+func durationEnv(name string, def time.Duration) time.Duration {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Printf("⚠️ Invalid %s %q, using default %v", name, val, def)
+		return def
+	}
+	return d
+}
+
+// Observe 记录告警的状态变化，并返回告警当前的抖动状态。
+func (d *Detector) Observe(alert common.Alert) Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.threshold <= 0 || alert.Fingerprint == "" {
+		return Status{}
+	}
+
+	now := time.Now()
+	if now.Sub(d.lastSweep) >= d.window {
+		d.sweep(now)
+	}
+
+	s, ok := d.states[alert.Fingerprint]
+	if !ok {
+		s = &alertState{status: alert.Status}
+		d.states[alert.Fingerprint] = s
+	}
+	s.alert = alert
+	s.lastSeen = now
+
+	changed := s.status != alert.Status
+	if changed {
+		s.status = alert.Status
+		s.transitions = append(s.transitions, now)
+	}
+	s.transitions = d.recent(s.transitions, now)
+
+	if changed {
+		if !s.flapping && len(s.transitions) >= d.threshold {
+			s.flapping = true
+			s.noticed = make(map[string]bool)
+			s.muted = make(map[string]bool)
+			log.Printf("🔀 Alert %s is flapping: %d transitions in %v",
+				alert.Labels["alertname"], len(s.transitions), d.window)
+		}
+		// 抖动期间每次状态变化都重新开始计算稳定期
+		if s.flapping {
+			d.schedule(alert.Fingerprint, s)
+		}
+	}
+
+	return d.status(s)
+}
+
+// Allow 判断抖动中的告警是否需要发送到指定目标：每个目标只发送一次抖动提示，之后静默。
+// 未抖动的告警总是返回 true。
+func (d *Detector) Allow(alert common.Alert, channel, target string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, ok := d.states[alert.Fingerprint]
+	if !ok || !s.flapping {
+		return true
+	}
+
+	dest := channel + "/" + target
+	if !s.noticed[dest] {
+		s.noticed[dest] = true
+		return true
+	}
+	s.muted[dest] = true
+	return false
+}
+
+// Unmute 清除告警在指定目标上的静默记录，解除抖动时不再向该目标发送当前状态。
+// 事件类渠道在告警 resolved 时已直接关闭事件，不需要在稳定期结束后再次关闭。
+func (d *Detector) Unmute(alert common.Alert, channel, target string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if s, ok := d.states[alert.Fingerprint]; ok && s.flapping {
+		delete(s.muted, channel+"/"+target)
+	}
+}
+
+// recent 返回检测窗口内的状态变化时间。
+func (d *Detector) recent(transitions []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(transitions) && now.Sub(transitions[i]) > d.window {
+		i++
+	}
+	return transitions[i:]
+}
+
+// status 返回告警的抖动状态，调用方需持有锁。
+func (d *Detector) status(s *alertState) Status {
+	return Status{
+		Flapping:    s.flapping,
+		Transitions: len(s.transitions),
+		Window:      d.window,
+		Stable:      d.stable,
+	}
+}
+
+// schedule 重新设置稳定期定时器，调用方需持有锁。
+func (d *Detector) schedule(fingerprint string, s *alertState) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(d.stable, func() { d.settle(fingerprint) })
+}
+
+// settle 在告警稳定期结束后解除抖动，并向抖动期间被静默过的目标发送当前状态。
+// 事件类渠道（如 PagerDuty、Opsgenie）按告警的当前状态触发或关闭对应的事件。
+func (d *Detector) settle(fingerprint string) {
+	d.mu.Lock()
+	s, ok := d.states[fingerprint]
+	if !ok || !s.flapping {
+		d.mu.Unlock()
+		return
+	}
+	alert := s.alert
+	muted := s.muted
+	s.flapping = false
+	s.noticed, s.muted, s.timer = nil, nil, nil
+	s.transitions = nil
+	stable := d.stable
+	d.mu.Unlock()
+
+	alertName := alert.Labels["alertname"]
+	log.Printf("🔀 Alert %s stopped flapping after %v", alertName, stable)
+
+	// 在锁外发送通知，避免网络请求阻塞其他告警的状态更新
+	msg := buildMessage(alert, stable)
+	for dest := range muted {
+		channel, target, _ := strings.Cut(dest, "/")
+		err := notify.SendAlert(channel, target, alert, msg)
+		history.Notified(alert, channel, target, err)
+		if err != nil {
+			log.Printf("❌ Failed to send flapping recovery of alert %s to %s: %v", alertName, dest, err)
+		}
+	}
+}
+
+// sweep 清除长时间未再收到且未处于抖动状态的告警，调用方需持有锁。
+func (d *Detector) sweep(now time.Time) {
+	for fingerprint, s := range d.states {
+		if !s.flapping && now.Sub(s.lastSeen) > d.window {
+			delete(d.states, fingerprint)
+		}
+	}
+	d.lastSweep = now
+}
+
+// buildMessage 构建告警停止抖动的通知消息。
+func buildMessage(alert common.Alert, stable time.Duration) notify.Message {
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "🔀 告警 %s 已停止抖动（%v 内状态未变化）\n", alertName, stable)
+	fmt.Fprintf(&builder, "当前状态: %s\n", alert.Status)
+	if summary := alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&builder, "摘要: %s\n", summary)
+	}
+	fmt.Fprintf(&builder, "指纹: %s", alert.Fingerprint)
+
+	return notify.Message{
+		Title:    fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Status), alertName),
+		Text:     builder.String(),
+		Severity: alert.Labels["severity"],
+	}
+}
+
+// Observe 使用全局检测器记录告警的状态变化，并返回告警当前的抖动状态。
+func Observe(alert common.Alert) Status {
+	return defaultDetector.Observe(alert)
+}
+
+// Allow 使用全局检测器判断告警是否需要发送到指定目标。
+func Allow(alert common.Alert, channel, target string) bool {
+	return defaultDetector.Allow(alert, channel, target)
+}
+
+// Unmute 使用全局检测器清除告警在指定目标上的静默记录。
+func Unmute(alert common.Alert, channel, target string) {
+	defaultDetector.Unmute(alert, channel, target)
+}
@@ -0,0 +1,207 @@
+package flapping
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSettleReplaysAlert 解除抖动时，事件类渠道按告警的当前状态发送告警，其他渠道发送通用消息。
+func TestSettleReplaysAlert(t *testing.T) {
+	var mu sync.Mutex
+	var replayed []common.Alert
+	var messages []notify.Message
+	notify.RegisterAlert("incident", func(target string, alert common.Alert) error {
+		mu.Lock()
+		defer mu.Unlock()
+		replayed = append(replayed, alert)
+		return nil
+	})
+	notify.Register("chat", func(target string, msg notify.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, msg)
+		return nil
+	})
+
+	d := New(2, time.Minute, 50*time.Millisecond)
+	alert := common.Alert{Status: "firing", Labels: map[string]string{"alertname": "Flappy"}, Fingerprint: "fp"}
+	for i, status := range []string{"firing", "resolved", "firing", "resolved"} {
+		alert.Status = status
+		flap := d.Observe(alert)
+		if want := i >= 2; flap.Flapping != want {
+			t.Fatalf("observe %d: flapping = %v, want %v", i, flap.Flapping, want)
+		}
+		if flap.Flapping {
+			// 第一次抖动提示正常发送，之后静默
+			for _, channel := range []string{"incident", "chat"} {
+				if allowed, want := d.Allow(alert, channel, "ops"), i == 2; allowed != want {
+					t.Fatalf("observe %d: %s allowed = %v, want %v", i, channel, allowed, want)
+				}
+			}
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(replayed) > 0 && len(messages) > 0
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for flapping recovery")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(replayed) != 1 || replayed[0].Status != "resolved" || replayed[0].Fingerprint != "fp" {
+		t.Errorf("replayed = %+v, want the current resolved alert", replayed)
+	}
+	if len(messages) != 1 || messages[0].Title != "[RESOLVED] Flappy" {
+		t.Errorf("messages = %+v, want one recovery message", messages)
+	}
+}
+
+// observeAll 依次记录告警状态，返回最后一次的抖动状态。
+func observeAll(d *Detector, fingerprint string, statuses ...string) Status {
+	var flap Status
+	for _, status := range statuses {
+		flap = d.Observe(common.Alert{Status: status, Labels: map[string]string{"alertname": "Flappy"}, Fingerprint: fingerprint})
+	}
+	return flap
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		name        string
+		threshold   int
+		statuses    []string
+		flapping    bool
+		transitions int
+	}{
+		{"below threshold", 3, []string{"firing", "resolved", "firing"}, false, 2},
+		{"at threshold", 3, []string{"firing", "resolved", "firing", "resolved"}, true, 3},
+		{"repeated status not counted", 3, []string{"firing", "firing", "resolved", "resolved", "firing", "firing"}, false, 2},
+		{"disabled", 0, []string{"firing", "resolved", "firing", "resolved"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New(tt.threshold, time.Minute, time.Minute)
+			flap := observeAll(d, "fp", tt.statuses...)
+			if flap.Flapping != tt.flapping || flap.Transitions != tt.transitions {
+				t.Errorf("status = %#v, want flapping=%v transitions=%d", flap, tt.flapping, tt.transitions)
+			}
+		})
+	}
+
+	d := New(2, time.Minute, time.Minute)
+	if flap := observeAll(d, "", "firing", "resolved", "firing"); flap.Flapping {
+		t.Error("alerts without fingerprint should not be tracked")
+	}
+	if flap := observeAll(d, "other", "firing"); flap.Flapping {
+		t.Error("alerts are tracked by fingerprint, another alert should not be flapping")
+	}
+}
+
+func TestWindow(t *testing.T) {
+	d := New(3, 200*time.Millisecond, time.Minute)
+
+	observeAll(d, "fp", "firing", "resolved")
+	time.Sleep(130 * time.Millisecond)
+	observeAll(d, "fp", "firing")
+	time.Sleep(130 * time.Millisecond)
+
+	// 第一次状态变化已超出检测窗口，不再计数
+	if flap := observeAll(d, "fp", "resolved"); flap.Flapping || flap.Transitions != 2 {
+		t.Errorf("status = %#v, want transitions outside the window dropped", flap)
+	}
+	if flap := observeAll(d, "fp", "firing"); !flap.Flapping || flap.Transitions != 3 {
+		t.Errorf("status = %#v, want flapping within the window", flap)
+	}
+}
+
+func TestStablePeriod(t *testing.T) {
+	d := New(2, time.Minute, 200*time.Millisecond)
+	alert := common.Alert{Status: "firing", Labels: map[string]string{"alertname": "Flappy"}, Fingerprint: "fp"}
+
+	if flap := observeAll(d, "fp", "firing", "resolved", "firing"); !flap.Flapping {
+		t.Fatalf("status = %#v, want flapping", flap)
+	}
+
+	// 每个目标只收到一次抖动提示
+	for _, target := range []string{"ops", "dba"} {
+		if !d.Allow(alert, "test", target) {
+			t.Errorf("first notice to %s should be allowed", target)
+		}
+		if d.Allow(alert, "test", target) {
+			t.Errorf("second notification to %s should be muted", target)
+		}
+	}
+
+	// 稳定期内的状态变化重新开始计算稳定期
+	time.Sleep(120 * time.Millisecond)
+	observeAll(d, "fp", "resolved")
+	time.Sleep(120 * time.Millisecond)
+	if d.Allow(alert, "test", "ops") {
+		t.Error("alert should still be muted, the stable period restarts on every transition")
+	}
+
+	// 稳定期结束后解除抖动，通知恢复正常
+	time.Sleep(250 * time.Millisecond)
+	if !d.Allow(alert, "test", "ops") || !d.Allow(alert, "test", "ops") {
+		t.Error("alert should not be muted after the stable period")
+	}
+	if flap := observeAll(d, "fp", "resolved"); flap.Flapping || flap.Transitions != 0 {
+		t.Errorf("status = %#v, want transitions reset after settling", flap)
+	}
+}
+
+func TestInit(t *testing.T) {
+	d := defaultDetector
+	threshold, window, stable := d.threshold, d.window, d.stable
+	t.Cleanup(func() { d.threshold, d.window, d.stable = threshold, window, stable })
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		threshold int
+		window    time.Duration
+		stable    time.Duration
+	}{
+		{"not configured", nil, 0, 30 * time.Minute, 30 * time.Minute},
+		{"defaults", map[string]string{"FLAP_THRESHOLD": "4"}, 4, 30 * time.Minute, 30 * time.Minute},
+		{"configured", map[string]string{"FLAP_THRESHOLD": "3", "FLAP_WINDOW": "10m", "FLAP_STABLE_PERIOD": "1h"}, 3, 10 * time.Minute, time.Hour},
+		{"invalid durations", map[string]string{"FLAP_THRESHOLD": "3", "FLAP_WINDOW": "-1m", "FLAP_STABLE_PERIOD": "soon"}, 3, 30 * time.Minute, 30 * time.Minute},
+		{"threshold too low", map[string]string{"FLAP_THRESHOLD": "1"}, 0, 30 * time.Minute, 30 * time.Minute},
+		{"invalid threshold", map[string]string{"FLAP_THRESHOLD": "many"}, 0, 30 * time.Minute, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.threshold, d.window, d.stable = 0, 30*time.Minute, 30*time.Minute
+			for _, key := range []string{"FLAP_THRESHOLD", "FLAP_WINDOW", "FLAP_STABLE_PERIOD"} {
+				t.Setenv(key, tt.env[key])
+			}
+			Init()
+
+			if d.threshold != tt.threshold || d.window != tt.window || d.stable != tt.stable {
+				t.Errorf("config = %d/%v/%v, want %d/%v/%v", d.threshold, d.window, d.stable, tt.threshold, tt.window, tt.stable)
+			}
+		})
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	if s := (Status{}).String(); s != "" {
+		t.Errorf("String() = %q, want empty when not flapping", s)
+	}
+	s := Status{Flapping: true, Transitions: 4, Window: 30 * time.Minute, Stable: 10 * time.Minute}
+	if got, want := s.String(), "告警抖动：30m0s 内状态变化 4 次，状态稳定 10m0s 后恢复通知"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultLimit 历史查询接口默认返回的最大记录数。
+const defaultLimit = 100
+
+// Handler 处理 /api/history 请求。
+// 指定 fingerprint 参数时返回该告警的所有触发记录；
+// 否则按 status、alertname、label（k=v，多个用逗号分隔）、since、until 和 limit 参数查询。
+// since、until 可以是 RFC3339 时间或相对当前的时长（如 24h）。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if defaultStore == nil {
+		http.Error(w, "alert history not configured", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	var records []*Record
+	var err error
+
+	if fingerprint := params.Get("fingerprint"); fingerprint != "" {
+		records, err = defaultStore.Get(fingerprint)
+	} else {
+		q := Query{
+			Status:    params.Get("status"),
+			AlertName: params.Get("alertname"),
+			Limit:     defaultLimit,
+		}
+		if label := params.Get("label"); label != "" {
+			q.Labels = make(map[string]string)
+			for _, pair := range strings.Split(label, ",") {
+				k, v, ok := strings.Cut(pair, "=")
+				if !ok {
+					http.Error(w, "invalid label "+pair, http.StatusBadRequest)
+					return
+				}
+				q.Labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		if q.Since, err = ParseTime(params.Get("since")); err != nil {
+			http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if q.Until, err = ParseTime(params.Get("until")); err != nil {
+			http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if limit := params.Get("limit"); limit != "" {
+			if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		records, err = defaultStore.List(q)
+	}
+
+	if err != nil {
+		log.Printf("❌ Failed to query alert history: %v", err)
+		http.Error(w, "failed to query alert history", http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []*Record{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// ParseTime 解析 RFC3339 时间，或相对当前时间之前的时长（如 24h）。空字符串返回零值。
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
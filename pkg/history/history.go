@@ -0,0 +1,464 @@
+// Package history 使用内嵌的 bbolt 数据库记录告警的生命周期：
+// 首次到达时间、状态变化、恢复时间以及发送过的通知和目标，
+// 作为去重、抖动检测、报表和历史查询接口的基础。
+package history
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// alertsBucket 保存告警记录的 bucket，key 为 "<fingerprint>/<startsAt>"。
+var alertsBucket = []byte("alerts")
+
+// maxNotifications 单条记录最多保留的通知数量，超出时丢弃最早的记录。
+const maxNotifications = 100
+
+// Transition 告警的一次状态变化。
+type Transition struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// Notification 一次通知发送记录。
+type Notification struct {
+	Channel string    `json:"channel"` // 通知渠道，如 feishu、syslog
+	Target  string    `json:"target"`  // 渠道中的目标名称
+	At      time.Time `json:"at"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Record 告警的一次触发（从 firing 到 resolved）的记录。
+// Alertmanager 对同一告警的多次触发使用相同的指纹和不同的 startsAt，
+// 因此记录以指纹和 startsAt 共同标识。
+type Record struct {
+	Fingerprint   string            `json:"fingerprint"`
+	AlertName     string            `json:"alertname"`
+	Severity      string            `json:"severity,omitempty"`
+	Labels        map[string]string `json:"labels"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+	Status        string            `json:"status"`
+	StartsAt      time.Time         `json:"starts_at"`
+	FirstSeen     time.Time         `json:"first_seen"`            // adapter 首次收到该告警的时间
+	LastSeen      time.Time         `json:"last_seen"`             // adapter 最近一次收到该告警的时间
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty"` // 告警恢复时间
+	Received      int               `json:"received"`              // 收到该告警 webhook 的次数，同一通知发往多个 receiver 时只计一次
+	Transitions   []Transition      `json:"transitions"`
+	Notifications []Notification    `json:"notifications,omitempty"`
+	Targets       []string          `json:"targets,omitempty"` // 成功送达过的目标，格式为 channel/target
+}
+
+// Duration 返回告警的持续时间，未恢复的告警返回到当前为止的时长。
+func (r *Record) Duration() time.Duration {
+	start := r.StartsAt
+	if start.IsZero() {
+		start = r.FirstSeen
+	}
+	if r.ResolvedAt != nil {
+		return r.ResolvedAt.Sub(start)
+	}
+	return time.Since(start)
+}
+
+// receiveWindow 同一告警以相同状态到达的时间窗口，窗口内的多次到达视为 Alertmanager 的同一次通知
+// 发往不同的 receiver（如同时路由到 /feishu 和 /kafka），只计一次。
+const receiveWindow = 30 * time.Second
+
+// Store 告警历史存储。
+type Store struct {
+	db *bolt.DB
+
+	// counted 告警最近一次计入 Received 的时间，key 为 "<记录 key>/<状态>"，只在 db.Update 中访问。
+	counted   map[string]time.Time
+	lastSweep time.Time
+}
+
+// Open 打开（不存在时创建）告警历史数据库。
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(alertsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history bucket: %w", err)
+	}
+	return &Store{db: db, counted: make(map[string]time.Time)}, nil
+}
+
+// Close 关闭数据库。
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// recordKey 返回告警记录的 key，startsAt 使用定长的十六进制编码以保证同一指纹的记录按时间排序。
+func recordKey(fingerprint string, startsAt time.Time) []byte {
+	return []byte(fmt.Sprintf("%s/%016x", fingerprint, startsAt.UnixNano()))
+}
+
+// Observe 记录收到的告警，并返回更新后的记录。
+// 首次收到时创建记录，状态变化时追加状态变化记录。
+func (s *Store) Observe(alert common.Alert) (*Record, error) {
+	now := time.Now()
+	var record *Record
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(alertsBucket)
+		key, existing, err := find(b, alert)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			existing = &Record{
+				Fingerprint: alert.Fingerprint,
+				StartsAt:    alert.StartsAt,
+				FirstSeen:   now,
+			}
+			start := alert.StartsAt
+			if start.IsZero() {
+				start = now
+			}
+			key = recordKey(alert.Fingerprint, start)
+		}
+
+		record = existing
+		record.AlertName = alert.Labels["alertname"]
+		record.Severity = alert.Labels["severity"]
+		record.Labels = alert.Labels
+		record.Annotations = alert.Annotations
+		record.LastSeen = now
+		if s.countReceive(string(key)+"/"+alert.Status, now) {
+			record.Received++
+		}
+
+		if record.Status != alert.Status {
+			record.Status = alert.Status
+			record.Transitions = append(record.Transitions, Transition{Status: alert.Status, At: now})
+		}
+		switch {
+		case alert.Status == "resolved" && record.ResolvedAt == nil:
+			resolved := alert.EndsAt
+			if resolved.IsZero() {
+				resolved = now
+			}
+			record.ResolvedAt = &resolved
+		case alert.Status == "firing":
+			record.ResolvedAt = nil
+		}
+
+		return put(b, key, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// countReceive 判断告警的这次到达是否计入 Received：同一告警以相同状态在 receiveWindow 内
+// 再次到达时不计入。调用方需在 db.Update 中调用。
+func (s *Store) countReceive(key string, now time.Time) bool {
+	if now.Sub(s.lastSweep) >= receiveWindow {
+		for k, at := range s.counted {
+			if now.Sub(at) >= receiveWindow {
+				delete(s.counted, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if at, ok := s.counted[key]; ok && now.Sub(at) < receiveWindow {
+		return false
+	}
+	s.counted[key] = now
+	return true
+}
+
+// Notified 记录告警的一次通知发送结果。
+func (s *Store) Notified(alert common.Alert, channel, target string, sendErr error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(alertsBucket)
+		key, record, err := find(b, alert)
+		if err != nil || record == nil {
+			return err
+		}
+
+		n := Notification{Channel: channel, Target: target, At: time.Now(), Success: sendErr == nil}
+		if sendErr != nil {
+			n.Error = sendErr.Error()
+		}
+		record.Notifications = append(record.Notifications, n)
+		if len(record.Notifications) > maxNotifications {
+			record.Notifications = record.Notifications[len(record.Notifications)-maxNotifications:]
+		}
+
+		if sendErr == nil {
+			dest := channel + "/" + target
+			if !contains(record.Targets, dest) {
+				record.Targets = append(record.Targets, dest)
+			}
+		}
+		return put(b, key, record)
+	})
+}
+
+// find 查找告警对应的记录：优先按指纹和 startsAt 精确匹配，
+// startsAt 为空时使用该指纹最近一条记录。未找到时返回 nil。
+func find(b *bolt.Bucket, alert common.Alert) ([]byte, *Record, error) {
+	if !alert.StartsAt.IsZero() {
+		key := recordKey(alert.Fingerprint, alert.StartsAt)
+		record, err := decode(b.Get(key))
+		return key, record, err
+	}
+
+	prefix := []byte(alert.Fingerprint + "/")
+	var lastKey, lastValue []byte
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, v = c.Next() {
+		lastKey, lastValue = k, v
+	}
+	if lastKey == nil {
+		return nil, nil, nil
+	}
+	record, err := decode(lastValue)
+	return append([]byte(nil), lastKey...), record, err
+}
+
+// decode 解析记录，value 为空时返回 nil。
+func decode(value []byte) (*Record, error) {
+	if value == nil {
+		return nil, nil
+	}
+	var record Record
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode history record: %w", err)
+	}
+	return &record, nil
+}
+
+// put 保存记录。
+func put(b *bolt.Bucket, key []byte, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+	return b.Put(key, data)
+}
+
+// contains 判断字符串切片是否包含指定值。
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Get 返回指纹对应的所有记录，按触发时间从新到旧排序。
+func (s *Store) Get(fingerprint string) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(fingerprint + "/")
+		c := tx.Bucket(alertsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, v = c.Next() {
+			record, err := decode(v)
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// key 按 startsAt 升序排列，反转为从新到旧
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// Query 历史记录查询条件，零值字段表示不过滤。
+type Query struct {
+	Status    string            // firing 或 resolved
+	AlertName string            // 告警名称
+	Labels    map[string]string // 需要完全匹配的标签
+	Since     time.Time         // 最近一次收到时间不早于该时间
+	Until     time.Time         // 首次收到时间早于该时间
+	Limit     int               // 最多返回的记录数
+}
+
+// match 判断记录是否满足查询条件。
+func (q Query) match(r *Record) bool {
+	if q.Status != "" && r.Status != q.Status {
+		return false
+	}
+	if q.AlertName != "" && r.AlertName != q.AlertName {
+		return false
+	}
+	for k, v := range q.Labels {
+		if r.Labels[k] != v {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && r.LastSeen.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.FirstSeen.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// List 返回满足条件的记录，按首次收到时间从新到旧排序。
+func (s *Store) List(q Query) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(alertsBucket).ForEach(func(_, v []byte) error {
+			record, err := decode(v)
+			if err != nil {
+				return err
+			}
+			if q.match(record) {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].FirstSeen.After(records[j].FirstSeen)
+	})
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[:q.Limit]
+	}
+	return records, nil
+}
+
+// Prune 删除最近一次收到时间早于 before 的记录，返回删除的数量。
+func (s *Store) Prune(before time.Time) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(alertsBucket)
+
+		// 先收集需要删除的 key，遍历过程中删除会导致游标跳过记录
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			record, err := decode(v)
+			if err != nil {
+				return err
+			}
+			if record.LastSeen.Before(before) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	return removed, err
+}
+
+// defaultStore 全局的告警历史存储，未配置 HISTORY_DB_PATH 时为 nil。
+var defaultStore *Store
+
+// Init 打开 HISTORY_DB_PATH 指定的数据库，并定期清理超过 HISTORY_RETENTION 的记录。
+func Init() {
+	path := os.Getenv("HISTORY_DB_PATH")
+	if path == "" {
+		return
+	}
+
+	retention := 7 * 24 * time.Hour
+	if val := os.Getenv("HISTORY_RETENTION"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			retention = d
+		} else {
+			log.Printf("⚠️ Invalid HISTORY_RETENTION %q, using default %v", val, retention)
+		}
+	}
+
+	store, err := Open(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to open HISTORY_DB_PATH %s, history disabled: %v", path, err)
+		return
+	}
+	defaultStore = store
+
+	go func() {
+		for {
+			if removed, err := store.Prune(time.Now().Add(-retention)); err != nil {
+				log.Printf("⚠️ Failed to prune alert history: %v", err)
+			} else if removed > 0 {
+				log.Printf("🧹 Pruned %d alert history records older than %v", removed, retention)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	log.Printf("✅ Alert history enabled: path=%s, retention=%v", path, retention)
+}
+
+// Default 返回全局的告警历史存储，未启用时返回 nil。
+func Default() *Store {
+	return defaultStore
+}
+
+// Close 关闭全局的告警历史存储，退出前调用以释放数据库文件锁，未启用时不做任何操作。
+func Close() {
+	if defaultStore == nil {
+		return
+	}
+	if err := defaultStore.Close(); err != nil {
+		log.Printf("⚠️ Failed to close alert history: %v", err)
+	}
+}
+
+// Observe 在全局存储中记录收到的告警，未启用历史记录时不做任何操作。
+func Observe(alert common.Alert) {
+	if defaultStore == nil || alert.Fingerprint == "" {
+		return
+	}
+	if _, err := defaultStore.Observe(alert); err != nil {
+		log.Printf("⚠️ Failed to record history of alert %s: %v", alert.Labels["alertname"], err)
+	}
+}
+
+// Notified 在全局存储中记录告警的通知发送结果，未启用历史记录时不做任何操作。
+func Notified(alert common.Alert, channel, target string, err error) {
+	if defaultStore == nil || alert.Fingerprint == "" {
+		return
+	}
+	if err := defaultStore.Notified(alert, channel, target, err); err != nil {
+		log.Printf("⚠️ Failed to record notification of alert %s: %v", alert.Labels["alertname"], err)
+	}
+}
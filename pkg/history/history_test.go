@@ -0,0 +1,60 @@
+package history
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestObserveCountsOncePerDelivery(t *testing.T) {
+	store := openTestStore(t)
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "HighCPU"},
+		Fingerprint: "fp",
+		StartsAt:    time.Now().Add(-time.Minute),
+	}
+
+	// 同一通知发往两个 receiver
+	for i := 0; i < 2; i++ {
+		if _, err := store.Observe(alert); err != nil {
+			t.Fatalf("Observe: %v", err)
+		}
+	}
+	record, _ := store.Observe(alert)
+	if record.Received != 1 {
+		t.Errorf("Received = %d after one delivery to several receivers, want 1", record.Received)
+	}
+
+	// Alertmanager 在 repeat_interval 后的重复通知
+	for k := range store.counted {
+		store.counted[k] = time.Now().Add(-receiveWindow)
+	}
+	record, _ = store.Observe(alert)
+	if record.Received != 2 {
+		t.Errorf("Received = %d after repeated notification, want 2", record.Received)
+	}
+
+	// 恢复通知是一次新的通知
+	alert.Status = "resolved"
+	alert.EndsAt = time.Now()
+	store.Observe(alert)
+	record, _ = store.Observe(alert)
+	if record.Received != 3 {
+		t.Errorf("Received = %d after resolve, want 3", record.Received)
+	}
+	if len(record.Transitions) != 2 || record.ResolvedAt == nil {
+		t.Errorf("transitions = %v, resolvedAt = %v", record.Transitions, record.ResolvedAt)
+	}
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将每个告警作为一条 JSON 记录写入指定目标的 topic。
+// 如果请求中包含 target 参数，则只写入指定的目标；
+// 如果没有指定，则默认写入所有已配置的 Kafka 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只写入指定目标，否则写入所有配置的 Kafka 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.KafkaTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid kafka targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警，每个告警都写入，不经过静默规则、抖动和去重筛选
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		event := common.NewAlertEvent(payload, alert, flap.Flapping)
+
+		// 写入所有目标。客户端在 KAFKA_TIMEOUT 内已自动重试可恢复的错误，不再按发送重试策略重试，
+		// 以免同一记录被重复写入，也避免 webhook 请求等待多个超时时间
+		for name, topicTemplate := range targets {
+			record, err := newRecord(name, topicTemplate, event)
+			if err == nil {
+				err = produce(name, record)
+			}
+			delivery.Record(alert, "kafka", name, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// newRecord 为告警事件创建 Kafka 记录：topic 由目标的模板渲染，key 为告警指纹，value 为 JSON 格式的事件。
+func newRecord(name, topicTemplate string, event common.AlertEvent) (*kgo.Record, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode kafka event: %w", err)
+	}
+	t, err := topic(name, topicTemplate, event)
+	if err != nil {
+		return nil, err
+	}
+	return &kgo.Record{Topic: t, Key: []byte(event.Fingerprint), Value: value}, nil
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testEvent() common.AlertEvent {
+	return common.NewAlertEvent(
+		common.WebhookMessage{Receiver: "kafka", Status: "firing", GroupKey: "{}:{alertname=\"HighCPU\"}"},
+		common.Alert{
+			Status:      "firing",
+			Fingerprint: "3f2a1b4c5d6e7f80",
+			Labels:      map[string]string{"alertname": "HighCPU", "team": "DB Ops", "severity": "critical"},
+		},
+		true,
+	)
+}
+
+func TestTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"static", "alerts", "alerts", false},
+		{"label", "alerts-{{ .Labels.severity }}", "alerts-critical", false},
+		{"invalid characters replaced", "alerts.{{ .Labels.team }}", "alerts.DB_Ops", false},
+		{"functions", `alerts-{{ replace (lower .Labels.team) " " "-" }}`, "alerts-db-ops", false},
+		{"missing label with default", `alerts-{{ default "none" .Labels.cluster }}`, "alerts-none", false},
+		{"missing label renders empty", "alerts-{{ .Labels.cluster }}", "alerts-", false},
+		{"group fields", "{{ .Receiver }}.{{ .Status }}", "kafka.firing", false},
+		{"empty", "{{ .Labels.cluster }}", "", true},
+		{"dot", ".", "", true},
+		{"too long", strings.Repeat("a", topicMaxLength+1), "", true},
+		{"parse error", "alerts-{{ .Labels.team", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 模板按目标名称缓存，每个用例使用不同的目标
+			got, err := topic("topic-"+tt.name, tt.template, testEvent())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("topic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("topic() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewRecord 记录的 key 为告警指纹，value 为告警事件的 JSON。
+func TestNewRecord(t *testing.T) {
+	record, err := newRecord("record", "alerts-{{ .Labels.severity }}", testEvent())
+	if err != nil {
+		t.Fatalf("newRecord: %v", err)
+	}
+	if record.Topic != "alerts-critical" {
+		t.Errorf("topic = %q, want alerts-critical", record.Topic)
+	}
+	if string(record.Key) != "3f2a1b4c5d6e7f80" {
+		t.Errorf("key = %q, want the alert fingerprint", record.Key)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(record.Value, &body); err != nil {
+		t.Fatalf("value is not JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"status":      "firing",
+		"fingerprint": "3f2a1b4c5d6e7f80",
+		"receiver":    "kafka",
+		"groupStatus": "firing",
+		"groupKey":    "{}:{alertname=\"HighCPU\"}",
+		"flapping":    true,
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("value[%s] = %v, want %v", k, body[k], v)
+		}
+	}
+	if labels, _ := body["labels"].(map[string]interface{}); labels["alertname"] != "HighCPU" {
+		t.Errorf("value labels = %v", body["labels"])
+	}
+	if _, ok := body["timestamp"]; !ok {
+		t.Error("value missing timestamp")
+	}
+}
@@ -0,0 +1,114 @@
+// Package kafka 提供将告警作为事件写入 Kafka topic 的功能，供数据平台做告警分析。
+// 每个告警写入一条 JSON 记录，以告警指纹作为记录的 key，同一告警的事件写入同一分区。
+package kafka
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// client Kafka 生产者客户端，未配置 Kafka 目标或创建失败时为 nil。
+var client *kgo.Client
+
+// Init 按 Kafka 配置创建生产者客户端。客户端在第一次发送时才连接 broker。
+func Init() {
+	if len(common.KafkaTargets) == 0 || len(common.KafkaConfig.Brokers) == 0 {
+		return
+	}
+
+	opts, err := options()
+	if err != nil {
+		log.Printf("❌ Invalid kafka config, kafka disabled: %v", err)
+		return
+	}
+	c, err := kgo.NewClient(opts...)
+	if err != nil {
+		log.Printf("❌ Failed to create kafka client, kafka disabled: %v", err)
+		return
+	}
+	client = c
+}
+
+// options 返回 Kafka 客户端的选项：broker、确认方式、压缩、幂等写入、超时以及 TLS 和 SASL 认证。
+func options() ([]kgo.Opt, error) {
+	cfg := common.KafkaConfig
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ClientID(cfg.ClientID),
+		kgo.RecordDeliveryTimeout(cfg.Timeout),
+	}
+
+	switch cfg.Acks {
+	case common.KafkaAcksLeader:
+		opts = append(opts, kgo.RequiredAcks(kgo.LeaderAck()))
+	case common.KafkaAcksNone:
+		opts = append(opts, kgo.RequiredAcks(kgo.NoAck()))
+	default:
+		opts = append(opts, kgo.RequiredAcks(kgo.AllISRAcks()))
+	}
+	if !cfg.Idempotent {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+
+	switch cfg.Compression {
+	case "gzip":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.GzipCompression()))
+	case "snappy":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.SnappyCompression()))
+	case "lz4":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.Lz4Compression()))
+	case "zstd":
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.ZstdCompression()))
+	default:
+		opts = append(opts, kgo.ProducerBatchCompression(kgo.NoCompression()))
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			return nil, fmt.Errorf("kafka TLS: %w", err)
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	if cfg.SASLMechanism != "" {
+		opts = append(opts, kgo.SASL(mechanism()))
+	}
+	return opts, nil
+}
+
+// mechanism 返回配置的 SASL 认证方式。
+func mechanism() sasl.Mechanism {
+	cfg := common.KafkaConfig
+	switch cfg.SASLMechanism {
+	case "scram-sha-256":
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsSha256Mechanism()
+	case "scram-sha-512":
+		return scram.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsSha512Mechanism()
+	default:
+		return plain.Auth{User: cfg.SASLUsername, Pass: cfg.SASLPassword}.AsMechanism()
+	}
+}
+
+// produce 同步写入一条记录，等待 broker 按确认方式确认。
+// 客户端在超时时间内会自动重试可恢复的错误（如 leader 切换），调用方不需要再重试。
+func produce(name string, record *kgo.Record) error {
+	if client == nil {
+		return fmt.Errorf("kafka target '%s': kafka client is not initialized", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.KafkaConfig.Timeout)
+	defer cancel()
+
+	if err := client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("failed to produce to kafka topic %s for %s: %w", record.Topic, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// topicMaxLength Kafka topic 名称的最大长度。
+const topicMaxLength = 249
+
+// funcs topic 模板可以使用的函数。
+var funcs = template.FuncMap{
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"replace": strings.ReplaceAll,
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+// templates 已解析的 topic 模板，key 为目标名称。
+var templates sync.Map
+
+// topicTemplate 返回目标的 topic 模板，引用不存在的标签时渲染为空字符串。
+func topicTemplate(name, text string) (*template.Template, error) {
+	if tmpl, ok := templates.Load(name); ok {
+		return tmpl.(*template.Template), nil
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse topic template of kafka target '%s': %w", name, err)
+	}
+	templates.Store(name, tmpl)
+	return tmpl, nil
+}
+
+// topic 渲染目标的 topic 名称，topic 中不允许的字符替换为下划线。
+func topic(name, text string, event common.AlertEvent) (string, error) {
+	tmpl, err := topicTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("failed to render topic template of kafka target '%s': %w", name, err)
+	}
+	result := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(buf.String()))
+
+	if result == "" || result == "." || result == ".." {
+		return "", fmt.Errorf("kafka target '%s' rendered an invalid topic %q", name, result)
+	}
+	if len(result) > topicMaxLength {
+		return "", fmt.Errorf("kafka target '%s' rendered a topic longer than %d characters", name, topicMaxLength)
+	}
+	return result, nil
+}
@@ -54,13 +54,114 @@ func (c *Client) QueryLogs(query string, limit int, rangeMinutes int) ([]string,
 	params.Add("end", strconv.FormatInt(end, 10))
 	params.Add("direction", "backward") // 从最新的日志开始
 
+	var queryResp QueryRangeResponse
+	if err := c.queryRange(params, &queryResp); err != nil {
+		return nil, err
+	}
+
+	// 提取日志行
+	var logs []string
+	for _, result := range queryResp.Data.Result {
+		for _, value := range result.Values {
+			if len(value) >= 2 {
+				// value[0] 是时间戳，value[1] 是日志内容
+				logLine := value[1]
+				logs = append(logs, logLine)
+
+				// 限制返回的日志条数
+				if len(logs) >= limit {
+					return logs, nil
+				}
+			}
+		}
+	}
+
+	return logs, nil
+}
+
+// MetricSeries 指标查询返回的一条时间序列。
+type MetricSeries struct {
+	Metric map[string]string
+	Points []MetricPoint
+}
+
+// MetricPoint 指标序列中的单个数据点。
+type MetricPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// metricRangeResponse Loki 指标查询（matrix 结果）的响应结构。
+type metricRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"` // [timestamp, "value"]
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryMetric 执行 LogQL 指标查询（如 count_over_time），返回时间序列。
+// query: LogQL 指标查询语句
+// start, end: 查询时间范围
+// step: 查询步长
+func (c *Client) QueryMetric(query string, start, end time.Time, step time.Duration) ([]MetricSeries, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("Loki URL not configured")
+	}
+
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Add("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Add("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	var queryResp metricRangeResponse
+	if err := c.queryRange(params, &queryResp); err != nil {
+		return nil, err
+	}
+	if queryResp.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("query is not a metric query: result type %q", queryResp.Data.ResultType)
+	}
+
+	series := make([]MetricSeries, 0, len(queryResp.Data.Result))
+	for _, result := range queryResp.Data.Result {
+		s := MetricSeries{Metric: result.Metric}
+		for _, value := range result.Values {
+			if len(value) < 2 {
+				continue
+			}
+			ts, ok := value[0].(float64)
+			if !ok {
+				continue
+			}
+			str, ok := value[1].(string)
+			if !ok {
+				continue
+			}
+			val, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				continue
+			}
+			s.Points = append(s.Points, MetricPoint{Time: time.Unix(0, int64(ts*1e9)), Value: val})
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+// queryRange 调用 Loki query_range 接口，并将响应解析到 out。
+func (c *Client) queryRange(params url.Values, out interface{}) error {
 	// 构建完整 URL
 	apiURL := fmt.Sprintf("%s/loki/api/v1/query_range?%s", c.URL, params.Encode())
 
 	// 创建 HTTP 请求
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// 添加 Basic Auth（如果配置了）
@@ -76,7 +177,7 @@ func (c *Client) QueryLogs(query string, limit int, rangeMinutes int) ([]string,
 	// 发送请求
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query Loki: %w", err)
+		return fmt.Errorf("failed to query Loki: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
@@ -87,33 +188,15 @@ func (c *Client) QueryLogs(query string, limit int, rangeMinutes int) ([]string,
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Loki API returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("Loki API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// 解析响应
-	var queryResp QueryRangeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&queryResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// 提取日志行
-	var logs []string
-	for _, result := range queryResp.Data.Result {
-		for _, value := range result.Values {
-			if len(value) >= 2 {
-				// value[0] 是时间戳，value[1] 是日志内容
-				logLine := value[1]
-				logs = append(logs, logLine)
-
-				// 限制返回的日志条数
-				if len(logs) >= limit {
-					return logs, nil
-				}
-			}
-		}
-	}
-
-	return logs, nil
+	return nil
 }
 
 // FormatLogs 格式化日志列表为易读的文本。
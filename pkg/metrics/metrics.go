@@ -0,0 +1,118 @@
+// Package metrics 提供简单的计数器，并以 Prometheus 文本格式通过 /metrics 接口暴露。
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CounterVec 带标签的计数器。
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // key 为按顺序拼接的标签值
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []*CounterVec
+)
+
+// NewCounterVec 创建并注册带标签的计数器。
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+	return c
+}
+
+// Inc 将指定标签值的计数加 1，标签值的顺序与创建时的标签名一致。
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 将指定标签值的计数增加 delta。
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[strings.Join(values, "\xff")] += delta
+}
+
+// write 以 Prometheus 文本格式输出计数器。
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(b, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", c.name)
+	for _, k := range keys {
+		b.WriteString(c.name)
+		if len(c.labels) > 0 {
+			values := strings.Split(k, "\xff")
+			pairs := make([]string, len(c.labels))
+			for i, label := range c.labels {
+				pairs[i] = fmt.Sprintf("%s=%q", label, values[i])
+			}
+			fmt.Fprintf(b, "{%s}", strings.Join(pairs, ","))
+		}
+		fmt.Fprintf(b, " %g\n", c.values[k])
+	}
+	c.mu.Unlock()
+}
+
+// Handler 处理 /metrics 请求，输出所有已注册的计数器。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	registryMu.Lock()
+	counters := append([]*CounterVec(nil), registry...)
+	registryMu.Unlock()
+
+	var b strings.Builder
+	for _, c := range counters {
+		c.write(&b)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// 适配器通用的通知计数器。
+var (
+	// Notifications 发送的告警通知数量，result 为 success 或 failure。
+	Notifications = NewCounterVec("alert_adapter_notifications_total",
+		"Number of alert notifications sent, by channel, target and result.",
+		"channel", "target", "result")
+
+	// Suppressed 被抑制（未发送）的告警通知数量，reason 为抑制原因，如 duplicate。
+	Suppressed = NewCounterVec("alert_adapter_notifications_suppressed_total",
+		"Number of alert notifications suppressed, by channel, target and reason.",
+		"channel", "target", "reason")
+)
+
+// Result 根据发送错误返回通知计数器的 result 标签值。
+func Result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
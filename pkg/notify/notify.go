@@ -0,0 +1,170 @@
+// Package notify 提供按渠道名称发送通知的注册表，
+// 供升级、摘要等后台任务在不依赖具体渠道实现的情况下发送消息；
+// 同时提供各渠道共用的发送失败重试策略。
+package notify
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Message 通用的通知消息。
+type Message struct {
+	Title    string   // 标题
+	Text     string   // 正文
+	Severity string   // 严重级别，如 critical、emerg（syslog 映射为对应的严重级别）
+	Mentions []string // 需要 @ 的用户 open_id（仅支持 @ 的渠道使用）
+}
+
+// Target 渠道中的通知目标。
+type Target struct {
+	Channel string `json:"channel"` // 通知渠道，如 feishu、syslog
+	Target  string `json:"target"`  // 渠道中的目标名称
+}
+
+// String 返回 channel/target 格式的目标名称。
+func (t Target) String() string {
+	return t.Channel + "/" + t.Target
+}
+
+// SendFunc 发送消息到渠道中的指定目标。
+type SendFunc func(target string, msg Message) error
+
+// AlertFunc 按渠道自身的格式发送告警到渠道中的指定目标。
+type AlertFunc func(target string, alert common.Alert) error
+
+var (
+	mu       sync.RWMutex
+	channels = make(map[string]SendFunc)
+	alerts   = make(map[string]AlertFunc)
+)
+
+// Register 注册渠道的发送函数，重复注册会覆盖之前的函数。
+func Register(channel string, fn SendFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	channels[channel] = fn
+}
+
+// Send 通过指定渠道发送消息，发送失败时按重试策略重试。
+func Send(channel, target string, msg Message) error {
+	mu.RLock()
+	fn, ok := channels[channel]
+	mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("notify channel %q not registered", channel)
+	}
+	return Retry(func() error { return fn(target, msg) })
+}
+
+// RegisterAlert 注册渠道的告警发送函数。事件类渠道（如 PagerDuty、Opsgenie）以告警指纹触发和关闭事件，
+// 抖动恢复、静默改道和摘要通过该函数按告警的当前状态触发或关闭对应的事件，而不是发送通用消息创建新的事件。
+func RegisterAlert(channel string, fn AlertFunc) {
+	mu.Lock()
+	defer mu.Unlock()
+	alerts[channel] = fn
+}
+
+// SendsAlerts 返回渠道是否注册了告警发送函数。
+func SendsAlerts(channel string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := alerts[channel]
+	return ok
+}
+
+// SendAlert 通过渠道注册的告警发送函数发送告警，发送失败时按重试策略重试；
+// 渠道没有注册告警发送函数时改为发送通用消息 msg。
+func SendAlert(channel, target string, alert common.Alert, msg Message) error {
+	mu.RLock()
+	fn, ok := alerts[channel]
+	mu.RUnlock()
+
+	if !ok {
+		return Send(channel, target, msg)
+	}
+	return Retry(func() error { return fn(target, alert) })
+}
+
+// Channels 返回已注册的渠道名称，按名称排序。
+func Channels() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// retryPolicy 发送失败时的重试策略，所有渠道共用。
+var retryPolicy = struct {
+	Attempts int           // 最多尝试次数（包含第一次发送）
+	Backoff  time.Duration // 第一次重试前的等待时间，之后每次翻倍
+}{
+	Attempts: 3,
+	Backoff:  500 * time.Millisecond,
+}
+
+// Init 从环境变量加载重试策略：SEND_RETRY_ATTEMPTS 为最多尝试次数（默认 3，设置为 1 时不重试），
+// SEND_RETRY_BACKOFF 为第一次重试前的等待时间（默认 500ms，之后每次翻倍）。
+func Init() {
+	if val := os.Getenv("SEND_RETRY_ATTEMPTS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 1 {
+			retryPolicy.Attempts = n
+		} else {
+			log.Printf("⚠️ Invalid SEND_RETRY_ATTEMPTS %q, using default %d", val, retryPolicy.Attempts)
+		}
+	}
+	if val := os.Getenv("SEND_RETRY_BACKOFF"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d >= 0 {
+			retryPolicy.Backoff = d
+		} else {
+			log.Printf("⚠️ Invalid SEND_RETRY_BACKOFF %q, using default %v", val, retryPolicy.Backoff)
+		}
+	}
+}
+
+// permanentError 不需要重试的错误。
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 将错误标记为不需要重试，如配置错误、目标返回 4xx 等。
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry 执行发送函数，失败时按重试策略重试，被 Permanent 标记的错误不重试。
+func Retry(fn func() error) error {
+	backoff := retryPolicy.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= retryPolicy.Attempts {
+			return err
+		}
+		log.Printf("🔁 Send failed (attempt %d/%d), retrying in %v: %v", attempt, retryPolicy.Attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,54 @@
+package oncall
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// teamStatus /api/oncall 接口中单个团队的值班信息。
+type teamStatus struct {
+	Team     string  `json:"team"`
+	Timezone string  `json:"timezone"`
+	Now      []Shift `json:"now"`
+	Next     []Shift `json:"next"`
+}
+
+// Handler 返回 /api/oncall 接口的处理函数，展示各团队当前及下一位值班人。
+// 可以通过 team 参数只查询指定团队，多个团队用逗号分隔。
+func Handler(cfg *Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg == nil {
+			http.Error(w, "oncall schedule not configured", http.StatusNotFound)
+			return
+		}
+
+		teams := make(map[string]bool)
+		if param := r.URL.Query().Get("team"); param != "" {
+			for _, t := range strings.Split(param, ",") {
+				teams[strings.TrimSpace(t)] = true
+			}
+		}
+
+		now := time.Now()
+		result := make([]teamStatus, 0, len(cfg.Schedules))
+		for _, s := range cfg.Schedules {
+			if len(teams) > 0 && !teams[s.Team] {
+				continue
+			}
+			result = append(result, teamStatus{
+				Team:     s.Team,
+				Timezone: s.location.String(),
+				Now:      s.At(now),
+				Next:     s.Next(now),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package oncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	// 87600h（约 10 年）的固定班次，当前值班人和下一位值班人不随测试运行时间变化
+	db := newSchedule(t, `{"team": "db", "timezone": "UTC", "rotations": [{"name": "primary", "type": "87600h",
+		"start": "2024-01-01", "members": [{"name": "a"}, {"name": "b"}]}]}`)
+	now := time.Now().UTC().Truncate(time.Second)
+	web := newSchedule(t, fmt.Sprintf(`{"team": "web", "timezone": "Asia/Shanghai", "rotations": [{"name": "primary", "type": "87600h",
+		"start": "2024-01-01", "members": [{"name": "c"}, {"name": "d"}]}],
+		"overrides": [{"start": %q, "end": %q, "member": {"name": "x"}}]}`,
+		now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339)))
+	cfg := &Config{Schedules: []*Schedule{db, web}}
+
+	get := func(t *testing.T, cfg *Config, query string) (int, []teamStatus) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		Handler(cfg)(rec, httptest.NewRequest(http.MethodGet, "/api/oncall"+query, nil))
+		var result []teamStatus
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("invalid response %s: %v", rec.Body, err)
+			}
+		}
+		return rec.Code, result
+	}
+
+	code, result := get(t, cfg, "")
+	if code != http.StatusOK || len(result) != 2 {
+		t.Fatalf("got %d %+v", code, result)
+	}
+
+	handoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(87600 * time.Hour)
+	status := result[0]
+	if status.Team != "db" || status.Timezone != "UTC" {
+		t.Errorf("team = %s, timezone = %s", status.Team, status.Timezone)
+	}
+	if len(status.Now) != 1 || status.Now[0].Member.Name != "a" || status.Now[0].Rotation != "primary" || !status.Now[0].End.Equal(handoff) {
+		t.Errorf("db now = %+v", status.Now)
+	}
+	if len(status.Next) != 1 || status.Next[0].Member.Name != "b" || !status.Next[0].Start.Equal(handoff) {
+		t.Errorf("db next = %+v", status.Next)
+	}
+
+	status = result[1]
+	if status.Timezone != "Asia/Shanghai" {
+		t.Errorf("web timezone = %s", status.Timezone)
+	}
+	if len(status.Now) != 1 || status.Now[0].Member.Name != "x" || !status.Now[0].Override || !status.Now[0].End.Equal(now.Add(time.Hour)) {
+		t.Errorf("web now = %+v", status.Now)
+	}
+	if len(status.Next) != 1 || status.Next[0].Member.Name != "c" || status.Next[0].Override || !status.Next[0].Start.Equal(now.Add(time.Hour)) {
+		t.Errorf("web next = %+v", status.Next)
+	}
+
+	if _, result := get(t, cfg, "?team=web,%20unknown"); len(result) != 1 || result[0].Team != "web" {
+		t.Errorf("team filter = %+v", result)
+	}
+	if code, _ := get(t, nil, ""); code != http.StatusNotFound {
+		t.Errorf("unconfigured = %d, want 404", code)
+	}
+}
@@ -0,0 +1,343 @@
+// Package oncall 提供值班表功能，根据轮值规则、交接时间和临时替班计算当前及下一位值班人。
+package oncall
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 轮值类型。
+const (
+	RotationDaily  = "daily"
+	RotationWeekly = "weekly"
+)
+
+// Member 值班成员。
+type Member struct {
+	Name   string `json:"name"`
+	OpenID string `json:"open_id,omitempty"` // 飞书 open_id
+	Email  string `json:"email,omitempty"`
+	Mobile string `json:"mobile,omitempty"` // 手机号，钉钉、企业微信 @ 使用
+	UserID string `json:"userid,omitempty"` // 企业微信 userid
+}
+
+// Rotation 一层轮值，成员按顺序轮流值班。
+type Rotation struct {
+	Name    string   `json:"name"`    // 轮值名称，如 primary、secondary
+	Type    string   `json:"type"`    // daily、weekly，或时长（如 72h）表示固定长度的班次
+	Handoff string   `json:"handoff"` // 交接时间：daily 为 "10:00"，weekly 为 "Mon 10:00"
+	Start   string   `json:"start"`   // 轮值起始日期（YYYY-MM-DD），该日期所在班次由第一位成员值班
+	Members []Member `json:"members"`
+
+	length   time.Duration // 固定长度班次的时长
+	days     int           // 按日历计算的班次天数（daily 为 1，weekly 为 7）
+	weekday  time.Weekday  // weekly 的交接日
+	hour     int           // 交接时间（时）
+	minute   int           // 交接时间（分）
+	startDay time.Time     // 起始日期（所在时区的 00:00）
+}
+
+// Override 临时替班，在 [Start, End) 时间段内替换指定轮值（为空时替换所有轮值）的值班人。
+type Override struct {
+	Rotation string    `json:"rotation,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Member   Member    `json:"member"`
+}
+
+// Schedule 一个团队的值班表。
+type Schedule struct {
+	Team      string     `json:"team"`     // 团队名称，与告警标签值对应
+	Label     string     `json:"label"`    // 匹配的告警标签名，默认 team
+	Timezone  string     `json:"timezone"` // 交接时间使用的时区，默认本地时区
+	Rotations []Rotation `json:"rotations"`
+	Overrides []Override `json:"overrides"`
+
+	location *time.Location
+}
+
+// Config 值班配置。
+type Config struct {
+	Schedules []*Schedule `json:"schedules"`
+}
+
+// Shift 某一轮值的一个班次。
+type Shift struct {
+	Rotation string    `json:"rotation"`
+	Member   Member    `json:"member"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Override bool      `json:"override"` // 是否为临时替班
+}
+
+// Load 从 JSON 文件加载并校验值班配置。
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oncall config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse oncall config: %w", err)
+	}
+
+	for _, s := range cfg.Schedules {
+		if err := s.init(); err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", s.Team, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// init 校验值班表并解析时区、交接时间等字段。
+func (s *Schedule) init() error {
+	if s.Team == "" {
+		return fmt.Errorf("team is required")
+	}
+	if s.Label == "" {
+		s.Label = "team"
+	}
+
+	s.location = time.Local
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+		}
+		s.location = loc
+	}
+
+	if len(s.Rotations) == 0 {
+		return fmt.Errorf("at least one rotation is required")
+	}
+	for i := range s.Rotations {
+		r := &s.Rotations[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rotation-%d", i+1)
+		}
+		if err := r.init(s.location); err != nil {
+			return fmt.Errorf("rotation %q: %w", r.Name, err)
+		}
+	}
+
+	for _, o := range s.Overrides {
+		if !o.End.After(o.Start) {
+			return fmt.Errorf("override for %s: end must be after start", o.Member.Name)
+		}
+	}
+	return nil
+}
+
+// init 解析轮值的类型、交接时间和起始日期。
+func (r *Rotation) init(loc *time.Location) error {
+	if len(r.Members) == 0 {
+		return fmt.Errorf("at least one member is required")
+	}
+
+	handoff := strings.TrimSpace(r.Handoff)
+	switch strings.ToLower(r.Type) {
+	case RotationDaily:
+		r.days = 1
+	case RotationWeekly, "":
+		r.days = 7
+		r.Type = RotationWeekly
+		// weekly 交接时间格式为 "Mon 10:00"，省略星期时默认周一
+		r.weekday = time.Monday
+		if fields := strings.Fields(handoff); len(fields) == 2 {
+			weekday, ok := parseWeekday(fields[0])
+			if !ok {
+				return fmt.Errorf("invalid handoff weekday %q", fields[0])
+			}
+			r.weekday = weekday
+			handoff = fields[1]
+		}
+	default:
+		length, err := time.ParseDuration(r.Type)
+		if err != nil || length <= 0 {
+			return fmt.Errorf("invalid rotation type %q", r.Type)
+		}
+		r.length = length
+	}
+
+	if handoff == "" {
+		handoff = "00:00"
+	}
+	t, err := time.Parse("15:04", handoff)
+	if err != nil {
+		return fmt.Errorf("invalid handoff time %q", r.Handoff)
+	}
+	r.hour, r.minute = t.Hour(), t.Minute()
+
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, loc)
+	if r.Start != "" {
+		day, err := time.ParseInLocation("2006-01-02", r.Start, loc)
+		if err != nil {
+			return fmt.Errorf("invalid start date %q", r.Start)
+		}
+		start = day
+	}
+	r.startDay = start
+	return nil
+}
+
+// parseWeekday 解析星期的英文缩写或全称。
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// shiftAt 计算轮值在时间点 t 的班次（不考虑替班）。
+func (r *Rotation) shiftAt(t time.Time, loc *time.Location) Shift {
+	var start, end time.Time
+	var index int
+
+	if r.length > 0 {
+		anchor := time.Date(r.startDay.Year(), r.startDay.Month(), r.startDay.Day(), r.hour, r.minute, 0, 0, loc)
+		periods := int(floorDiv(int64(t.Sub(anchor)), int64(r.length)))
+		start = anchor.Add(time.Duration(periods) * r.length)
+		end = start.Add(r.length)
+		index = periods
+	} else {
+		anchor := r.lastHandoff(time.Date(r.startDay.Year(), r.startDay.Month(), r.startDay.Day(), r.hour, r.minute, 0, 0, loc))
+		start = r.lastHandoff(t.In(loc))
+		end = start.AddDate(0, 0, r.days)
+		index = int(floorDiv(int64(civilDays(anchor, start)), int64(r.days)))
+	}
+
+	n := len(r.Members)
+	member := r.Members[((index%n)+n)%n]
+	return Shift{Rotation: r.Name, Member: member, Start: start, End: end}
+}
+
+// lastHandoff 返回不晚于 t 的最近一次交接时间（按日历计算，夏令时切换不影响交接时刻）。
+func (r *Rotation) lastHandoff(t time.Time) time.Time {
+	handoff := time.Date(t.Year(), t.Month(), t.Day(), r.hour, r.minute, 0, 0, t.Location())
+	if r.days == 7 {
+		handoff = handoff.AddDate(0, 0, -((int(handoff.Weekday()) - int(r.weekday) + 7) % 7))
+	}
+	if handoff.After(t) {
+		handoff = handoff.AddDate(0, 0, -r.days)
+	}
+	return handoff
+}
+
+// civilDays 计算两个时间点所在日期之间相差的天数。
+func civilDays(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// floorDiv 向下取整的整数除法。
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// At 返回值班表在时间点 t 各轮值的班次，临时替班优先。
+func (s *Schedule) At(t time.Time) []Shift {
+	shifts := make([]Shift, 0, len(s.Rotations))
+	for i := range s.Rotations {
+		shifts = append(shifts, s.shiftWithOverride(&s.Rotations[i], t))
+	}
+	return shifts
+}
+
+// Next 返回各轮值在时间点 t 之后的下一个班次。
+func (s *Schedule) Next(t time.Time) []Shift {
+	shifts := make([]Shift, 0, len(s.Rotations))
+	for i := range s.Rotations {
+		r := &s.Rotations[i]
+		current := s.shiftWithOverride(r, t)
+		// 跳过值班人不变的班次，最多向后查找 len(members)+len(overrides)+1 次
+		next := current
+		for range len(r.Members) + len(s.Overrides) + 1 {
+			next = s.shiftWithOverride(r, next.End)
+			if next.Member != current.Member {
+				break
+			}
+		}
+		shifts = append(shifts, next)
+	}
+	return shifts
+}
+
+// shiftWithOverride 计算轮值在时间点 t 的班次，并应用临时替班。
+// 替班的班次边界与原班次取交集。
+func (s *Schedule) shiftWithOverride(r *Rotation, t time.Time) Shift {
+	shift := r.shiftAt(t, s.location)
+
+	for _, o := range s.Overrides {
+		if o.Rotation != "" && o.Rotation != r.Name {
+			continue
+		}
+		if !t.Before(o.Start) && t.Before(o.End) {
+			return Shift{Rotation: r.Name, Member: o.Member, Start: o.Start, End: o.End, Override: true}
+		}
+		// 原班次中途开始的替班会提前结束原班次
+		if o.Start.After(t) && o.Start.Before(shift.End) {
+			shift.End = o.Start
+		}
+		// 原班次中途结束的替班会推迟原班次的开始时间
+		if !o.End.After(t) && o.End.After(shift.Start) {
+			shift.Start = o.End
+		}
+	}
+	return shift
+}
+
+// Find 返回与告警标签匹配的值班表。
+func (c *Config) Find(labels map[string]string) []*Schedule {
+	if c == nil {
+		return nil
+	}
+	var matched []*Schedule
+	for _, s := range c.Schedules {
+		if labels[s.Label] == s.Team {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// OnCall 返回与告警标签匹配的所有值班表中当前的值班人（去重）。
+func (c *Config) OnCall(labels map[string]string, t time.Time) []Member {
+	seen := make(map[Member]bool)
+	var members []Member
+	for _, s := range c.Find(labels) {
+		for _, shift := range s.At(t) {
+			if !seen[shift.Member] {
+				seen[shift.Member] = true
+				members = append(members, shift.Member)
+			}
+		}
+	}
+	return members
+}
+
+// Teams 返回所有值班表的团队名称，按名称排序。
+func (c *Config) Teams() []string {
+	if c == nil {
+		return nil
+	}
+	teams := make([]string, 0, len(c.Schedules))
+	for _, s := range c.Schedules {
+		teams = append(teams, s.Team)
+	}
+	sort.Strings(teams)
+	return teams
+}
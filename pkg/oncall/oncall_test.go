@@ -0,0 +1,165 @@
+package oncall
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// newSchedule 解析并初始化 JSON 格式的值班表。
+func newSchedule(t *testing.T, data string) *Schedule {
+	t.Helper()
+	var s Schedule
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.init(); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+// mustTime 解析 RFC3339 格式的时间。
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+// shiftCase 某一时间点期望的班次。
+type shiftCase struct {
+	name     string
+	at       string
+	member   string
+	start    string
+	end      string
+	override bool
+}
+
+// checkShift 校验班次的值班人和起止时间。
+func checkShift(t *testing.T, got Shift, tt shiftCase) {
+	t.Helper()
+	if got.Member.Name != tt.member {
+		t.Errorf("member = %s, want %s", got.Member.Name, tt.member)
+	}
+	if want := mustTime(t, tt.start); !got.Start.Equal(want) {
+		t.Errorf("start = %v, want %v", got.Start, want)
+	}
+	if want := mustTime(t, tt.end); !got.End.Equal(want) {
+		t.Errorf("end = %v, want %v", got.End, want)
+	}
+	if got.Override != tt.override {
+		t.Errorf("override = %v, want %v", got.Override, tt.override)
+	}
+}
+
+func TestWeeklyRotation(t *testing.T) {
+	s := newSchedule(t, `{"team": "db", "timezone": "UTC", "rotations": [{"name": "primary", "type": "weekly",
+		"handoff": "Mon 10:00", "start": "2024-01-01", "members": [{"name": "a"}, {"name": "b"}, {"name": "c"}]}]}`)
+
+	for _, tt := range []shiftCase{
+		{"first shift", "2024-01-01T10:00:00Z", "a", "2024-01-01T10:00:00Z", "2024-01-08T10:00:00Z", false},
+		{"before start", "2024-01-01T09:59:00Z", "c", "2023-12-25T10:00:00Z", "2024-01-01T10:00:00Z", false},
+		{"just before handoff", "2024-01-08T09:59:59Z", "a", "2024-01-01T10:00:00Z", "2024-01-08T10:00:00Z", false},
+		{"at handoff", "2024-01-08T10:00:00Z", "b", "2024-01-08T10:00:00Z", "2024-01-15T10:00:00Z", false},
+		{"mid week", "2024-01-19T23:00:00Z", "c", "2024-01-15T10:00:00Z", "2024-01-22T10:00:00Z", false},
+		{"wraps around", "2024-01-22T10:00:00Z", "a", "2024-01-22T10:00:00Z", "2024-01-29T10:00:00Z", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			shifts := s.At(mustTime(t, tt.at))
+			if len(shifts) != 1 {
+				t.Fatalf("got %d shifts", len(shifts))
+			}
+			checkShift(t, shifts[0], tt)
+		})
+	}
+}
+
+func TestRotationTimezone(t *testing.T) {
+	// Europe/Berlin 2024-03-31 02:00 进入夏令时，America/New_York 2024-11-03 02:00 退出夏令时，
+	// 交接时刻按当地时间保持不变，跨越切换的班次比平时短或长一小时
+	berlin := newSchedule(t, `{"team": "db", "timezone": "Europe/Berlin", "rotations": [{"type": "weekly",
+		"handoff": "Sun 09:00", "start": "2024-03-03", "members": [{"name": "a"}, {"name": "b"}]}]}`)
+	newYork := newSchedule(t, `{"team": "web", "timezone": "America/New_York", "rotations": [{"type": "daily",
+		"handoff": "09:00", "start": "2024-11-01", "members": [{"name": "a"}, {"name": "b"}]}]}`)
+
+	for _, tt := range []struct {
+		schedule *Schedule
+		shiftCase
+	}{
+		{berlin, shiftCase{"berlin before handoff", "2024-03-31T06:59:00Z", "b", "2024-03-24T08:00:00Z", "2024-03-31T07:00:00Z", false}},
+		{berlin, shiftCase{"berlin at handoff", "2024-03-31T07:00:00Z", "a", "2024-03-31T07:00:00Z", "2024-04-07T07:00:00Z", false}},
+		{berlin, shiftCase{"berlin winter", "2024-03-10T07:59:00Z", "a", "2024-03-03T08:00:00Z", "2024-03-10T08:00:00Z", false}},
+		{newYork, shiftCase{"new york long day", "2024-11-03T13:30:00Z", "b", "2024-11-02T13:00:00Z", "2024-11-03T14:00:00Z", false}},
+		{newYork, shiftCase{"new york at handoff", "2024-11-03T14:00:00Z", "a", "2024-11-03T14:00:00Z", "2024-11-04T14:00:00Z", false}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkShift(t, tt.schedule.At(mustTime(t, tt.at))[0], tt.shiftCase)
+		})
+	}
+
+	if got := berlin.At(mustTime(t, "2024-03-31T07:00:00Z"))[0]; got.Start.Location().String() != "Europe/Berlin" {
+		t.Errorf("shift location = %v, want Europe/Berlin", got.Start.Location())
+	}
+}
+
+func TestOverrideAcrossHandoff(t *testing.T) {
+	// 替班覆盖 2024-01-08 10:00 的交接：前一班次提前结束，后一班次推迟开始
+	s := newSchedule(t, `{"team": "db", "timezone": "UTC",
+		"rotations": [
+			{"name": "primary", "type": "weekly", "handoff": "Mon 10:00", "start": "2024-01-01", "members": [{"name": "a"}, {"name": "b"}]},
+			{"name": "secondary", "type": "weekly", "handoff": "Mon 10:00", "start": "2024-01-01", "members": [{"name": "c"}, {"name": "d"}]}
+		],
+		"overrides": [{"rotation": "primary", "start": "2024-01-08T08:00:00Z", "end": "2024-01-08T12:00:00Z", "member": {"name": "x"}}]}`)
+
+	for _, tt := range []shiftCase{
+		{"before override", "2024-01-08T07:00:00Z", "a", "2024-01-01T10:00:00Z", "2024-01-08T08:00:00Z", false},
+		{"override start", "2024-01-08T08:00:00Z", "x", "2024-01-08T08:00:00Z", "2024-01-08T12:00:00Z", true},
+		{"override at handoff", "2024-01-08T10:00:00Z", "x", "2024-01-08T08:00:00Z", "2024-01-08T12:00:00Z", true},
+		{"after override", "2024-01-08T12:00:00Z", "b", "2024-01-08T12:00:00Z", "2024-01-15T10:00:00Z", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			shifts := s.At(mustTime(t, tt.at))
+			checkShift(t, shifts[0], tt)
+			// 只替换指定的轮值
+			if shifts[1].Override {
+				t.Errorf("secondary rotation should not be overridden: %+v", shifts[1])
+			}
+		})
+	}
+
+	for _, tt := range []shiftCase{
+		{"next is override", "2024-01-08T07:00:00Z", "x", "2024-01-08T08:00:00Z", "2024-01-08T12:00:00Z", true},
+		{"next after override", "2024-01-08T09:00:00Z", "b", "2024-01-08T12:00:00Z", "2024-01-15T10:00:00Z", false},
+		{"next early in shift", "2024-01-02T00:00:00Z", "x", "2024-01-08T08:00:00Z", "2024-01-08T12:00:00Z", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkShift(t, s.Next(mustTime(t, tt.at))[0], tt)
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"timezone": "UTC", "rotations": [{"members": [{"name": "a"}]}]}`,
+		`{"team": "db", "timezone": "Mars/Base", "rotations": [{"members": [{"name": "a"}]}]}`,
+		`{"team": "db", "rotations": []}`,
+		`{"team": "db", "rotations": [{"members": []}]}`,
+		`{"team": "db", "rotations": [{"type": "weekly", "handoff": "Xyz 10:00", "members": [{"name": "a"}]}]}`,
+		`{"team": "db", "rotations": [{"type": "hourly", "members": [{"name": "a"}]}]}`,
+		`{"team": "db", "rotations": [{"type": "daily", "handoff": "25:00", "members": [{"name": "a"}]}]}`,
+		`{"team": "db", "rotations": [{"members": [{"name": "a"}]}],
+			"overrides": [{"start": "2024-01-02T00:00:00Z", "end": "2024-01-01T00:00:00Z", "member": {"name": "x"}}]}`,
+	} {
+		var s Schedule
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.init(); err == nil {
+			t.Errorf("init(%s) should fail", data)
+		}
+	}
+}
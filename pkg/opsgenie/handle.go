@@ -0,0 +1,183 @@
+package opsgenie
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，firing 告警创建 Opsgenie 告警，resolved 告警关闭对应的告警。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认发送到所有已配置的 Opsgenie 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则发送到所有配置的 Opsgenie 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.OpsgenieTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid opsgenie targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "opsgenie", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		// resolved 告警关闭 alias 对应的告警
+		if alert.Status == "resolved" {
+			for name, apiKey := range sendTargets {
+				_ = delivery.Send(alert, "opsgenie", name, func() error {
+					return closeAlert(name, apiKey, alert)
+				})
+			}
+			continue
+		}
+
+		og := buildAlert(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标
+		for name, apiKey := range sendTargets {
+			_ = delivery.Send(alert, "opsgenie", name, func() error {
+				return og.Create(name, apiKey)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// closeAlert 使用指定的 API key 关闭告警指纹对应的 Opsgenie 告警，没有指纹的告警无法关闭，不再重试。
+func closeAlert(name, apiKey string, alert common.Alert) error {
+	if alert.Fingerprint == "" {
+		return notify.Permanent(errors.New("cannot close opsgenie alert for alert without fingerprint"))
+	}
+	return Close(name, apiKey, alert.Fingerprint, "Resolved in Alertmanager")
+}
+
+// SendAlert 按告警的当前状态发送到指定名称的 Opsgenie 目标，供抖动恢复、静默改道和摘要使用：
+// firing 告警以告警指纹为 alias 创建告警（已有告警时只增加计数），resolved 告警关闭对应的告警。
+func SendAlert(name string, alert common.Alert) error {
+	apiKey, ok := common.OpsgenieTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("opsgenie target '%s' not found in configuration", name))
+	}
+	if alert.Status == "resolved" {
+		return closeAlert(name, apiKey, alert)
+	}
+	return buildAlert(alert, "", flapping.Status{}).Create(name, apiKey)
+}
+
+// buildAlert 为 firing 告警构建 Opsgenie 告警：优先级和详情来自告警的标签和注解，
+// 描述中包含告警描述、指标趋势和触发日志，详情中附带触发日志的摘录。
+func buildAlert(alert common.Alert, externalURL string, flap flapping.Status) *Alert {
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	message := alertName
+	if s := alert.Annotations["summary"]; s != "" {
+		message = alertName + ": " + s
+	}
+	if flap.Flapping {
+		message = "[FLAPPING] " + message
+	}
+
+	// 详情：告警标签和注解，summary、description 已在标题和描述中展示，trigger_logs 和 log_query 通过触发日志展示
+	details := make(map[string]string, len(alert.Labels)+len(alert.Annotations))
+	for k, v := range alert.Labels {
+		details[k] = truncate(v, detailLimit)
+	}
+	for k, v := range alert.Annotations {
+		switch k {
+		case "summary", "description", "trigger_logs", "log_query":
+		default:
+			details[k] = truncate(v, detailLimit)
+		}
+	}
+	if alert.GeneratorURL != "" {
+		details["generator_url"] = alert.GeneratorURL
+	}
+	if externalURL != "" {
+		details["alertmanager_url"] = externalURL
+	}
+
+	var description strings.Builder
+	if desc := alert.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&description, "%s\n\n", desc)
+	}
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	if trend := common.MetricTrendText(alert); trend != "" {
+		details["metric_trend"] = trend
+		fmt.Fprintf(&description, "Metric trend: %s\n\n", trend)
+	}
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		fmt.Fprintf(&description, "🔀 Flapping: %d transitions in %v, muted until stable for %v\n\n",
+			flap.Transitions, flap.Window, flap.Stable)
+	}
+
+	// 尝试从 Loki 查询实际日志内容
+	if logs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)"); logs != "" {
+		details["trigger_logs"] = truncate(logs, detailLimit)
+		fmt.Fprintf(&description, "Trigger logs:\n%s\n", logs)
+	}
+
+	// 标签：告警名称、级别和 Alertmanager 标记
+	tags := []string{"alertmanager", truncate("alertname:"+alertName, tagLimit)}
+	if severity := alert.Labels["severity"]; severity != "" {
+		tags = append(tags, truncate("severity:"+severity, tagLimit))
+	}
+
+	return &Alert{
+		Message:     truncate(message, messageLimit),
+		Alias:       alert.Fingerprint,
+		Description: truncate(strings.TrimSpace(description.String()), descriptionLimit),
+		Tags:        tags,
+		Details:     details,
+		Entity:      alert.Labels["instance"],
+		Source:      source,
+		Priority:    Priority(alert.Labels),
+	}
+}
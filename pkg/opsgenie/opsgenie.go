@@ -0,0 +1,142 @@
+// Package opsgenie 提供通过 Opsgenie Alert API 创建和关闭告警的功能。
+// 告警指纹作为 Opsgenie 告警的 alias，firing 告警创建告警（相同 alias 的重复通知只增加计数），
+// resolved 告警自动关闭对应的告警。
+package opsgenie
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Opsgenie 告警字段的长度限制（字符）。
+const (
+	messageLimit     = 130
+	descriptionLimit = 15000
+	detailLimit      = 2000 // 单个详情值，所有详情合计不能超过 8000 字符
+	tagLimit         = 50
+)
+
+// source 告警来源。
+const source = "Alertmanager"
+
+// client 发送 Opsgenie 请求使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Alert 定义了创建 Opsgenie 告警的请求结构。
+type Alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Priority    string            `json:"priority,omitempty"` // P1 到 P5
+	Note        string            `json:"note,omitempty"`
+}
+
+// Create 使用指定的 API key 创建告警。
+func (a *Alert) Create(name, apiKey string) error {
+	return request(name, apiKey, "/v2/alerts", a)
+}
+
+// Close 使用指定的 API key 关闭 alias 对应的告警。
+func Close(name, apiKey, alias, note string) error {
+	path := "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+	return request(name, apiKey, path, map[string]string{"source": source, "note": note})
+}
+
+// request 发送 Alert API 请求。Opsgenie 异步处理请求，成功时返回 202。
+// 限流（429）和服务端错误可以重试，其他错误（如 API key 无效、请求格式错误）不再重试。
+func request(name, apiKey, path string, body interface{}) error {
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, common.OpsgenieConfig.APIBase+path, bytes.NewReader(data))
+	if err != nil {
+		return notify.Permanent(fmt.Errorf("failed to create request for %s: %w", name, err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("opsgenie %s returned %s: %s", name, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// priorityPattern Opsgenie 的优先级格式。
+var priorityPattern = regexp.MustCompile(`^P[1-5]$`)
+
+// Priority 返回告警的 Opsgenie 优先级：标签 priority 为 P1 到 P5 时直接使用，
+// 否则按告警级别映射（critical 为 P1，error 为 P2，warning 为 P3，info 为 P5），未知级别为 P3。
+func Priority(labels map[string]string) string {
+	if p := strings.ToUpper(labels["priority"]); priorityPattern.MatchString(p) {
+		return p
+	}
+	return severityPriority(labels["severity"])
+}
+
+// severityPriority 将告警级别映射为 Opsgenie 优先级。
+func severityPriority(severity string) string {
+	switch common.SeverityRank(severity) {
+	case 4:
+		return "P1"
+	case 3:
+		return "P2"
+	case 1:
+		return "P5"
+	default:
+		return "P3"
+	}
+}
+
+// truncate 将字符串截断为最多 n 个字符，超出部分以省略号代替。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// SendText 发送通用通知消息到指定名称的 Opsgenie 目标，供升级、报表等后台任务使用。
+// 每条消息创建一个新的告警，消息的 Severity 决定优先级。
+func SendText(name string, msg notify.Message) error {
+	apiKey, ok := common.OpsgenieTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("opsgenie target '%s' not found in configuration", name))
+	}
+
+	message := msg.Title
+	if message == "" {
+		message = strings.SplitN(msg.Text, "\n", 2)[0]
+	}
+	alert := &Alert{
+		Message:     truncate(message, messageLimit),
+		Description: truncate(msg.Text, descriptionLimit),
+		Source:      source,
+		Priority:    severityPriority(msg.Severity),
+	}
+	return alert.Create(name, apiKey)
+}
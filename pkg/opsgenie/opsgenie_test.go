@@ -0,0 +1,223 @@
+package opsgenie
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"alertmanagerWebhookAdapter/pkg/quiet"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apiRequest 测试服务器收到的请求。
+type apiRequest struct {
+	Path          string // 包含查询参数
+	Authorization string
+	Body          map[string]any
+}
+
+// fakeOpsgenie 模拟 Alert API 的创建和关闭接口，记录收到的请求。
+type fakeOpsgenie struct {
+	mu       sync.Mutex
+	requests []apiRequest
+	status   int
+}
+
+func newFakeOpsgenie(t *testing.T) *fakeOpsgenie {
+	f := &fakeOpsgenie{status: http.StatusAccepted}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/v2/alerts") {
+			http.NotFound(w, r)
+			return
+		}
+		req := apiRequest{Path: r.URL.RequestURI(), Authorization: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		status := f.status
+		f.mu.Unlock()
+
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"result":"Request will be processed","took":0.01,"requestId":"r1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	common.OpsgenieConfig.APIBase = srv.URL
+	common.OpsgenieTargets = map[string]string{"ops": "api-key"}
+	return f
+}
+
+func (f *fakeOpsgenie) received() []apiRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]apiRequest(nil), f.requests...)
+}
+
+// post 发送 webhook 请求到 Handler。
+func post(t *testing.T, status, alertName, fingerprint string) {
+	t.Helper()
+	payload := fmt.Sprintf(`{"receiver":"og","status":%q,"externalURL":"http://am:9093","alerts":[{
+		"status":%q,
+		"labels":{"alertname":%q,"severity":"error","instance":"node-1"},
+		"annotations":{"summary":"CPU usage above 90%%","description":"node-1 is busy","runbook":"http://wiki/cpu"},
+		"startsAt":"2026-10-19T06:00:00Z","generatorURL":"http://prom:9090/graph","fingerprint":%q}]}`,
+		status, status, alertName, fingerprint)
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("POST", "/opsgenie?target=ops", strings.NewReader(payload)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
+
+func TestHandlerCreateClose(t *testing.T) {
+	f := newFakeOpsgenie(t)
+
+	post(t, "firing", "HighCPU", "fp/create")
+	post(t, "resolved", "HighCPU", "fp/create")
+
+	requests := f.received()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+
+	create := requests[0]
+	if create.Path != "/v2/alerts" || create.Authorization != "GenieKey api-key" {
+		t.Errorf("unexpected create request: %s %s", create.Path, create.Authorization)
+	}
+	body := create.Body
+	if body["alias"] != "fp/create" || body["message"] != "HighCPU: CPU usage above 90%" ||
+		body["priority"] != "P2" || body["entity"] != "node-1" || body["source"] != "Alertmanager" {
+		t.Errorf("unexpected create body: %v", body)
+	}
+	if !strings.HasPrefix(body["description"].(string), "node-1 is busy") {
+		t.Errorf("description = %q", body["description"])
+	}
+	details, _ := body["details"].(map[string]any)
+	if details["runbook"] != "http://wiki/cpu" || details["alertmanager_url"] != "http://am:9093" || details["summary"] != nil {
+		t.Errorf("unexpected details: %v", details)
+	}
+
+	// alias 中的 / 需要转义，以 alias 标识关闭对应的告警
+	closeReq := requests[1]
+	if closeReq.Path != "/v2/alerts/fp%2Fcreate/close?identifierType=alias" || closeReq.Authorization != "GenieKey api-key" {
+		t.Errorf("unexpected close request: %s %s", closeReq.Path, closeReq.Authorization)
+	}
+	if closeReq.Body["source"] != "Alertmanager" || closeReq.Body["note"] != "Resolved in Alertmanager" {
+		t.Errorf("unexpected close body: %v", closeReq.Body)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		want   string
+	}{
+		{map[string]string{"severity": "critical"}, "P1"},
+		{map[string]string{"severity": "error"}, "P2"},
+		{map[string]string{"severity": "warning"}, "P3"},
+		{map[string]string{"severity": "info"}, "P5"},
+		{map[string]string{"severity": "unknown"}, "P3"},
+		{map[string]string{}, "P3"},
+		{map[string]string{"severity": "info", "priority": "p1"}, "P1"},
+		{map[string]string{"severity": "critical", "priority": "P4"}, "P4"},
+		{map[string]string{"severity": "critical", "priority": "P9"}, "P1"},
+	}
+	for _, tt := range tests {
+		if got := Priority(tt.labels); got != tt.want {
+			t.Errorf("Priority(%v) = %q, want %q", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestRequestRetry(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	for status, attempts := range map[int]int{
+		http.StatusUnauthorized:        1,
+		http.StatusUnprocessableEntity: 1,
+		http.StatusTooManyRequests:     3,
+		http.StatusBadGateway:          3,
+	} {
+		f := newFakeOpsgenie(t)
+		f.status = status
+		err := notify.Retry(func() error {
+			return Close("ops", "api-key", "fp", "note")
+		})
+		if err == nil {
+			t.Errorf("%d: expected error", status)
+		}
+		if n := len(f.received()); n != attempts {
+			t.Errorf("%d: attempts = %d, want %d", status, n, attempts)
+		}
+	}
+}
+
+// TestSendAlert 抖动恢复和静默摘要按告警的当前状态创建或关闭告警，以告警指纹为 alias。
+func TestSendAlert(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "HighCPU", "severity": "critical"},
+		Fingerprint: "fp-replay",
+	}
+	if err := SendAlert("ops", alert); err != nil {
+		t.Fatalf("SendAlert firing: %v", err)
+	}
+	alert.Status = "resolved"
+	if err := SendAlert("ops", alert); err != nil {
+		t.Fatalf("SendAlert resolved: %v", err)
+	}
+	if err := SendAlert("ops", common.Alert{Status: "resolved"}); err == nil {
+		t.Error("expected error for resolved alert without fingerprint")
+	}
+
+	requests := f.received()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if requests[0].Path != "/v2/alerts" || requests[0].Body["alias"] != "fp-replay" || requests[0].Body["priority"] != "P1" {
+		t.Errorf("unexpected create request: %v", requests[0])
+	}
+	if requests[1].Path != "/v2/alerts/fp-replay/close?identifierType=alias" {
+		t.Errorf("unexpected close request: %v", requests[1])
+	}
+}
+
+// TestHandlerCloseDuringQuietHold 静默规则暂缓 firing 告警时，resolved 告警仍然关闭对应的告警。
+func TestHandlerCloseDuringQuietHold(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	notify.RegisterAlert("opsgenie", SendAlert)
+
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	config := fmt.Sprintf(`{"rules":[{"name":"maintenance","start":%q,"end":%q,
+		"matchers":{"alertname":"Maintenance"},"channels":["opsgenie"],"action":"hold"}]}`,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+	path := filepath.Join(t.TempDir(), "quiet.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("QUIET_CONFIG", path)
+	quiet.Init()
+
+	post(t, "firing", "Maintenance", "fp-quiet")
+	if n := len(f.received()); n != 0 {
+		t.Fatalf("firing alert during quiet hold sent %d requests, want 0", n)
+	}
+
+	post(t, "resolved", "Maintenance", "fp-quiet")
+	requests := f.received()
+	if len(requests) != 1 || requests[0].Path != "/v2/alerts/fp-quiet/close?identifierType=alias" {
+		t.Errorf("resolved alert during quiet hold: got %v, want one close request", requests)
+	}
+}
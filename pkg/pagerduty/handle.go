@@ -0,0 +1,178 @@
+package pagerduty
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// logsLimit 事件详情中触发日志的长度限制（字符），事件总大小不能超过 512KB。
+const logsLimit = 8000
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，firing 告警触发 PagerDuty 事件，resolved 告警解决对应的事件。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认发送到所有已配置的 PagerDuty 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则发送到所有配置的 PagerDuty 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.PagerDutyTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid pagerduty targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "pagerduty", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		event := buildEvent(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标
+		for name, routingKey := range sendTargets {
+			_ = delivery.Send(alert, "pagerduty", name, func() error {
+				return send(name, routingKey, event)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// send 使用指定的 routing key 发送告警的事件，没有指纹的 resolved 告警无法解决对应的事件，不再重试。
+func send(name, routingKey string, event Event) error {
+	if event.EventAction == actionResolve && event.DedupKey == "" {
+		return notify.Permanent(errors.New("cannot resolve pagerduty event for alert without fingerprint"))
+	}
+	return event.Send(name, routingKey)
+}
+
+// SendAlert 按告警的当前状态发送事件到指定名称的 PagerDuty 目标，供抖动恢复、静默改道和摘要使用：
+// firing 告警以告警指纹触发事件（已有事件时归入同一事件），resolved 告警解决对应的事件。
+func SendAlert(name string, alert common.Alert) error {
+	routingKey, ok := common.PagerDutyTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("pagerduty target '%s' not found in configuration", name))
+	}
+	return send(name, routingKey, buildEvent(alert, "", flapping.Status{}))
+}
+
+// buildEvent 为单个告警构建事件：resolved 告警为解决事件，其他告警为触发事件，
+// 事件级别和详情来自告警的标签和注解，触发日志和指标趋势附在详情中。
+func buildEvent(alert common.Alert, externalURL string, flap flapping.Status) Event {
+	if alert.Status == "resolved" {
+		return Event{EventAction: actionResolve, DedupKey: alert.Fingerprint}
+	}
+
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	summary := alertName
+	if s := alert.Annotations["summary"]; s != "" {
+		summary = alertName + ": " + s
+	}
+	if flap.Flapping {
+		summary = "[FLAPPING] " + summary
+	}
+
+	// 事件来源：优先使用 instance，其次 job
+	source := alert.Labels["instance"]
+	if source == "" {
+		source = alert.Labels["job"]
+	}
+	if source == "" {
+		source = "alertmanager"
+	}
+
+	// 注解中的 trigger_logs 和 log_query 通过触发日志展示，不重复
+	annotations := make(map[string]string, len(alert.Annotations))
+	for k, v := range alert.Annotations {
+		if k != "trigger_logs" && k != "log_query" {
+			annotations[k] = v
+		}
+	}
+	details := map[string]interface{}{
+		"status":      alert.Status,
+		"labels":      alert.Labels,
+		"annotations": annotations,
+	}
+
+	// 尝试从 Loki 查询实际日志内容
+	if logs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)"); logs != "" {
+		details["trigger_logs"] = truncate(logs, logsLimit)
+	}
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	if trend := common.MetricTrendText(alert); trend != "" {
+		details["metric_trend"] = trend
+	}
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		details["flapping"] = fmt.Sprintf("%d transitions in %v, muted until stable for %v", flap.Transitions, flap.Window, flap.Stable)
+	}
+
+	event := Event{
+		EventAction: actionTrigger,
+		DedupKey:    alert.Fingerprint,
+		Payload: &Payload{
+			Summary:       truncate(summary, summaryLimit),
+			Source:        source,
+			Severity:      Severity(alert.Labels["severity"]),
+			Component:     alert.Labels["job"],
+			Group:         alert.Labels["cluster"],
+			Class:         alertName,
+			CustomDetails: details,
+		},
+		Client:    "Alertmanager",
+		ClientURL: externalURL,
+	}
+	if !alert.StartsAt.IsZero() {
+		event.Payload.Timestamp = alert.StartsAt.Format(time.RFC3339)
+	}
+	if alert.GeneratorURL != "" {
+		event.Links = append(event.Links, Link{Href: alert.GeneratorURL, Text: "Open in Prometheus"})
+	}
+	return event
+}
@@ -0,0 +1,128 @@
+// Package pagerduty 提供通过 PagerDuty Events API v2 触发和解决事件的功能。
+// 告警指纹作为事件的 dedup_key，firing 告警触发事件，resolved 告警自动解决对应的事件。
+package pagerduty
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 事件的动作。
+const (
+	actionTrigger = "trigger"
+	actionResolve = "resolve"
+)
+
+// summaryLimit 事件摘要的长度限制（字符）。
+const summaryLimit = 1024
+
+// client 发送 PagerDuty 事件使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Link 事件中的链接。
+type Link struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+// Payload 触发事件的内容。
+type Payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"` // critical、error、warning 或 info
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// Event 定义了发送到 Events API v2 的事件结构，routing_key 在发送时按目标填充。
+type Event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"` // trigger 或 resolve
+	DedupKey    string   `json:"dedup_key,omitempty"`
+	Payload     *Payload `json:"payload,omitempty"` // 只有触发事件需要
+	Client      string   `json:"client,omitempty"`
+	ClientURL   string   `json:"client_url,omitempty"`
+	Links       []Link   `json:"links,omitempty"`
+}
+
+// Send 使用指定的 routing key 发送事件。
+// 限流（429）和服务端错误可以重试，其他错误（如 routing key 无效、事件格式错误）不再重试。
+func (e Event) Send(name, routingKey string) error {
+	e.RoutingKey = routingKey
+	body, _ := json.Marshal(e)
+	resp, err := client.Post(common.PagerDutyConfig.APIBase+"/v2/enqueue", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("pagerduty %s returned %s: %s", name, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// Severity 将告警级别映射为 PagerDuty 的事件级别，未知级别按 warning 处理。
+func Severity(severity string) string {
+	switch common.SeverityRank(severity) {
+	case 4:
+		return "critical"
+	case 3:
+		return "error"
+	case 1:
+		return "info"
+	default:
+		return "warning"
+	}
+}
+
+// truncate 将字符串截断为最多 n 个字符，超出部分以省略号代替。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
+
+// SendText 发送通用通知消息到指定名称的 PagerDuty 目标，供升级、报表等后台任务使用。
+// 每条消息触发一个新的事件，消息的 Severity 决定事件级别。
+func SendText(name string, msg notify.Message) error {
+	routingKey, ok := common.PagerDutyTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("pagerduty target '%s' not found in configuration", name))
+	}
+
+	summary := msg.Title
+	if summary == "" {
+		summary = strings.SplitN(msg.Text, "\n", 2)[0]
+	}
+	event := Event{
+		EventAction: actionTrigger,
+		Payload: &Payload{
+			Summary:       truncate(summary, summaryLimit),
+			Source:        "alertmanager-webhook-adapter",
+			Severity:      Severity(msg.Severity),
+			CustomDetails: map[string]interface{}{"text": msg.Text},
+		},
+		Client: "Alertmanager",
+	}
+	return event.Send(name, routingKey)
+}
@@ -0,0 +1,227 @@
+package pagerduty
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"alertmanagerWebhookAdapter/pkg/quiet"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePagerDuty 模拟 Events API v2 的 /v2/enqueue 接口，记录收到的事件。
+type fakePagerDuty struct {
+	mu     sync.Mutex
+	events []map[string]any
+	status int
+}
+
+func newFakePagerDuty(t *testing.T) *fakePagerDuty {
+	f := &fakePagerDuty{status: http.StatusAccepted}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/enqueue" {
+			http.NotFound(w, r)
+			return
+		}
+		var event map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid event: %v", err)
+		}
+		f.mu.Lock()
+		f.events = append(f.events, event)
+		status := f.status
+		f.mu.Unlock()
+
+		w.WriteHeader(status)
+		if status == http.StatusAccepted {
+			fmt.Fprint(w, `{"status":"success","message":"Event processed","dedup_key":"x"}`)
+		} else {
+			fmt.Fprint(w, `{"status":"invalid event","message":"Event object is invalid"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	common.PagerDutyConfig.APIBase = srv.URL
+	common.PagerDutyTargets = map[string]string{"ops": "routing-key"}
+	return f
+}
+
+func (f *fakePagerDuty) received() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]any(nil), f.events...)
+}
+
+// post 发送 webhook 请求到 Handler。
+func post(t *testing.T, status, alertName, fingerprint string) {
+	t.Helper()
+	endsAt := ""
+	if status == "resolved" {
+		endsAt = `,"endsAt":"2026-10-19T06:30:00Z"`
+	}
+	payload := fmt.Sprintf(`{"receiver":"pd","status":%q,"externalURL":"http://am:9093","alerts":[{
+		"status":%q,
+		"labels":{"alertname":%q,"severity":"critical","instance":"node-1","job":"node","cluster":"prod"},
+		"annotations":{"summary":"CPU usage above 90%%","trigger_logs":"x"},
+		"startsAt":"2026-10-19T06:00:00Z"%s,"generatorURL":"http://prom:9090/graph","fingerprint":%q}]}`,
+		status, status, alertName, endsAt, fingerprint)
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("POST", "/pagerduty?target=ops", strings.NewReader(payload)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
+
+func TestHandlerTriggerResolve(t *testing.T) {
+	f := newFakePagerDuty(t)
+
+	post(t, "firing", "HighCPU", "fp-trigger")
+	post(t, "resolved", "HighCPU", "fp-trigger")
+
+	events := f.received()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	trigger := events[0]
+	if trigger["routing_key"] != "routing-key" || trigger["event_action"] != "trigger" || trigger["dedup_key"] != "fp-trigger" {
+		t.Errorf("unexpected trigger event: %v", trigger)
+	}
+	payload, _ := trigger["payload"].(map[string]any)
+	if payload["summary"] != "HighCPU: CPU usage above 90%" || payload["severity"] != "critical" ||
+		payload["source"] != "node-1" || payload["component"] != "node" || payload["group"] != "prod" ||
+		payload["class"] != "HighCPU" || payload["timestamp"] != "2026-10-19T06:00:00Z" {
+		t.Errorf("unexpected trigger payload: %v", payload)
+	}
+	details, _ := payload["custom_details"].(map[string]any)
+	if annotations, _ := details["annotations"].(map[string]any); annotations["trigger_logs"] != nil {
+		t.Errorf("trigger_logs annotation should not be repeated in details: %v", annotations)
+	}
+	if links, _ := trigger["links"].([]any); len(links) != 1 || trigger["client_url"] != "http://am:9093" {
+		t.Errorf("unexpected links: %v, client_url %v", trigger["links"], trigger["client_url"])
+	}
+
+	resolve := events[1]
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != "fp-trigger" || resolve["routing_key"] != "routing-key" {
+		t.Errorf("unexpected resolve event: %v", resolve)
+	}
+	if _, ok := resolve["payload"]; ok {
+		t.Errorf("resolve event should not have a payload: %v", resolve)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for severity, want := range map[string]string{
+		"critical": "critical",
+		"CRITICAL": "critical",
+		"error":    "error",
+		"warning":  "warning",
+		"info":     "info",
+		"page":     "warning",
+		"":         "warning",
+	} {
+		if got := Severity(severity); got != want {
+			t.Errorf("Severity(%q) = %q, want %q", severity, got, want)
+		}
+	}
+}
+
+func TestSendRetry(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	for status, attempts := range map[int]int{
+		http.StatusBadRequest:          1,
+		http.StatusTooManyRequests:     3,
+		http.StatusInternalServerError: 3,
+	} {
+		f := newFakePagerDuty(t)
+		f.status = status
+		err := notify.Retry(func() error {
+			return send("ops", "routing-key", Event{EventAction: actionResolve, DedupKey: "fp"})
+		})
+		if err == nil {
+			t.Errorf("%d: expected error", status)
+		}
+		if n := len(f.received()); n != attempts {
+			t.Errorf("%d: attempts = %d, want %d", status, n, attempts)
+		}
+	}
+}
+
+func TestResolveWithoutFingerprint(t *testing.T) {
+	f := newFakePagerDuty(t)
+	if err := SendAlert("ops", common.Alert{Status: "resolved"}); err == nil {
+		t.Error("expected error for resolved alert without fingerprint")
+	}
+	if n := len(f.received()); n != 0 {
+		t.Errorf("sent %d events, want 0", n)
+	}
+}
+
+// TestSendAlert 抖动恢复和静默摘要按告警的当前状态触发或解决事件，而不是创建新的事件。
+func TestSendAlert(t *testing.T) {
+	f := newFakePagerDuty(t)
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "HighCPU", "severity": "warning"},
+		Fingerprint: "fp-replay",
+	}
+	if err := SendAlert("ops", alert); err != nil {
+		t.Fatalf("SendAlert firing: %v", err)
+	}
+	alert.Status = "resolved"
+	if err := SendAlert("ops", alert); err != nil {
+		t.Fatalf("SendAlert resolved: %v", err)
+	}
+	if err := SendAlert("missing", alert); err == nil {
+		t.Error("expected error for unknown target")
+	}
+
+	events := f.received()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0]["event_action"] != "trigger" || events[0]["dedup_key"] != "fp-replay" {
+		t.Errorf("unexpected trigger event: %v", events[0])
+	}
+	if events[1]["event_action"] != "resolve" || events[1]["dedup_key"] != "fp-replay" {
+		t.Errorf("unexpected resolve event: %v", events[1])
+	}
+}
+
+// TestHandlerResolveDuringQuietHold 静默规则暂缓 firing 告警时，resolved 告警仍然解决对应的事件。
+func TestHandlerResolveDuringQuietHold(t *testing.T) {
+	f := newFakePagerDuty(t)
+	notify.RegisterAlert("pagerduty", SendAlert)
+
+	start, end := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	config := fmt.Sprintf(`{"rules":[{"name":"maintenance","start":%q,"end":%q,
+		"matchers":{"alertname":"Maintenance"},"channels":["pagerduty"],"action":"hold"}]}`,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+	path := filepath.Join(t.TempDir(), "quiet.json")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("QUIET_CONFIG", path)
+	quiet.Init()
+
+	post(t, "firing", "Maintenance", "fp-quiet")
+	if n := len(f.received()); n != 0 {
+		t.Fatalf("firing alert during quiet hold sent %d events, want 0", n)
+	}
+
+	post(t, "resolved", "Maintenance", "fp-quiet")
+	events := f.received()
+	if len(events) != 1 || events[0]["event_action"] != "resolve" || events[0]["dedup_key"] != "fp-quiet" {
+		t.Errorf("resolved alert during quiet hold: got %v, want one resolve event", events)
+	}
+}
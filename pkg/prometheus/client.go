@@ -0,0 +1,379 @@
+// Package prometheus 提供与 Prometheus HTTP API 交互的客户端功能，
+// 用于在告警消息中补充指标的当前值和近期趋势。
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client Prometheus API 客户端。
+type Client struct {
+	URL      string        // Prometheus 服务地址，如 http://prometheus:9090
+	Username string        // Basic Auth 用户名（可选）
+	Password string        // Basic Auth 密码（可选）
+	Timeout  time.Duration // HTTP 请求超时时间
+}
+
+// Sample 单个采样点。
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series 一条时间序列，包含指标标签和采样点。
+type Series struct {
+	Metric  map[string]string
+	Samples []Sample
+}
+
+// queryResponse Prometheus /api/v1/query 与 /api/v1/query_range 的响应结构。
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// QueryInstant 在指定时间点执行即时查询，返回结果向量。
+func (c *Client) QueryInstant(ctx context.Context, query string, at time.Time) ([]Series, error) {
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("time", formatTime(at))
+
+	return c.query(ctx, "/api/v1/query", params)
+}
+
+// QueryRange 在指定时间范围内执行区间查询，返回结果矩阵。
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("start", formatTime(start))
+	params.Add("end", formatTime(end))
+	params.Add("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	return c.query(ctx, "/api/v1/query_range", params)
+}
+
+// query 调用 Prometheus 查询接口并解析结果，ctx 取消或超时时立即返回。
+func (c *Client) query(ctx context.Context, path string, params url.Values) ([]Series, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("Prometheus URL not configured")
+	}
+
+	apiURL := fmt.Sprintf("%s%s?%s", strings.TrimRight(c.URL, "/"), path, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// 添加 Basic Auth（如果配置了）
+	if c.Username != "" && c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := &http.Client{
+		Timeout: c.Timeout,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			log.Printf("failed to close response body: %v", cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Prometheus API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var queryResp queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&queryResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if queryResp.Status != "success" {
+		return nil, fmt.Errorf("Prometheus query failed: %s: %s", queryResp.ErrorType, queryResp.Error)
+	}
+
+	return parseResult(queryResp.Data.ResultType, queryResp.Data.Result)
+}
+
+// parseResult 将 vector / matrix / scalar 结果统一转换为 Series 列表。
+func parseResult(resultType string, raw json.RawMessage) ([]Series, error) {
+	switch resultType {
+	case "vector":
+		var result []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode vector: %w", err)
+		}
+		series := make([]Series, 0, len(result))
+		for _, r := range result {
+			s := Series{Metric: r.Metric}
+			if sample, ok := parseSample(r.Value); ok {
+				s.Samples = append(s.Samples, sample)
+			}
+			series = append(series, s)
+		}
+		return series, nil
+	case "matrix":
+		var result []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][]interface{}   `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode matrix: %w", err)
+		}
+		series := make([]Series, 0, len(result))
+		for _, r := range result {
+			s := Series{Metric: r.Metric}
+			for _, v := range r.Values {
+				if sample, ok := parseSample(v); ok {
+					s.Samples = append(s.Samples, sample)
+				}
+			}
+			series = append(series, s)
+		}
+		return series, nil
+	case "scalar":
+		var value []interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("failed to decode scalar: %w", err)
+		}
+		s := Series{Metric: map[string]string{}}
+		if sample, ok := parseSample(value); ok {
+			s.Samples = append(s.Samples, sample)
+		}
+		return []Series{s}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q", resultType)
+	}
+}
+
+// parseSample 解析 [timestamp, "value"] 形式的采样点。
+// NaN 和 ±Inf 无法参与比较和绘图，作为无效采样点丢弃。
+func parseSample(pair []interface{}) (Sample, bool) {
+	if len(pair) < 2 {
+		return Sample{}, false
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return Sample{}, false
+	}
+	str, ok := pair[1].(string)
+	if !ok {
+		return Sample{}, false
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return Sample{}, false
+	}
+	sec, frac := math.Modf(ts)
+	return Sample{Time: time.Unix(int64(sec), int64(frac*1e9)), Value: val}, true
+}
+
+// formatTime 将时间格式化为 Prometheus API 接受的 Unix 秒数。
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+}
+
+// ExprFromGeneratorURL 从告警的 GeneratorURL 中提取 PromQL 表达式（g0.expr 参数）。
+// 无法解析时返回空字符串。
+func ExprFromGeneratorURL(generatorURL string) string {
+	if generatorURL == "" {
+		return ""
+	}
+	u, err := url.Parse(generatorURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(u.Query().Get("g0.expr"))
+}
+
+// MatchSeries 从查询结果中挑选与告警标签最匹配的序列。
+// 序列的所有标签都必须与告警标签一致（忽略 __name__），匹配标签最多的优先；
+// 若没有完全匹配的序列且结果只有一条，则直接返回该序列。
+func MatchSeries(series []Series, labels map[string]string) (Series, bool) {
+	best, bestScore := -1, -1
+	for i, s := range series {
+		score, ok := 0, true
+		for k, v := range s.Metric {
+			if k == "__name__" {
+				continue
+			}
+			if labels[k] != v {
+				ok = false
+				break
+			}
+			score++
+		}
+		if ok && score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best >= 0 {
+		return series[best], true
+	}
+	if len(series) == 1 {
+		return series[0], true
+	}
+	return Series{}, false
+}
+
+// Trend 告警指标在触发时刻的值以及近期走势。
+type Trend struct {
+	Query      string        // 实际执行的 PromQL
+	Range      time.Duration // 趋势的时间范围
+	StartValue float64       // 告警触发时（StartsAt）的值
+	HasStart   bool          // StartValue 是否有效
+	Samples    []Sample      // 趋势区间内的采样点，按时间升序
+}
+
+// Current 返回趋势区间内最新的采样值。
+func (t *Trend) Current() (float64, bool) {
+	if t == nil || len(t.Samples) == 0 {
+		return 0, false
+	}
+	return t.Samples[len(t.Samples)-1].Value, true
+}
+
+// AlertTrend 在告警触发时刻重新执行查询，并获取截至 end 的 rangeDur 时间内的走势。
+// labels 用于在多序列结果中挑选与告警对应的序列。
+func (c *Client) AlertTrend(ctx context.Context, query string, labels map[string]string, startsAt, end time.Time,
+	rangeDur, step time.Duration,
+) (*Trend, error) {
+	trend := &Trend{Query: query, Range: rangeDur}
+
+	if !startsAt.IsZero() {
+		series, err := c.QueryInstant(ctx, query, startsAt)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := MatchSeries(series, labels); ok && len(s.Samples) > 0 {
+			trend.StartValue = s.Samples[0].Value
+			trend.HasStart = true
+		}
+	}
+
+	series, err := c.QueryRange(ctx, query, end.Add(-rangeDur), end, step)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := MatchSeries(series, labels); ok {
+		trend.Samples = s.Samples
+		sort.Slice(trend.Samples, func(i, j int) bool {
+			return trend.Samples[i].Time.Before(trend.Samples[j].Time)
+		})
+	}
+
+	return trend, nil
+}
+
+// sparkTicks 用于绘制文本迷你走势图的字符。
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// String 将趋势格式化为单行文本，例如：
+// now=12.3 | at_start=10.1 | 30m: ↑ +21.8% (min=8, max=12.3) ▁▂▃▅▇。
+func (t *Trend) String() string {
+	if t == nil {
+		return ""
+	}
+
+	current, ok := t.Current()
+	if !ok && !t.HasStart {
+		return "（指标查询无数据）"
+	}
+
+	parts := make([]string, 0, 3)
+	if ok {
+		parts = append(parts, "now="+FormatValue(current))
+	}
+	if t.HasStart {
+		parts = append(parts, "at_start="+FormatValue(t.StartValue))
+	}
+
+	if len(t.Samples) >= 2 {
+		first := t.Samples[0].Value
+		minV, maxV := first, first
+		for _, s := range t.Samples {
+			minV = math.Min(minV, s.Value)
+			maxV = math.Max(maxV, s.Value)
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s (min=%s, max=%s) %s",
+			formatRange(t.Range), trendChange(first, current),
+			FormatValue(minV), FormatValue(maxV), sparkline(t.Samples, minV, maxV)))
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// trendChange 描述从 first 到 last 的变化方向和幅度。
+func trendChange(first, last float64) string {
+	arrow := "→"
+	switch {
+	case last > first:
+		arrow = "↑"
+	case last < first:
+		arrow = "↓"
+	}
+	if first == 0 {
+		return fmt.Sprintf("%s %s", arrow, FormatValue(last-first))
+	}
+	return fmt.Sprintf("%s %+.1f%%", arrow, (last-first)/math.Abs(first)*100)
+}
+
+// sparkline 将采样点压缩为最多 20 个字符的迷你走势图。
+func sparkline(samples []Sample, minV, maxV float64) string {
+	const width = 20
+	step := 1
+	if len(samples) > width {
+		step = (len(samples) + width - 1) / width
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(samples); i += step {
+		idx := 0
+		if maxV > minV {
+			idx = int((samples[i].Value - minV) / (maxV - minV) * float64(len(sparkTicks)-1))
+		}
+		idx = max(0, min(idx, len(sparkTicks)-1))
+		builder.WriteRune(sparkTicks[idx])
+	}
+	return builder.String()
+}
+
+// formatRange 将时间范围格式化为紧凑形式，如 30m、2h。
+func formatRange(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+// FormatValue 将指标值格式化为易读的字符串，最多保留三位小数。
+func FormatValue(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
@@ -0,0 +1,211 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePrometheus 返回一个模拟 Prometheus HTTP API 的测试服务器，按路径返回固定的响应。
+func fakePrometheus(t *testing.T, responses map[string]string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "" {
+			http.Error(w, "missing query", http.StatusBadRequest)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{URL: srv.URL, Timeout: 5 * time.Second}
+}
+
+func TestQueryInstant(t *testing.T) {
+	c := fakePrometheus(t, map[string]string{
+		"/api/v1/query": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"instance":"a"},"value":[1700000000.5,"12.5"]},
+			{"metric":{"instance":"b"},"value":[1700000000,"NaN"]}]}}`,
+	})
+
+	series, err := c.QueryInstant(context.Background(), "up", time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("QueryInstant: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want 2", len(series))
+	}
+	if len(series[0].Samples) != 1 || series[0].Samples[0].Value != 12.5 {
+		t.Errorf("series a samples = %v, want [12.5]", series[0].Samples)
+	}
+	if want := time.Unix(1700000000, 5e8); !series[0].Samples[0].Time.Equal(want) {
+		t.Errorf("sample time = %v, want %v", series[0].Samples[0].Time, want)
+	}
+	if len(series[1].Samples) != 0 {
+		t.Errorf("NaN sample should be dropped, got %v", series[1].Samples)
+	}
+}
+
+func TestQueryRangeDropsNonFinite(t *testing.T) {
+	c := fakePrometheus(t, map[string]string{
+		"/api/v1/query_range": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"instance":"a"},"values":[[1,"1"],[2,"+Inf"],[3,"2"],[4,"-Inf"],[5,"NaN"],[6,"3"]]}]}}`,
+	})
+
+	series, err := c.QueryRange(context.Background(), "up", time.Unix(0, 0), time.Unix(10, 0), time.Second)
+	if err != nil {
+		t.Fatalf("QueryRange: %v", err)
+	}
+	var got []float64
+	for _, s := range series[0].Samples {
+		got = append(got, s.Value)
+	}
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("values = %v, want [1 2 3]", got)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	c := fakePrometheus(t, map[string]string{
+		"/api/v1/query": `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+	})
+	if _, err := c.QueryInstant(context.Background(), "up{", time.Now()); err == nil || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("error = %v, want parse error", err)
+	}
+	if _, err := c.QueryRange(context.Background(), "up", time.Now(), time.Now(), time.Second); err == nil {
+		t.Error("expected error for HTTP 404")
+	}
+	if _, err := (&Client{}).QueryInstant(context.Background(), "up", time.Now()); err == nil {
+		t.Error("expected error without URL")
+	}
+}
+
+func TestAlertTrend(t *testing.T) {
+	c := fakePrometheus(t, map[string]string{
+		"/api/v1/query": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"instance":"a"},"value":[100,"10"]},
+			{"metric":{"instance":"b"},"value":[100,"99"]}]}}`,
+		"/api/v1/query_range": `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"instance":"b"},"values":[[100,"1"]]},
+			{"metric":{"instance":"a"},"values":[[300,"12"],[100,"8"],[200,"+Inf"]]}]}}`,
+	})
+
+	trend, err := c.AlertTrend(context.Background(), "up", map[string]string{"instance": "a", "job": "node"},
+		time.Unix(100, 0), time.Unix(300, 0), 30*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("AlertTrend: %v", err)
+	}
+	if !trend.HasStart || trend.StartValue != 10 {
+		t.Errorf("start = %v/%v, want 10", trend.StartValue, trend.HasStart)
+	}
+	if len(trend.Samples) != 2 || trend.Samples[0].Value != 8 || trend.Samples[1].Value != 12 {
+		t.Errorf("samples = %v, want sorted [8 12]", trend.Samples)
+	}
+	want := "now=12 | at_start=10 | 30m: ↑ +50.0% (min=8, max=12) ▁█"
+	if got := trend.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestTrendString(t *testing.T) {
+	samples := func(values ...float64) []Sample {
+		s := make([]Sample, len(values))
+		for i, v := range values {
+			s[i] = Sample{Time: time.Unix(int64(i), 0), Value: v}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		trend *Trend
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"no data", &Trend{Range: time.Hour}, "（指标查询无数据）"},
+		{"start only", &Trend{Range: time.Hour, StartValue: 1.5, HasStart: true}, "at_start=1.5"},
+		{"flat", &Trend{Range: time.Hour, Samples: samples(2, 2, 2)}, "now=2 | 1h: → +0.0% (min=2, max=2) ▁▁▁"},
+		{"from zero", &Trend{Range: 90 * time.Minute, Samples: samples(0, 4)}, "now=4 | 90m: ↑ 4 (min=0, max=4) ▁█"},
+		{"falling", &Trend{Range: time.Hour, Samples: samples(10, 5)}, "now=5 | 1h: ↓ -50.0% (min=5, max=10) █▁"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trend.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrendStringNonFinite 构造的趋势中包含非有限值时不能 panic。
+func TestTrendStringNonFinite(t *testing.T) {
+	for _, values := range [][]float64{
+		{1, math.Inf(1), 2},
+		{1, math.NaN(), 2},
+		{math.Inf(-1), 1},
+	} {
+		trend := &Trend{Range: time.Hour}
+		for i, v := range values {
+			trend.Samples = append(trend.Samples, Sample{Time: time.Unix(int64(i), 0), Value: v})
+		}
+		if got := trend.String(); got == "" {
+			t.Errorf("String() for %v is empty", values)
+		}
+	}
+}
+
+func TestSparklineWidth(t *testing.T) {
+	var samples []Sample
+	for i := 0; i < 100; i++ {
+		samples = append(samples, Sample{Value: float64(i)})
+	}
+	got := []rune(sparkline(samples, 0, 99))
+	if len(got) != 20 {
+		t.Errorf("sparkline width = %d, want 20", len(got))
+	}
+	if got[0] != '▁' {
+		t.Errorf("sparkline = %q, want starting with ▁", string(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] < got[i-1] {
+			t.Errorf("sparkline = %q, want non-decreasing", string(got))
+			break
+		}
+	}
+}
+
+// TestAlertTrendContextTimeout Prometheus 响应缓慢时，趋势查询在 ctx 超时后立即返回。
+func TestAlertTrendContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	c := &Client{URL: srv.URL, Timeout: 10 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.AlertTrend(ctx, "up", nil, time.Now().Add(-time.Minute), time.Now(), 30*time.Minute, time.Minute)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("AlertTrend took %v, want it to stop at the context deadline", elapsed)
+	}
+}
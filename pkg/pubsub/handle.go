@@ -0,0 +1,79 @@
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// handle 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将每个告警发布到指定渠道的目标。
+// 如果请求中包含 target 参数，则只发布到指定的目标；
+// 如果没有指定，则默认发布到渠道中所有已配置的目标。
+func handle(w http.ResponseWriter, r *http.Request, channel string, configured map[string]common.PublishTarget,
+	publish func(name string, target common.PublishTarget, data TemplateData) error) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发布到指定目标，否则发布到所有配置的目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), configured)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Printf("⚠️ No valid %s targets configured", channel)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, channel, targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		data := newTemplateData(common.NewAlertEvent(payload, alert, flap.Flapping))
+
+		// 发布到所有目标
+		for name, target := range sendTargets {
+			targetData := data
+			targetData.Target = name
+			_ = delivery.Send(alert, channel, name, func() error {
+				return publish(name, target, targetData)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// textData 返回通用通知消息的模板数据，没有告警信息。
+func textData(name string, msg notify.Message) TemplateData {
+	return TemplateData{Title: msg.Title, Text: msg.Text, Severity: msg.Severity, Target: name}
+}
@@ -0,0 +1,118 @@
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttClient MQTT 客户端，未配置 MQTT 目标时为 nil。
+var mqttClient mqtt.Client
+
+// mqttTopicLimit MQTT topic 的最大长度（字节）。
+const mqttTopicLimit = 65535
+
+// mqttToken 替换标签值中 MQTT topic 的分隔符（/）和通配符（+ 和 #）。
+var mqttToken = strings.NewReplacer("/", "_", "+", "_", "#", "_", "\x00", "").Replace
+
+// initMQTT 连接 MQTT broker。首次连接失败和连接断开后都会在后台自动重连，
+// 重连期间不发布消息，由重试策略在连接恢复后重新发布。
+func initMQTT() {
+	cfg := common.MQTTConfig
+	opts := mqtt.NewClientOptions().
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectTimeout(cfg.Timeout).
+		SetWriteTimeout(cfg.Timeout).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(time.Minute).
+		SetOnConnectHandler(func(mqtt.Client) {
+			log.Printf("✅ MQTT connected to %s", cfg.Broker)
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("⚠️ MQTT connection lost: %v", err)
+		})
+	for _, broker := range strings.Split(cfg.Broker, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			opts.AddBroker(broker)
+		}
+	}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			log.Printf("❌ Invalid MQTT TLS config, mqtt disabled: %v", err)
+			return
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	mqttClient = mqtt.NewClient(opts)
+	// 开启连接重试后，Connect 在连接成功前不会完成，连接结果由 OnConnect 回调记录
+	mqttClient.Connect()
+}
+
+// validTopic 检查 topic：不能为空，不能包含通配符，长度不能超过限制。
+func validTopic(topic string) bool {
+	return topic != "" && len(topic) <= mqttTopicLimit && !strings.ContainsAny(topic, "+#\x00")
+}
+
+// publishMQTT 发布消息到 MQTT 目标。QoS 1 和 2 等待 broker 的确认（PUBACK/PUBCOMP），QoS 0 只等待消息写出。
+// 连接断开时不发布，避免消息进入客户端缓冲区，在重连后与重试的消息重复发布。
+// topic 无效时不再重试，连接断开和等待确认超时可以重试。
+func publishMQTT(name string, target common.PublishTarget, data TemplateData) error {
+	if mqttClient == nil {
+		return notify.Permanent(fmt.Errorf("mqtt target '%s': mqtt is not configured", name))
+	}
+
+	topic, err := renderSubject("mqtt", name, target, data, mqttToken)
+	if err != nil {
+		return notify.Permanent(err)
+	}
+	if !validTopic(topic) {
+		return notify.Permanent(fmt.Errorf("mqtt target '%s' rendered an invalid topic %q", name, topic))
+	}
+	body, err := renderBody("mqtt", name, target, data)
+	if err != nil {
+		return notify.Permanent(err)
+	}
+
+	qos := common.MQTTConfig.QoS
+	if target.QoS != nil {
+		qos = *target.QoS
+	}
+	if !mqttClient.IsConnectionOpen() {
+		return fmt.Errorf("mqtt target '%s': mqtt is not connected", name)
+	}
+	token := mqttClient.Publish(topic, byte(qos), target.Retain, body)
+	if !token.WaitTimeout(common.MQTTConfig.Timeout) {
+		return fmt.Errorf("timeout waiting for mqtt broker to acknowledge %s for %s (qos %d)", topic, name, qos)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("failed to publish to mqtt topic %s for %s: %w", topic, name, err)
+	}
+	return nil
+}
+
+// MQTTHandler 处理来自 Alertmanager 的 webhook 请求，将每个告警发布到 MQTT 目标。
+func MQTTHandler(w http.ResponseWriter, r *http.Request) {
+	handle(w, r, "mqtt", common.MQTTTargets, publishMQTT)
+}
+
+// SendMQTTText 发布通用通知消息到指定名称的 MQTT 目标，供升级、报表等后台任务使用。
+// topic 模板中引用的标签渲染为空字符串。
+func SendMQTTText(name string, msg notify.Message) error {
+	target, ok := common.MQTTTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("mqtt target '%s' not found in configuration", name))
+	}
+	return publishMQTT(name, target, textData(name, msg))
+}
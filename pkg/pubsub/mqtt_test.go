@@ -0,0 +1,141 @@
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// received 记录 broker 上订阅收到的消息。
+type received struct {
+	mu     sync.Mutex
+	topics []string
+	bodies []string
+}
+
+func (r *received) add(topic string, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics = append(r.topics, topic)
+	r.bodies = append(r.bodies, string(body))
+}
+
+func (r *received) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.topics)
+}
+
+// startMQTT 在进程内启动 MQTT broker，address 为空时使用随机端口；收到的 alerts/# 消息记录到 r。
+func startMQTT(t *testing.T, address string, r *received) *mochi.Server {
+	t.Helper()
+	if address == "" {
+		address = "127.0.0.1:0"
+	}
+	broker := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := broker.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("failed to add auth hook: %v", err)
+	}
+	if err := broker.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: address})); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if err := broker.Serve(); err != nil {
+		t.Fatalf("failed to start mqtt broker: %v", err)
+	}
+	err := broker.Subscribe("alerts/#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		r.add(pk.TopicName, pk.Payload)
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	return broker
+}
+
+// brokerAddress 返回 broker 监听的地址。
+func brokerAddress(broker *mochi.Server) string {
+	l, _ := broker.Listeners.Get("tcp")
+	return l.Address()
+}
+
+// setupMQTT 启动 MQTT broker 并按测试配置连接，返回 broker 的地址和停止 broker 的函数。
+func setupMQTT(t *testing.T, topic string, r *received) (string, func()) {
+	t.Helper()
+	broker := startMQTT(t, "", r)
+	var once sync.Once
+	stop := func() { once.Do(func() { broker.Close() }) }
+	t.Cleanup(stop)
+
+	common.MQTTConfig.Broker = "tcp://" + brokerAddress(broker)
+	common.MQTTConfig.ClientID = "adapter-test"
+	common.MQTTConfig.QoS = 1
+	common.MQTTConfig.Timeout = 2 * time.Second
+	common.MQTTTargets = map[string]common.PublishTarget{"edge": {Subject: topic}}
+	resetTemplates()
+	initMQTT()
+	t.Cleanup(func() {
+		mqttClient.Disconnect(0)
+		mqttClient = nil
+	})
+	waitFor(t, "mqtt connect", mqttClient.IsConnectionOpen)
+	return brokerAddress(broker), stop
+}
+
+func TestPublishMQTT(t *testing.T) {
+	r := &received{}
+	setupMQTT(t, "alerts/{{ .Labels.cluster }}/{{ .Labels.severity }}", r)
+
+	event := testEvent()
+	event.Labels["cluster"] = "eu/west"
+	if err := publishMQTT("edge", common.MQTTTargets["edge"], newTemplateData(event)); err != nil {
+		t.Fatalf("publishMQTT: %v", err)
+	}
+	waitFor(t, "mqtt message", func() bool { return r.count() == 1 })
+	// 标签值中的 / 被替换，不会改变 topic 的层级
+	if r.topics[0] != "alerts/eu_west/critical" {
+		t.Errorf("topic = %q, want alerts/eu_west/critical", r.topics[0])
+	}
+	if !strings.Contains(r.bodies[0], `"fingerprint":"abc123"`) {
+		t.Errorf("unexpected body: %s", r.bodies[0])
+	}
+}
+
+// TestPublishMQTTReconnect broker 重启期间发布直接失败，不进入客户端缓冲区；
+// 重连后重新发布，订阅方只收到一条消息。
+func TestPublishMQTTReconnect(t *testing.T) {
+	r := &received{}
+	address, stop := setupMQTT(t, "alerts/{{ .Labels.severity }}", r)
+
+	stop()
+	waitFor(t, "mqtt disconnect", func() bool { return !mqttClient.IsConnectionOpen() })
+
+	data := newTemplateData(testEvent())
+	err := publishMQTT("edge", common.MQTTTargets["edge"], data)
+	if err == nil || !strings.Contains(err.Error(), "not connected") {
+		t.Fatalf("publish while disconnected = %v, want not connected error", err)
+	}
+
+	restarted := startMQTT(t, address, r)
+	t.Cleanup(func() { restarted.Close() })
+	waitFor(t, "mqtt reconnect", mqttClient.IsConnectionOpen)
+
+	if err := publishMQTT("edge", common.MQTTTargets["edge"], data); err != nil {
+		t.Fatalf("publish after reconnect: %v", err)
+	}
+	waitFor(t, "mqtt message", func() bool { return r.count() >= 1 })
+	time.Sleep(500 * time.Millisecond)
+	if n := r.count(); n != 1 {
+		t.Errorf("got %d messages, want 1", n)
+	}
+}
@@ -0,0 +1,146 @@
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var (
+	// natsConn NATS 连接，未配置 NATS 目标或连接失败时为 nil
+	natsConn *nats.Conn
+	// natsJS JetStream 上下文，NATS_JETSTREAM=true 时使用
+	natsJS jetstream.JetStream
+)
+
+// natsToken 替换标签值中 NATS subject 的分隔符（.）、通配符（* 和 >）以及空白字符。
+var natsToken = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "\t", "_", "\n", "_", "\r", "_").Replace
+
+// initNATS 连接 NATS 服务器。连接断开后无限重连；重连期间不缓冲消息，发布直接返回错误并按重试策略重试，
+// 避免缓冲区中的消息在重连后与重试的消息重复发布。
+func initNATS() {
+	cfg := common.NATSConfig
+	opts := []nats.Option{
+		nats.Name("alertmanager-webhook-adapter"),
+		nats.Timeout(cfg.Timeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2 * time.Second),
+		nats.ReconnectBufSize(-1),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Printf("⚠️ NATS disconnected: %v", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Printf("✅ NATS reconnected to %s", nc.ConnectedUrlRedacted())
+		}),
+	}
+	if cfg.User != "" {
+		opts = append(opts, nats.UserInfo(cfg.User, cfg.Password))
+	}
+	if cfg.Token != "" {
+		opts = append(opts, nats.Token(cfg.Token))
+	}
+	if cfg.CredsFile != "" {
+		opts = append(opts, nats.UserCredentials(cfg.CredsFile))
+	}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			log.Printf("❌ Invalid NATS TLS config, nats disabled: %v", err)
+			return
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	nc, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		log.Printf("❌ Failed to connect to NATS, nats disabled: %v", err)
+		return
+	}
+	if cfg.JetStream {
+		js, err := jetstream.New(nc)
+		if err != nil {
+			log.Printf("❌ Failed to create JetStream context, nats disabled: %v", err)
+			nc.Close()
+			return
+		}
+		natsJS = js
+	}
+	natsConn = nc
+}
+
+// validSubject 检查 subject：不能为空，各级不能为空，不能包含空白字符和通配符。
+func validSubject(subject string) bool {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return false
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// publishNATS 发布消息到 NATS 目标，并等待服务器确认：
+// 使用 JetStream 时等待 stream 的确认，否则通过 flush 等待服务器收到消息。
+// subject 无效、消息超过服务器的大小限制时不再重试。
+func publishNATS(name string, target common.PublishTarget, data TemplateData) error {
+	if natsConn == nil {
+		return notify.Permanent(fmt.Errorf("nats target '%s': nats is not connected", name))
+	}
+
+	subject, err := renderSubject("nats", name, target, data, natsToken)
+	if err != nil {
+		return notify.Permanent(err)
+	}
+	if !validSubject(subject) {
+		return notify.Permanent(fmt.Errorf("nats target '%s' rendered an invalid subject %q", name, subject))
+	}
+	body, err := renderBody("nats", name, target, data)
+	if err != nil {
+		return notify.Permanent(err)
+	}
+
+	timeout := common.NATSConfig.Timeout
+	if natsJS != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_, err = natsJS.Publish(ctx, subject, body)
+	} else if err = natsConn.Publish(subject, body); err == nil {
+		err = natsConn.FlushTimeout(timeout)
+	}
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("failed to publish to nats subject %s for %s: %w", subject, name, err)
+	if errors.Is(err, nats.ErrBadSubject) || errors.Is(err, nats.ErrMaxPayload) {
+		return notify.Permanent(err)
+	}
+	return err
+}
+
+// NATSHandler 处理来自 Alertmanager 的 webhook 请求，将每个告警发布到 NATS 目标。
+func NATSHandler(w http.ResponseWriter, r *http.Request) {
+	handle(w, r, "nats", common.NATSTargets, publishNATS)
+}
+
+// SendNATSText 发布通用通知消息到指定名称的 NATS 目标，供升级、报表等后台任务使用。
+// subject 模板中引用的标签渲染为空字符串。
+func SendNATSText(name string, msg notify.Message) error {
+	target, ok := common.NATSTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("nats target '%s' not found in configuration", name))
+	}
+	return publishNATS(name, target, textData(name, msg))
+}
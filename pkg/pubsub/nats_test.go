@@ -0,0 +1,168 @@
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// startNATS 在进程内启动 NATS 服务器，port 为 0 时使用随机端口。
+func startNATS(t *testing.T, port int) *server.Server {
+	t.Helper()
+	if port == 0 {
+		port = server.RANDOM_PORT
+	}
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("failed to create nats server: %v", err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	return ns
+}
+
+// setupNATS 启动 NATS 服务器并按测试配置连接，返回服务器和用于接收消息的订阅。
+func setupNATS(t *testing.T, subject string) (*server.Server, *nats.Subscription) {
+	t.Helper()
+	ns := startNATS(t, 0)
+	t.Cleanup(func() { ns.Shutdown() })
+
+	common.NATSConfig.URL = ns.ClientURL()
+	common.NATSConfig.Timeout = 2 * time.Second
+	common.NATSTargets = map[string]common.PublishTarget{"edge": {Subject: subject}}
+	resetTemplates()
+	initNATS()
+	if natsConn == nil {
+		t.Fatal("nats is not connected")
+	}
+	t.Cleanup(func() {
+		natsConn.Close()
+		natsConn, natsJS = nil, nil
+	})
+
+	sub, err := nats.Connect(ns.ClientURL(), nats.MaxReconnects(-1), nats.ReconnectWait(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to connect subscriber: %v", err)
+	}
+	t.Cleanup(sub.Close)
+	s, err := sub.SubscribeSync("alerts.>")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if err := sub.Flush(); err != nil {
+		t.Fatalf("failed to flush subscriber: %v", err)
+	}
+	return ns, s
+}
+
+func testEvent() common.AlertEvent {
+	return common.AlertEvent{
+		Alert: common.Alert{
+			Status:      "firing",
+			Labels:      map[string]string{"alertname": "HighCPU", "cluster": "eu.west", "severity": "critical"},
+			Fingerprint: "abc123",
+		},
+		Receiver: "edge",
+	}
+}
+
+func TestPublishNATS(t *testing.T) {
+	_, sub := setupNATS(t, "alerts.{{ .Labels.cluster }}.{{ .Labels.severity }}")
+
+	if err := publishNATS("edge", common.NATSTargets["edge"], newTemplateData(testEvent())); err != nil {
+		t.Fatalf("publishNATS: %v", err)
+	}
+	msg, err := sub.NextMsg(2 * time.Second)
+	if err != nil {
+		t.Fatalf("no message received: %v", err)
+	}
+	// 标签值中的 . 被替换，不会改变 subject 的层级
+	if msg.Subject != "alerts.eu_west.critical" {
+		t.Errorf("subject = %q, want alerts.eu_west.critical", msg.Subject)
+	}
+	var event common.AlertEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		t.Fatalf("invalid message body: %v", err)
+	}
+	if event.Fingerprint != "abc123" || event.Labels["cluster"] != "eu.west" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestPublishNATSInvalidSubject(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+	setupNATS(t, "alerts.{{ .Labels.missing }}")
+
+	attempts := 0
+	err := notify.Retry(func() error {
+		attempts++
+		return publishNATS("edge", common.NATSTargets["edge"], newTemplateData(testEvent()))
+	})
+	if err == nil {
+		t.Fatal("expected error for empty subject token")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+// TestPublishNATSReconnect 服务器重启期间发布直接失败，不写入客户端缓冲区；
+// 重连后重新发布，订阅方只收到一条消息。
+func TestPublishNATSReconnect(t *testing.T) {
+	ns, sub := setupNATS(t, "alerts.{{ .Labels.severity }}")
+	port := ns.Addr().(*net.TCPAddr).Port
+
+	ns.Shutdown()
+	waitFor(t, "nats disconnect", func() bool { return !natsConn.IsConnected() })
+
+	data := newTemplateData(testEvent())
+	err := publishNATS("edge", common.NATSTargets["edge"], data)
+	if !errors.Is(err, nats.ErrReconnectBufExceeded) {
+		t.Fatalf("publish while reconnecting = %v, want %v", err, nats.ErrReconnectBufExceeded)
+	}
+
+	restarted := startNATS(t, port)
+	t.Cleanup(func() { restarted.Shutdown() })
+	waitFor(t, "nats reconnect", func() bool { return natsConn.IsConnected() })
+
+	if err := publishNATS("edge", common.NATSTargets["edge"], data); err != nil {
+		t.Fatalf("publish after reconnect: %v", err)
+	}
+	if _, err := sub.NextMsg(5 * time.Second); err != nil {
+		t.Fatalf("no message received after reconnect: %v", err)
+	}
+	if msg, err := sub.NextMsg(500 * time.Millisecond); err == nil {
+		t.Errorf("got duplicate message on %s", msg.Subject)
+	}
+}
+
+// resetTemplates 清除已解析的模板，各测试使用不同的 subject/topic 模板。
+func resetTemplates() {
+	templates.Range(func(key, _ any) bool {
+		templates.Delete(key)
+		return true
+	})
+}
+
+// waitFor 等待条件成立，超时后测试失败。
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,156 @@
+// Package pubsub 提供将告警发布到 NATS subject 和 MQTT topic 的功能，用于边缘站点等使用消息系统的场景。
+// subject/topic 可以由告警标签生成（如 alerts.<cluster>.<severity>），
+// 消息内容为 JSON 格式的告警事件或自定义模板。连接断开后自动重连。
+package pubsub
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// TemplateData subject/topic 模板和消息内容模板的数据。
+type TemplateData struct {
+	common.AlertEvent
+	Title    string // 消息标题，如 [FIRING] HighCPU
+	Text     string // 纯文本格式的消息内容
+	Severity string // 告警级别
+	Target   string // 目标名称
+}
+
+// newTemplateData 从告警事件创建模板数据。
+func newTemplateData(event common.AlertEvent) TemplateData {
+	alertName := event.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	data := TemplateData{
+		AlertEvent: event,
+		Title:      fmt.Sprintf("[%s] %s", strings.ToUpper(event.Status), alertName),
+		Severity:   event.Labels["severity"],
+	}
+	if event.Flapping {
+		data.Title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(event.Status), alertName)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s\n", data.Title)
+	if data.Severity != "" {
+		fmt.Fprintf(&builder, "Severity: %s\n", data.Severity)
+	}
+	if summary := event.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&builder, "Summary: %s\n", summary)
+	}
+	if desc := event.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&builder, "Description: %s\n", desc)
+	}
+	data.Text = strings.TrimSuffix(builder.String(), "\n")
+	return data
+}
+
+// funcs 模板可以使用的函数。
+var funcs = template.FuncMap{
+	// json 将值编码为 JSON，字符串会带上引号并转义，可以直接嵌入 JSON 消息内容
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"join":      strings.Join,
+	"replace":   strings.ReplaceAll,
+	"trimSpace": strings.TrimSpace,
+	"time": func(t time.Time, layout string) string {
+		return t.Local().Format(layout)
+	},
+	"default": func(def, value string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	},
+}
+
+// templates 已解析的模板，key 为 <channel>/<目标名称>/<subject 或 body>。
+var templates sync.Map
+
+// parse 返回已解析的模板，引用不存在的标签时渲染为空字符串。
+func parse(key, text string) (*template.Template, error) {
+	if tmpl, ok := templates.Load(key); ok {
+		return tmpl.(*template.Template), nil
+	}
+	tmpl, err := template.New(key).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	templates.Store(key, tmpl)
+	return tmpl, nil
+}
+
+// renderSubject 渲染目标的 subject/topic。标签值先经过 token 处理，
+// 替换其中的层级分隔符和通配符，避免标签值改变 subject/topic 的层级结构。
+func renderSubject(channel, name string, target common.PublishTarget, data TemplateData, token func(string) string) (string, error) {
+	tmpl, err := parse(channel+"/"+name+"/subject", target.Subject)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse subject template of %s target '%s': %w", channel, name, err)
+	}
+
+	data.Labels = tokens(data.Labels, token)
+	data.GroupLabels = tokens(data.GroupLabels, token)
+	data.CommonLabels = tokens(data.CommonLabels, token)
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render subject template of %s target '%s': %w", channel, name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// tokens 返回标签值经过 token 处理后的副本。
+func tokens(labels map[string]string, token func(string) string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		result[k] = token(v)
+	}
+	return result
+}
+
+// renderBody 渲染消息内容。未配置模板时：有告警信息则发送 JSON 格式的告警事件，否则发送标题和正文。
+func renderBody(channel, name string, target common.PublishTarget, data TemplateData) ([]byte, error) {
+	if target.Body == "" {
+		if len(data.Labels) > 0 {
+			return json.Marshal(data.AlertEvent)
+		}
+		return json.Marshal(map[string]string{
+			"title":    data.Title,
+			"text":     data.Text,
+			"severity": data.Severity,
+		})
+	}
+
+	tmpl, err := parse(channel+"/"+name+"/body", target.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse body template of %s target '%s': %w", channel, name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render body template of %s target '%s': %w", channel, name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Init 连接已配置的 NATS 服务器和 MQTT broker。服务器暂时不可用时在后台自动重连，期间的发布按重试策略重试。
+func Init() {
+	if len(common.NATSTargets) > 0 && common.NATSConfig.URL != "" {
+		initNATS()
+	}
+	if len(common.MQTTTargets) > 0 && common.MQTTConfig.Broker != "" {
+		initMQTT()
+	}
+}
@@ -0,0 +1,543 @@
+// Package quiet 提供静默时段和维护窗口功能：在指定时间内按告警标签暂缓或改道发送通知，
+// 例如夜间只将非 critical 告警发送到 syslog、周末不发送 env=staging 的告警。
+// 暂缓发送的通知可以在时间窗口结束后以摘要的形式统一发送。
+package quiet
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/cron"
+	"alertmanagerWebhookAdapter/pkg/dedup"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/metrics"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 规则动作。
+const (
+	ActionHold     = "hold"     // 暂缓发送
+	ActionRedirect = "redirect" // 改为发送到其他目标
+)
+
+// maxCronDuration cron 时间窗口的最大时长。
+const maxCronDuration = 7 * 24 * time.Hour
+
+// TimeRange 每周重复的时间段，结束时间早于开始时间时表示跨天（如 22:00-08:00）。
+type TimeRange struct {
+	Weekdays []string `json:"weekdays"` // 生效的星期（以开始时间所在日计算），如 ["mon-fri"]、["sat", "sun"]，为空时每天生效
+	Start    string   `json:"start"`    // 开始时间，如 22:00
+	End      string   `json:"end"`      // 结束时间，如 08:00
+
+	weekdays   [7]bool
+	start, end time.Duration // 距当天 00:00 的时长
+	everyDay   bool
+}
+
+// Rule 静默时段或维护窗口规则。
+type Rule struct {
+	Name       string            `json:"name"`
+	Timezone   string            `json:"timezone"`    // 时间使用的时区，默认本地时区
+	TimeRanges []TimeRange       `json:"time_ranges"` // 每周重复的时间段
+	Cron       string            `json:"cron"`        // 时间窗口的开始时间（5 段 cron 表达式）
+	Duration   string            `json:"duration"`    // cron 时间窗口的时长，如 2h
+	Start      *time.Time        `json:"start"`       // 一次性维护窗口的开始时间（RFC3339）
+	End        *time.Time        `json:"end"`         // 一次性维护窗口的结束时间（RFC3339）
+	Matchers   map[string]string `json:"matchers"`    // 需要完全匹配的告警标签
+	Severities []string          `json:"severities"`  // 生效的告警级别，为空时不限制
+	Channels   []string          `json:"channels"`    // 生效的通知渠道，如 ["feishu"]，为空时不限制
+	Action     string            `json:"action"`      // hold 或 redirect
+	Redirect   []notify.Target   `json:"redirect"`    // redirect 时改为发送的目标
+	Digest     bool              `json:"digest"`      // hold 的通知是否在时间窗口结束后以摘要发送
+
+	location *time.Location
+	cron     *cron.Expr
+	duration time.Duration
+}
+
+// Config 静默规则配置。
+type Config struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// heldAlert 暂缓发送的告警，同一告警只保留最新的状态。
+type heldAlert struct {
+	alert common.Alert
+	count int // 暂缓发送的次数
+}
+
+var (
+	mu    sync.Mutex
+	rules []*Rule
+	held  = make(map[*Rule]map[string]map[string]*heldAlert) // 规则 -> channel/target -> 指纹 -> 告警
+
+	// redirected 改道发送的去重记录。同一告警通常会同时发送到多个原目标，
+	// 改道目标在短时间内只需要收到一次
+	redirected = dedup.New(time.Minute)
+)
+
+// Init 从 QUIET_CONFIG 指定的 JSON 文件加载规则，并在需要时启动摘要发送任务。
+func Init() {
+	path := os.Getenv("QUIET_CONFIG")
+	if path == "" {
+		return
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		log.Printf("⚠️ Failed to load QUIET_CONFIG %s, quiet hours disabled: %v", path, err)
+		return
+	}
+
+	mu.Lock()
+	rules = cfg.Rules
+	mu.Unlock()
+
+	for _, r := range cfg.Rules {
+		if r.Digest {
+			go releaseLoop()
+			break
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		names = append(names, r.Name)
+	}
+	log.Printf("✅ Quiet rules loaded: %v", names)
+}
+
+// Load 从 JSON 文件加载并校验静默规则。
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quiet config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse quiet config: %w", err)
+	}
+
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if err := r.init(); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// init 校验规则并解析时区、时间段和 cron 表达式。
+func (r *Rule) init() error {
+	r.location = time.Local
+	if r.Timezone != "" {
+		loc, err := time.LoadLocation(r.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", r.Timezone, err)
+		}
+		r.location = loc
+	}
+
+	for i := range r.TimeRanges {
+		if err := r.TimeRanges[i].parse(); err != nil {
+			return fmt.Errorf("time range %d: %w", i+1, err)
+		}
+	}
+
+	if r.Cron != "" {
+		expr, err := cron.Parse(r.Cron)
+		if err != nil {
+			return fmt.Errorf("invalid cron: %w", err)
+		}
+		duration, err := time.ParseDuration(r.Duration)
+		if err != nil || duration <= 0 || duration > maxCronDuration {
+			return fmt.Errorf("invalid duration %q (must be between 1m and %v)", r.Duration, maxCronDuration)
+		}
+		r.cron, r.duration = expr, duration
+	}
+
+	if (r.Start == nil) != (r.End == nil) || (r.Start != nil && !r.End.After(*r.Start)) {
+		return fmt.Errorf("start and end must both be set and end must be after start")
+	}
+	if len(r.TimeRanges) == 0 && r.cron == nil && r.Start == nil {
+		return fmt.Errorf("one of time_ranges, cron or start/end is required")
+	}
+
+	switch r.Action {
+	case ActionHold:
+	case ActionRedirect:
+		if len(r.Redirect) == 0 {
+			return fmt.Errorf("redirect requires at least one destination")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	return nil
+}
+
+// parse 解析时间段的星期和起止时间。
+func (tr *TimeRange) parse() error {
+	start, err := parseClock(tr.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(tr.End)
+	if err != nil {
+		return err
+	}
+	tr.start, tr.end = start, end
+
+	tr.everyDay = len(tr.Weekdays) == 0
+	for _, w := range tr.Weekdays {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(w)), "-")
+		lo, ok := cron.WeekdayNames[from]
+		if !ok {
+			return fmt.Errorf("invalid weekday %q", w)
+		}
+		hi := lo
+		if isRange {
+			if hi, ok = cron.WeekdayNames[to]; !ok {
+				return fmt.Errorf("invalid weekday %q", w)
+			}
+		}
+		// 支持跨周的范围，如 fri-mon
+		for d := lo; ; d = (d + 1) % 7 {
+			tr.weekdays[d] = true
+			if d == hi {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回距当天 00:00 的时长。
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// activeUntil 判断时间段在 now 是否生效，生效时返回结束时间。
+func (tr *TimeRange) activeUntil(now time.Time) (time.Time, bool) {
+	// 跨天的时间段可能从前一天开始
+	for offset := 0; offset >= -1; offset-- {
+		day := time.Date(now.Year(), now.Month(), now.Day()+offset, 0, 0, 0, 0, now.Location())
+		if !tr.everyDay && !tr.weekdays[day.Weekday()] {
+			continue
+		}
+		start := day.Add(tr.start)
+		end := day.Add(tr.end)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		if !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ActiveUntil 判断规则在 now 是否生效，生效时返回当前时间窗口的结束时间。
+func (r *Rule) ActiveUntil(now time.Time) (time.Time, bool) {
+	now = now.In(r.location)
+
+	if r.Start != nil && !now.Before(*r.Start) && now.Before(*r.End) {
+		return *r.End, true
+	}
+	for i := range r.TimeRanges {
+		if end, ok := r.TimeRanges[i].activeUntil(now); ok {
+			return end, true
+		}
+	}
+	if r.cron != nil {
+		if at, ok := r.cron.LastBefore(now, r.duration); ok && now.Before(at.Add(r.duration)) {
+			return at.Add(r.duration), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// matches 判断规则是否适用于发送到指定渠道的告警。
+func (r *Rule) matches(alert common.Alert, channel string) bool {
+	if len(r.Channels) > 0 && !containsFold(r.Channels, channel) {
+		return false
+	}
+	if len(r.Severities) > 0 && !containsFold(r.Severities, alert.Labels["severity"]) {
+		return false
+	}
+	for k, v := range r.Matchers {
+		if alert.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold 判断字符串切片是否包含指定值（忽略大小写）。
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply 对发送到 channel/target 的告警应用静默规则。
+// 第一个生效且匹配的规则决定处理方式：hold 时暂缓发送（需要时记录到摘要）；
+// redirect 时改为发送到规则中的目标。返回匹配的规则名称，以及是否仍需发送到原目标。
+func Apply(alert common.Alert, channel, target string) (string, bool) {
+	now := time.Now()
+
+	// 规则只在 Init 时加载，在锁外判断时间窗口，避免 cron 规则的计算阻塞其他告警
+	mu.Lock()
+	current := rules
+	mu.Unlock()
+
+	var rule *Rule
+	for _, r := range current {
+		if !r.matches(alert, channel) {
+			continue
+		}
+		if _, active := r.ActiveUntil(now); active {
+			rule = r
+			break
+		}
+	}
+	if rule == nil {
+		return "", true
+	}
+
+	switch {
+	case rule.Action == ActionHold && rule.Digest:
+		mu.Lock()
+		hold(rule, alert, channel, target)
+		mu.Unlock()
+	case rule.Action == ActionRedirect:
+		redirect(rule, alert)
+	}
+	return rule.Name, false
+}
+
+// hold 记录暂缓发送的告警，调用方需持有锁。
+func hold(rule *Rule, alert common.Alert, channel, target string) {
+	dests, ok := held[rule]
+	if !ok {
+		dests = make(map[string]map[string]*heldAlert)
+		held[rule] = dests
+	}
+	dest := channel + "/" + target
+	alerts, ok := dests[dest]
+	if !ok {
+		alerts = make(map[string]*heldAlert)
+		dests[dest] = alerts
+	}
+
+	key := alert.Fingerprint
+	if key == "" {
+		key = alert.Labels["alertname"]
+	}
+	if h, ok := alerts[key]; ok {
+		h.alert = alert
+		h.count++
+		return
+	}
+	alerts[key] = &heldAlert{alert: alert, count: 1}
+}
+
+// Discard 清除告警在 channel/target 上暂缓发送的记录。
+// 事件类渠道在告警 resolved 时已直接关闭事件，时间窗口结束后不再需要按暂缓时的状态重新发送。
+func Discard(alert common.Alert, channel, target string) {
+	key := alert.Fingerprint
+	if key == "" {
+		key = alert.Labels["alertname"]
+	}
+	dest := channel + "/" + target
+
+	mu.Lock()
+	defer mu.Unlock()
+	for rule, dests := range held {
+		alerts, ok := dests[dest]
+		if !ok {
+			continue
+		}
+		delete(alerts, key)
+		if len(alerts) == 0 {
+			delete(dests, dest)
+		}
+		if len(dests) == 0 {
+			delete(held, rule)
+		}
+	}
+}
+
+// redirect 将告警改为发送到规则中的目标。
+func redirect(rule *Rule, alert common.Alert) {
+	alertName := alert.Labels["alertname"]
+	msg := buildMessage(alert, rule.Name)
+	for _, d := range rule.Redirect {
+		if !redirected.Acquire(alert, d.Channel, d.Target) {
+			continue
+		}
+		err := notify.SendAlert(d.Channel, d.Target, alert, msg)
+		redirected.Done(alert, d.Channel, d.Target, err)
+		metrics.Notifications.Inc(d.Channel, d.Target, metrics.Result(err))
+		history.Notified(alert, d.Channel, d.Target, err)
+		if err != nil {
+			log.Printf("❌ Failed to redirect alert %s to %s/%s: %v", alertName, d.Channel, d.Target, err)
+		} else {
+			log.Printf("↪️ Redirected alert %s to %s/%s by quiet rule %s", alertName, d.Channel, d.Target, rule.Name)
+		}
+	}
+}
+
+// releaseLoop 每分钟检查一次，在时间窗口结束后发送暂缓通知的摘要。
+func releaseLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		release(time.Now())
+	}
+}
+
+// release 发送已结束时间窗口的规则暂缓的通知摘要。
+func release(now time.Time) {
+	type digest struct {
+		rule   string
+		dest   string
+		alerts []*heldAlert
+	}
+
+	mu.Lock()
+	pending := make([]*Rule, 0, len(held))
+	for rule := range held {
+		pending = append(pending, rule)
+	}
+	mu.Unlock()
+
+	// 在锁外判断时间窗口是否结束
+	ended := make([]*Rule, 0, len(pending))
+	for _, rule := range pending {
+		if _, active := rule.ActiveUntil(now); !active {
+			ended = append(ended, rule)
+		}
+	}
+
+	mu.Lock()
+	var digests []digest
+	for _, rule := range ended {
+		for dest, alerts := range held[rule] {
+			d := digest{rule: rule.Name, dest: dest}
+			for _, h := range alerts {
+				d.alerts = append(d.alerts, h)
+			}
+			digests = append(digests, d)
+		}
+		delete(held, rule)
+	}
+	mu.Unlock()
+
+	// 在锁外发送通知，避免网络请求阻塞告警处理
+	for _, d := range digests {
+		channel, target, _ := strings.Cut(d.dest, "/")
+
+		// 事件类渠道不发送摘要，按暂缓告警的最新状态逐个触发或关闭对应的事件
+		if notify.SendsAlerts(channel) {
+			for _, h := range d.alerts {
+				err := notify.SendAlert(channel, target, h.alert, notify.Message{})
+				history.Notified(h.alert, channel, target, err)
+				if err != nil {
+					log.Printf("❌ Failed to send held alert %s of rule %s to %s: %v", h.alert.Labels["alertname"], d.rule, d.dest, err)
+				}
+			}
+			log.Printf("📨 Sent held alerts of rule %s to %s: %d alerts", d.rule, d.dest, len(d.alerts))
+			continue
+		}
+
+		if err := notify.Send(channel, target, buildDigest(d.rule, d.alerts)); err != nil {
+			log.Printf("❌ Failed to send quiet digest of rule %s to %s: %v", d.rule, d.dest, err)
+			continue
+		}
+		log.Printf("📨 Sent quiet digest of rule %s to %s: %d alerts", d.rule, d.dest, len(d.alerts))
+	}
+}
+
+// buildMessage 构建改道发送的告警消息。
+func buildMessage(alert common.Alert, rule string) notify.Message {
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "🌙 [%s] %s（静默规则 %s 改道发送）\n", strings.ToUpper(alert.Status), alertName, rule)
+	if severity := alert.Labels["severity"]; severity != "" {
+		fmt.Fprintf(&builder, "级别: %s\n", severity)
+	}
+	if summary := alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&builder, "摘要: %s\n", summary)
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&builder, "详情: %s\n", desc)
+	}
+	fmt.Fprintf(&builder, "指纹: %s", alert.Fingerprint)
+
+	return notify.Message{
+		Title:    fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Status), alertName),
+		Text:     builder.String(),
+		Severity: alert.Labels["severity"],
+	}
+}
+
+// buildDigest 构建时间窗口结束后的暂缓通知摘要，按告警开始时间排序。
+func buildDigest(rule string, alerts []*heldAlert) notify.Message {
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].alert.StartsAt.Before(alerts[j].alert.StartsAt)
+	})
+
+	severity := ""
+	firing := 0
+	var builder strings.Builder
+	for _, h := range alerts {
+		if h.alert.Status == "firing" {
+			firing++
+		}
+		if common.SeverityRank(h.alert.Labels["severity"]) > common.SeverityRank(severity) {
+			severity = h.alert.Labels["severity"]
+		}
+	}
+
+	fmt.Fprintf(&builder, "📨 静默规则 %s 已结束，期间暂缓发送 %d 个告警（仍在 firing: %d）\n", rule, len(alerts), firing)
+	for _, h := range alerts {
+		alertName := h.alert.Labels["alertname"]
+		fmt.Fprintf(&builder, "- [%s] %s", strings.ToUpper(h.alert.Status), alertName)
+		if s := h.alert.Labels["severity"]; s != "" {
+			fmt.Fprintf(&builder, " (%s)", s)
+		}
+		if summary := h.alert.Annotations["summary"]; summary != "" {
+			fmt.Fprintf(&builder, ": %s", summary)
+		}
+		if h.count > 1 {
+			fmt.Fprintf(&builder, " ×%d", h.count)
+		}
+		builder.WriteString("\n")
+	}
+
+	return notify.Message{
+		Title:    fmt.Sprintf("[DIGEST] %s", rule),
+		Text:     strings.TrimSuffix(builder.String(), "\n"),
+		Severity: severity,
+	}
+}
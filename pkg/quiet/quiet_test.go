@@ -0,0 +1,292 @@
+package quiet
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRule 解析测试规则，解析失败时终止测试。
+func newRule(t *testing.T, r Rule) *Rule {
+	t.Helper()
+	if err := r.init(); err != nil {
+		t.Fatalf("init rule: %v", err)
+	}
+	return &r
+}
+
+// useRules 在测试期间使用指定的规则，并清空暂缓的告警。
+func useRules(t *testing.T, rs ...*Rule) {
+	t.Helper()
+	mu.Lock()
+	rules = rs
+	held = make(map[*Rule]map[string]map[string]*heldAlert)
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		rules = nil
+		held = make(map[*Rule]map[string]map[string]*heldAlert)
+		mu.Unlock()
+	})
+}
+
+// recorder 记录发送到测试渠道的消息。
+type recorder struct {
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func (r *recorder) send(target string, msg notify.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recorder) sent() []notify.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]notify.Message(nil), r.messages...)
+}
+
+func TestTimeRangeActive(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 工作日夜间 22:00 到次日 08:00，以开始时间所在日判断星期
+	r := newRule(t, Rule{
+		Timezone:   "Asia/Shanghai",
+		TimeRanges: []TimeRange{{Weekdays: []string{"mon-fri"}, Start: "22:00", End: "08:00"}},
+		Action:     ActionHold,
+	})
+
+	tests := []struct {
+		at     string
+		active bool
+		until  string
+	}{
+		{"2024-03-04T21:59", false, ""},                // 周一
+		{"2024-03-04T22:00", true, "2024-03-05T08:00"}, // 周一夜间
+		{"2024-03-05T07:59", true, "2024-03-05T08:00"}, // 周一开始的时间段跨到周二
+		{"2024-03-05T08:00", false, ""},
+		{"2024-03-09T07:00", true, "2024-03-09T08:00"}, // 周五开始的时间段跨到周六
+		{"2024-03-09T23:00", false, ""},                // 周六不生效
+		{"2024-03-10T23:00", false, ""},                // 周日不生效
+	}
+	for _, tt := range tests {
+		at, _ := time.ParseInLocation("2006-01-02T15:04", tt.at, shanghai)
+		until, active := r.ActiveUntil(at.UTC())
+		if active != tt.active {
+			t.Errorf("ActiveUntil(%s) active = %v, want %v", tt.at, active, tt.active)
+			continue
+		}
+		if tt.active {
+			want, _ := time.ParseInLocation("2006-01-02T15:04", tt.until, shanghai)
+			if !until.Equal(want) {
+				t.Errorf("ActiveUntil(%s) = %v, want %v", tt.at, until, want)
+			}
+		}
+	}
+}
+
+func TestCronRuleActive(t *testing.T) {
+	// 每周六 02:00 开始的 4 小时维护窗口
+	r := newRule(t, Rule{Timezone: "UTC", Cron: "0 2 * * sat", Duration: "4h", Action: ActionHold})
+
+	tests := []struct {
+		at     time.Time
+		active bool
+	}{
+		{time.Date(2024, 3, 9, 1, 59, 0, 0, time.UTC), false},
+		{time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 3, 9, 5, 59, 0, 0, time.UTC), true},
+		{time.Date(2024, 3, 9, 6, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 3, 12, 3, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		until, active := r.ActiveUntil(tt.at)
+		if active != tt.active {
+			t.Errorf("ActiveUntil(%v) active = %v, want %v", tt.at, active, tt.active)
+		}
+		if want := time.Date(2024, 3, 9, 6, 0, 0, 0, time.UTC); active && !until.Equal(want) {
+			t.Errorf("ActiveUntil(%v) = %v, want %v", tt.at, until, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"valid", `{"rules": [{"cron": "0 2 * * sat", "duration": "2h", "action": "hold"}]}`, ""},
+		{"no window", `{"rules": [{"action": "hold"}]}`, "one of time_ranges"},
+		{"bad action", `{"rules": [{"start": "2024-03-09T02:00:00Z", "end": "2024-03-09T04:00:00Z", "action": "drop"}]}`, "unknown action"},
+		{"end before start", `{"rules": [{"start": "2024-03-09T04:00:00Z", "end": "2024-03-09T02:00:00Z", "action": "hold"}]}`, "end must be after start"},
+		{"redirect without target", `{"rules": [{"cron": "0 2 * * *", "duration": "1h", "action": "redirect"}]}`, "at least one destination"},
+		{"bad duration", `{"rules": [{"cron": "0 2 * * *", "duration": "9d", "action": "hold"}]}`, "invalid duration"},
+		{"bad weekday", `{"rules": [{"time_ranges": [{"weekdays": ["funday"], "start": "22:00", "end": "08:00"}], "action": "hold"}]}`, "invalid weekday"},
+		{"bad timezone", `{"rules": [{"timezone": "Mars/Base", "cron": "0 2 * * *", "duration": "1h", "action": "hold"}]}`, "invalid timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "quiet.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load error: %v", err)
+				}
+				if cfg.Rules[0].Name != "rule-1" {
+					t.Errorf("default name = %q, want rule-1", cfg.Rules[0].Name)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestHoldDigest hold 规则暂缓匹配的告警，时间窗口结束后按目标发送一条摘要，同一告警只保留最新状态。
+func TestHoldDigest(t *testing.T) {
+	rec := &recorder{}
+	notify.Register("quiet-hold", rec.send)
+
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	useRules(t, newRule(t, Rule{
+		Name: "night", Start: &start, End: &end, Severities: []string{"warning"},
+		Channels: []string{"quiet-hold"}, Action: ActionHold, Digest: true,
+	}))
+
+	disk := common.Alert{Status: "firing", Fingerprint: "disk", StartsAt: now.Add(-time.Minute),
+		Labels: map[string]string{"alertname": "DiskFull", "severity": "warning"}}
+	cpu := common.Alert{Status: "firing", Fingerprint: "cpu", StartsAt: now.Add(-2 * time.Minute),
+		Labels: map[string]string{"alertname": "HighCPU", "severity": "warning"}}
+	critical := common.Alert{Status: "firing", Fingerprint: "down",
+		Labels: map[string]string{"alertname": "NodeDown", "severity": "critical"}}
+
+	if rule, send := Apply(critical, "quiet-hold", "ops"); !send || rule != "" {
+		t.Errorf("critical alert: rule = %q, send = %v; want not matched", rule, send)
+	}
+	if _, send := Apply(disk, "quiet-other", "ops"); !send {
+		t.Error("alert to another channel was held")
+	}
+	for _, a := range []common.Alert{disk, cpu, disk} {
+		if rule, send := Apply(a, "quiet-hold", "ops"); send || rule != "night" {
+			t.Fatalf("%s: rule = %q, send = %v; want held by night", a.Labels["alertname"], rule, send)
+		}
+	}
+	disk.Status = "resolved"
+	Apply(disk, "quiet-hold", "ops")
+
+	// 时间窗口仍在生效时不发送
+	release(now)
+	if sent := rec.sent(); len(sent) != 0 {
+		t.Fatalf("sent %d messages before the window ended", len(sent))
+	}
+
+	release(end.Add(time.Minute))
+	sent := rec.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d digests, want 1", len(sent))
+	}
+	digest := sent[0]
+	if digest.Title != "[DIGEST] night" || digest.Severity != "warning" {
+		t.Errorf("digest title = %q, severity = %q", digest.Title, digest.Severity)
+	}
+	want := []string{
+		"期间暂缓发送 2 个告警（仍在 firing: 1）",
+		"- [FIRING] HighCPU (warning)\n- [RESOLVED] DiskFull (warning) ×3",
+	}
+	for _, w := range want {
+		if !strings.Contains(digest.Text, w) {
+			t.Errorf("digest text missing %q:\n%s", w, digest.Text)
+		}
+	}
+
+	// 已发送的摘要不再重复发送
+	release(end.Add(2 * time.Minute))
+	if sent := rec.sent(); len(sent) != 1 {
+		t.Errorf("sent %d digests after second release, want 1", len(sent))
+	}
+}
+
+// TestRedirect redirect 规则将告警改为发送到规则中的目标，同一告警发送到多个原目标时只改道发送一次。
+func TestRedirect(t *testing.T) {
+	rec := &recorder{}
+	notify.Register("quiet-redirect", rec.send)
+
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	useRules(t, newRule(t, Rule{
+		Name: "weekend", Start: &start, End: &end, Matchers: map[string]string{"env": "staging"},
+		Action: ActionRedirect, Redirect: []notify.Target{{Channel: "quiet-redirect", Target: "night"}},
+	}))
+
+	alert := common.Alert{Status: "firing", Fingerprint: "redirect-fp",
+		Labels: map[string]string{"alertname": "DiskFull", "env": "staging", "severity": "warning"}}
+	for _, target := range []string{"ops", "dba"} {
+		if rule, send := Apply(alert, "feishu", target); send || rule != "weekend" {
+			t.Fatalf("%s: rule = %q, send = %v; want redirected by weekend", target, rule, send)
+		}
+	}
+
+	sent := rec.sent()
+	if len(sent) != 1 {
+		t.Fatalf("redirected %d messages, want 1", len(sent))
+	}
+	if sent[0].Title != "[FIRING] DiskFull" || !strings.Contains(sent[0].Text, "静默规则 weekend 改道发送") {
+		t.Errorf("redirected message = %+v", sent[0])
+	}
+
+	prod := common.Alert{Status: "firing", Fingerprint: "prod-fp", Labels: map[string]string{"alertname": "DiskFull", "env": "prod"}}
+	if _, send := Apply(prod, "feishu", "ops"); !send {
+		t.Error("alert not matching the rule was redirected")
+	}
+}
+
+// TestDiscard 事件类渠道的 resolved 告警直接关闭事件后清除暂缓的记录，时间窗口结束后不再发送；
+// 其他目标暂缓的告警不受影响。
+func TestDiscard(t *testing.T) {
+	rec := &recorder{}
+	notify.Register("quiet-discard", rec.send)
+
+	now := time.Now()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	useRules(t, newRule(t, Rule{
+		Name: "maintenance", Start: &start, End: &end,
+		Channels: []string{"quiet-discard"}, Action: ActionHold, Digest: true,
+	}))
+
+	alert := common.Alert{Status: "firing", Fingerprint: "discard-fp",
+		Labels: map[string]string{"alertname": "DiskFull", "severity": "warning"}}
+	for _, target := range []string{"ops", "dba"} {
+		if _, send := Apply(alert, "quiet-discard", target); send {
+			t.Fatalf("alert to %s was not held", target)
+		}
+	}
+
+	alert.Status = "resolved"
+	Discard(alert, "quiet-discard", "ops")
+
+	release(end.Add(time.Minute))
+	sent := rec.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d digests, want 1 for the target that was not discarded", len(sent))
+	}
+	if !strings.Contains(sent[0].Text, "[FIRING] DiskFull") {
+		t.Errorf("digest text = %q, want the held alert for dba", sent[0].Text)
+	}
+}
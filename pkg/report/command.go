@@ -0,0 +1,130 @@
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/history"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Command 执行 report 子命令，按需生成报表并输出到标准输出，返回进程退出码。
+// 指定 -server 时通过运行中 adapter 的 /api/report 接口生成；
+// 否则直接读取 HISTORY_DB_PATH 指定的数据库（adapter 运行时数据库被锁定，需要使用 -server）。
+func Command(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.String("since", "", "report start, RFC3339 time or duration before now (default REPORT_PERIOD)")
+	until := fs.String("until", "", "report end, RFC3339 time or duration before now (default now)")
+	format := fs.String("format", "text", "output format: text or json")
+	send := fs.Bool("send", false, "send the report to -target (default REPORT_TARGETS)")
+	target := fs.String("target", "", "comma separated report targets, channel/target or feishu target name")
+	server := fs.String("server", "", "address of a running adapter, e.g. http://localhost:8080")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	loadConfig()
+
+	if *server != "" {
+		return remote(*server, *since, *until, *format, *target, *send)
+	}
+
+	to, err := history.ParseTime(*until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -until: %v\n", err)
+		return 2
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from, err := history.ParseTime(*since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -since: %v\n", err)
+		return 2
+	}
+	if from.IsZero() {
+		from = to.Add(-config.Period)
+	}
+
+	path := os.Getenv("HISTORY_DB_PATH")
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "HISTORY_DB_PATH is not set")
+		return 1
+	}
+	store, err := history.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v (if the adapter is running, use -server)\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	rep, text, err := BuildFrom(store, from, to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(rep)
+	} else {
+		fmt.Println(text)
+	}
+
+	if *send {
+		targets := config.Targets
+		if *target != "" {
+			targets = ParseTargets(*target)
+		}
+		if err := Send(text, targets); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to send report: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// remote 通过运行中 adapter 的 /api/report 接口生成报表。
+func remote(server, since, until, format, target string, send bool) int {
+	params := url.Values{}
+	for k, v := range map[string]string{"since": since, "until": until, "format": format, "target": target} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+
+	method := http.MethodGet
+	if send {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(server, "/")+"/api/report?"+params.Encode(), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// reportResponse /api/report 接口的 JSON 响应。
+type reportResponse struct {
+	*Report
+	Text string `json:"text"`
+	Sent bool   `json:"sent"`
+}
+
+// Handler 处理 /api/report 请求，按需生成报表。
+// since、until 可以是 RFC3339 时间或相对当前的时长（如 24h），默认为最近一个 REPORT_PERIOD；
+// format=json 时返回 JSON，否则返回渲染后的文本。
+// POST 请求会将报表发送到 target 参数（多个用逗号分隔）指定的目标，默认发送到 REPORT_TARGETS；
+// 需要携带 REPORT_TOKEN，且只能发送到 REPORT_TARGETS 和 REPORT_ALLOWED_TARGETS 中的目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	var targets []notify.Target
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if config.Token == "" {
+			http.Error(w, "report sending not enabled", http.StatusForbidden)
+			return
+		}
+		if !common.BearerAuthorized(r, config.Token) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		targets = config.Targets
+		if param := params.Get("target"); param != "" {
+			targets = ParseTargets(param)
+		}
+		for _, t := range targets {
+			if !allowed(t) {
+				http.Error(w, fmt.Sprintf("target %s not allowed", t), http.StatusForbidden)
+				return
+			}
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	to, err := history.ParseTime(params.Get("until"))
+	if err != nil {
+		http.Error(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from, err := history.ParseTime(params.Get("since"))
+	if err != nil {
+		http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from.IsZero() {
+		from = to.Add(-config.Period)
+	}
+
+	rep, text, err := Build(from, to)
+	if err != nil {
+		log.Printf("❌ Failed to build report: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sent := false
+	if r.Method == http.MethodPost {
+		if err := Send(text, targets); err != nil {
+			log.Printf("❌ Failed to send report: %v", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		sent = true
+	}
+
+	if params.Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(reportResponse{Report: rep, Text: text, Sent: sent}); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte(text + "\n")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestMain 为 /api/report 使用的全局告警历史存储创建临时数据库。
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "report")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HISTORY_DB_PATH", filepath.Join(dir, "history.db"))
+	history.Init()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// recorder 记录通过 test 渠道发送的报表。
+type recorder struct {
+	mu      sync.Mutex
+	targets []string
+}
+
+func (r *recorder) send(target string, msg notify.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets = append(r.targets, target)
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	rec := &recorder{}
+	notify.Register("test", rec.send)
+
+	saved := config
+	t.Cleanup(func() { config = saved })
+	config.Targets = ParseTargets("test/daily")
+	config.Allowed = append(ParseTargets("test/daily"), ParseTargets("test/weekly")...)
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		token  string // 配置的 REPORT_TOKEN
+		auth   string
+		status int
+		sent   []string
+	}{
+		{"get text", http.MethodGet, "?since=24h", "", "", http.StatusOK, nil},
+		{"get json", http.MethodGet, "?since=24h&format=json", "", "", http.StatusOK, nil},
+		{"get ignores target", http.MethodGet, "?target=test/other", "secret", "", http.StatusOK, nil},
+		{"invalid since", http.MethodGet, "?since=yesterday", "", "", http.StatusBadRequest, nil},
+		{"invalid until", http.MethodGet, "?until=yesterday", "", "", http.StatusBadRequest, nil},
+		{"method not allowed", http.MethodDelete, "", "secret", "Bearer secret", http.StatusMethodNotAllowed, nil},
+		{"post without token configured", http.MethodPost, "", "", "Bearer secret", http.StatusForbidden, nil},
+		{"post without authorization", http.MethodPost, "", "secret", "", http.StatusUnauthorized, nil},
+		{"post with wrong token", http.MethodPost, "", "secret", "Bearer wrong", http.StatusUnauthorized, nil},
+		{"post to default targets", http.MethodPost, "", "secret", "Bearer secret", http.StatusOK, []string{"daily"}},
+		{"post to allowed target", http.MethodPost, "?target=test/weekly,test/daily", "secret", "Bearer secret", http.StatusOK, []string{"weekly", "daily"}},
+		{"post to other target", http.MethodPost, "?target=test/weekly,test/other", "secret", "Bearer secret", http.StatusForbidden, nil},
+		{"post to other channel", http.MethodPost, "?target=daily", "secret", "Bearer secret", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Token = tt.token
+			rec.targets = nil
+
+			req := httptest.NewRequest(tt.method, "/api/report"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			Handler(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !reflect.DeepEqual(rec.targets, tt.sent) {
+				t.Errorf("sent to %v, want %v", rec.targets, tt.sent)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			if strings.Contains(tt.query, "format=json") {
+				var resp reportResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("invalid JSON response: %v", err)
+				}
+				if resp.Report == nil || !strings.HasPrefix(resp.Text, "📊 告警报表") || resp.Sent {
+					t.Errorf("unexpected response %s", w.Body)
+				}
+			} else if !strings.HasPrefix(w.Body.String(), "📊 告警报表") {
+				t.Errorf("unexpected response %s", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Package report 根据告警历史生成摘要报表：触发最多的告警、按级别和团队的统计、
+// 平均恢复时间、最嘈杂的告警以及仍在 firing 的告警，并支持定时发送到通知目标。
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Count 按名称统计的数量。
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// FiringAlert 仍在 firing 的告警。
+type FiringAlert struct {
+	AlertName   string        `json:"alertname"`
+	Severity    string        `json:"severity,omitempty"`
+	Summary     string        `json:"summary,omitempty"`
+	Fingerprint string        `json:"fingerprint"`
+	StartsAt    time.Time     `json:"starts_at"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Report 一段时间内的告警摘要报表。
+type Report struct {
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Total       int           `json:"total"`        // 告警触发次数
+	Resolved    int           `json:"resolved"`     // 已恢复的触发次数
+	MTTR        time.Duration `json:"mttr"`         // 已恢复告警的平均恢复时间
+	BySeverity  []Count       `json:"by_severity"`  // 按级别统计的触发次数
+	ByTeam      []Count       `json:"by_team"`      // 按团队统计的触发次数
+	TopFiring   []Count       `json:"top_firing"`   // 触发次数最多的告警
+	Noisiest    []Count       `json:"noisiest"`     // 发送通知最多的告警
+	StillFiring []FiringAlert `json:"still_firing"` // 仍在 firing 的告警，按开始时间排序
+}
+
+// Options 报表生成选项。
+type Options struct {
+	TeamLabel string // 团队标签名，默认 team
+	Top       int    // 排行榜的条数，默认 10
+}
+
+// Generate 根据告警历史生成 [from, to) 时间段的报表。
+func Generate(store *history.Store, from, to time.Time, opts Options) (*Report, error) {
+	if opts.TeamLabel == "" {
+		opts.TeamLabel = "team"
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+
+	records, err := store.List(history.Query{Since: from, Until: to})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{From: from, To: to}
+	severities := make(map[string]int)
+	teams := make(map[string]int)
+	firing := make(map[string]int)
+	noisy := make(map[string]int)
+	var resolvedTotal time.Duration
+
+	for _, rec := range records {
+		alertName := rec.AlertName
+		if alertName == "" {
+			alertName = "Unknown Alert"
+		}
+
+		for _, n := range rec.Notifications {
+			if n.Success && !n.At.Before(from) && n.At.Before(to) {
+				noisy[alertName]++
+			}
+		}
+
+		// 只统计在时间段内开始的触发，时间段之前开始的告警只计入仍在 firing 和通知数量
+		if !rec.FirstSeen.Before(from) {
+			r.Total++
+			severities[valueOr(rec.Severity, "unknown")]++
+			teams[valueOr(rec.Labels[opts.TeamLabel], "unknown")]++
+			firing[alertName]++
+		}
+
+		if rec.Status == "resolved" && rec.ResolvedAt != nil {
+			if !rec.ResolvedAt.Before(from) && rec.ResolvedAt.Before(to) {
+				r.Resolved++
+				resolvedTotal += rec.Duration()
+			}
+			continue
+		}
+		if rec.Status == "firing" {
+			r.StillFiring = append(r.StillFiring, FiringAlert{
+				AlertName:   alertName,
+				Severity:    rec.Severity,
+				Summary:     rec.Annotations["summary"],
+				Fingerprint: rec.Fingerprint,
+				StartsAt:    startOf(rec),
+				Duration:    to.Sub(startOf(rec)),
+			})
+		}
+	}
+
+	if r.Resolved > 0 {
+		r.MTTR = resolvedTotal / time.Duration(r.Resolved)
+	}
+	r.BySeverity = sortCounts(severities, 0)
+	sort.SliceStable(r.BySeverity, func(i, j int) bool {
+		return common.SeverityRank(r.BySeverity[i].Name) > common.SeverityRank(r.BySeverity[j].Name)
+	})
+	r.ByTeam = sortCounts(teams, 0)
+	r.TopFiring = sortCounts(firing, opts.Top)
+	r.Noisiest = sortCounts(noisy, opts.Top)
+	sort.Slice(r.StillFiring, func(i, j int) bool {
+		return r.StillFiring[i].StartsAt.Before(r.StillFiring[j].StartsAt)
+	})
+	return r, nil
+}
+
+// startOf 返回告警的开始时间，缺少 startsAt 时使用 adapter 首次收到的时间。
+func startOf(rec *history.Record) time.Time {
+	if rec.StartsAt.IsZero() {
+		return rec.FirstSeen
+	}
+	return rec.StartsAt
+}
+
+// valueOr 返回 value，为空时返回默认值。
+func valueOr(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// sortCounts 按数量从多到少排序，数量相同时按名称排序；limit 大于 0 时只保留前 limit 条。
+func sortCounts(m map[string]int, limit int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, count := range m {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if limit > 0 && len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts
+}
+
+// defaultTemplate 默认的报表模板。
+const defaultTemplate = `📊 告警报表 {{ time .From }} ~ {{ time .To }}
+触发次数: {{ .Total }}，已恢复: {{ .Resolved }}{{ if .Resolved }}，平均恢复时间: {{ duration .MTTR }}{{ end }}
+{{- if .BySeverity }}
+
+按级别:
+{{- range .BySeverity }}
+- {{ .Name }}: {{ .Count }}
+{{- end }}
+{{- end }}
+{{- if .ByTeam }}
+
+按团队:
+{{- range .ByTeam }}
+- {{ .Name }}: {{ .Count }}
+{{- end }}
+{{- end }}
+{{- if .TopFiring }}
+
+触发最多的告警:
+{{- range $i, $c := .TopFiring }}
+{{ inc $i }}. {{ $c.Name }} ×{{ $c.Count }}
+{{- end }}
+{{- end }}
+{{- if .Noisiest }}
+
+通知最多的告警:
+{{- range $i, $c := .Noisiest }}
+{{ inc $i }}. {{ $c.Name }}（{{ $c.Count }} 条通知）
+{{- end }}
+{{- end }}
+
+仍在 firing 的告警: {{ len .StillFiring }}
+{{- range .StillFiring }}
+- {{ .AlertName }}{{ if .Severity }} ({{ .Severity }}){{ end }}{{ if .Summary }}: {{ .Summary }}{{ end }}，已持续 {{ duration .Duration }}
+{{- end }}`
+
+// funcs 报表模板可以使用的函数。
+var funcs = template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Minute).String()
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"upper": strings.ToUpper,
+}
+
+// ParseTemplate 解析报表模板，text 为空时使用默认模板。
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultTemplate
+	}
+	tmpl, err := template.New("report").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render 使用模板渲染报表。
+func (r *Report) Render(tmpl *template.Template) (string, error) {
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, r); err != nil {
+		return "", fmt.Errorf("failed to render report: %w", err)
+	}
+	return builder.String(), nil
+}
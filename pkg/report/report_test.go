@@ -0,0 +1,233 @@
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *history.Store {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// testAlert 创建测试告警，team 为空时不设置团队标签。
+func testAlert(fingerprint, name, severity, team, status string, startsAt, endsAt time.Time) common.Alert {
+	labels := map[string]string{"alertname": name}
+	if severity != "" {
+		labels["severity"] = severity
+	}
+	if team != "" {
+		labels["team"] = team
+	}
+	return common.Alert{
+		Status:      status,
+		Labels:      labels,
+		Annotations: map[string]string{"summary": name + " summary"},
+		Fingerprint: fingerprint,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+	}
+}
+
+func observe(t *testing.T, store *history.Store, alerts ...common.Alert) {
+	t.Helper()
+	for _, alert := range alerts {
+		if _, err := store.Observe(alert); err != nil {
+			t.Fatalf("Observe: %v", err)
+		}
+	}
+}
+
+func notified(t *testing.T, store *history.Store, alert common.Alert, err error) {
+	t.Helper()
+	if err := store.Notified(alert, "feishu", "ops", err); err != nil {
+		t.Fatalf("Notified: %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now().Round(0) // 去掉单调时钟读数，与从历史记录读出的时间一致
+	start := now.Add(-time.Hour)
+
+	disk1 := testAlert("fp1", "DiskFull", "critical", "db", "firing", now.Add(-30*time.Minute), time.Time{})
+	disk2 := testAlert("fp2", "DiskFull", "critical", "db", "firing", now.Add(-50*time.Minute), time.Time{})
+	cpu := testAlert("fp3", "HighCPU", "warning", "web", "firing", now.Add(-2*time.Hour), time.Time{})
+	// 在报表时间段之前已经恢复的告警只计入触发次数
+	old := testAlert("fp4", "OldAlert", "", "", "resolved", now.Add(-5*time.Hour), now.Add(-4*time.Hour))
+	observe(t, store, disk1, disk2, cpu, old)
+
+	disk1.Status, disk1.EndsAt = "resolved", now.Add(-10*time.Minute)
+	disk2.Status, disk2.EndsAt = "resolved", now.Add(-10*time.Minute)
+	observe(t, store, disk1, disk2)
+	for range 3 {
+		notified(t, store, cpu, nil)
+	}
+	notified(t, store, cpu, errors.New("timeout")) // 发送失败的通知不计入
+	notified(t, store, disk1, nil)
+
+	// mid 之后只收到 HighCPU 的重复通知
+	time.Sleep(2 * time.Millisecond)
+	mid := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	observe(t, store, cpu)
+	notified(t, store, cpu, nil)
+	end := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		opts        Options
+		total       int
+		resolved    int
+		mttr        time.Duration
+		bySeverity  []Count
+		byTeam      []Count
+		topFiring   []Count
+		noisiest    []Count
+		stillFiring []string
+	}{
+		{
+			name: "whole period", from: start, to: end,
+			total: 4, resolved: 2, mttr: 30 * time.Minute,
+			bySeverity:  []Count{{"critical", 2}, {"warning", 1}, {"unknown", 1}},
+			byTeam:      []Count{{"db", 2}, {"unknown", 1}, {"web", 1}},
+			topFiring:   []Count{{"DiskFull", 2}, {"HighCPU", 1}, {"OldAlert", 1}},
+			noisiest:    []Count{{"HighCPU", 4}, {"DiskFull", 1}},
+			stillFiring: []string{"HighCPU"},
+		},
+		{
+			name: "top 1", from: start, to: end, opts: Options{Top: 1},
+			total: 4, resolved: 2, mttr: 30 * time.Minute,
+			bySeverity:  []Count{{"critical", 2}, {"warning", 1}, {"unknown", 1}},
+			byTeam:      []Count{{"db", 2}, {"unknown", 1}, {"web", 1}},
+			topFiring:   []Count{{"DiskFull", 2}},
+			noisiest:    []Count{{"HighCPU", 4}},
+			stillFiring: []string{"HighCPU"},
+		},
+		{
+			name: "custom team label", from: start, to: end, opts: Options{TeamLabel: "severity"},
+			total: 4, resolved: 2, mttr: 30 * time.Minute,
+			bySeverity:  []Count{{"critical", 2}, {"warning", 1}, {"unknown", 1}},
+			byTeam:      []Count{{"critical", 2}, {"unknown", 1}, {"warning", 1}},
+			topFiring:   []Count{{"DiskFull", 2}, {"HighCPU", 1}, {"OldAlert", 1}},
+			noisiest:    []Count{{"HighCPU", 4}, {"DiskFull", 1}},
+			stillFiring: []string{"HighCPU"},
+		},
+		{
+			// 时间段之前首次收到的告警只计入仍在 firing 和通知数量
+			name: "after mid", from: mid, to: end,
+			bySeverity:  []Count{},
+			byTeam:      []Count{},
+			topFiring:   []Count{},
+			noisiest:    []Count{{"HighCPU", 1}},
+			stillFiring: []string{"HighCPU"},
+		},
+		{
+			name: "before any alert", from: start.Add(-time.Hour), to: start,
+			bySeverity: []Count{}, byTeam: []Count{}, topFiring: []Count{}, noisiest: []Count{},
+		},
+		{
+			name: "after last seen", from: end, to: end.Add(time.Hour),
+			bySeverity: []Count{}, byTeam: []Count{}, topFiring: []Count{}, noisiest: []Count{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Generate(store, tt.from, tt.to, tt.opts)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if r.Total != tt.total || r.Resolved != tt.resolved {
+				t.Errorf("total = %d, resolved = %d, want %d, %d", r.Total, r.Resolved, tt.total, tt.resolved)
+			}
+			if r.MTTR != tt.mttr {
+				t.Errorf("MTTR = %v, want %v", r.MTTR, tt.mttr)
+			}
+			for _, c := range []struct {
+				name      string
+				got, want []Count
+			}{
+				{"by severity", r.BySeverity, tt.bySeverity},
+				{"by team", r.ByTeam, tt.byTeam},
+				{"top firing", r.TopFiring, tt.topFiring},
+				{"noisiest", r.Noisiest, tt.noisiest},
+			} {
+				if !reflect.DeepEqual(c.got, c.want) {
+					t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+				}
+			}
+
+			var firing []string
+			for _, a := range r.StillFiring {
+				firing = append(firing, a.AlertName)
+				if want := tt.to.Sub(cpu.StartsAt); a.Duration != want {
+					t.Errorf("%s duration = %v, want %v", a.AlertName, a.Duration, want)
+				}
+			}
+			if !reflect.DeepEqual(firing, tt.stillFiring) {
+				t.Errorf("still firing = %v, want %v", firing, tt.stillFiring)
+			}
+		})
+	}
+}
+
+func TestRenderDefaultTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Report{
+		Total: 3, Resolved: 2, MTTR: 90 * time.Second,
+		BySeverity: []Count{{"critical", 3}},
+		TopFiring:  []Count{{"DiskFull", 2}, {"HighCPU", 1}},
+		StillFiring: []FiringAlert{
+			{AlertName: "HighCPU", Severity: "warning", Summary: "cpu busy", Duration: 2 * time.Hour},
+		},
+	}
+	text, err := r.Render(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"触发次数: 3，已恢复: 2，平均恢复时间: 2m0s",
+		"按级别:\n- critical: 3",
+		"触发最多的告警:\n1. DiskFull ×2\n2. HighCPU ×1",
+		"仍在 firing 的告警: 1\n- HighCPU (warning): cpu busy，已持续 2h0m0s",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report does not contain %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "按团队") || strings.Contains(text, "通知最多") {
+		t.Errorf("empty sections should be omitted:\n%s", text)
+	}
+
+	if _, err := ParseTemplate("{{ .Total "); err == nil {
+		t.Error("invalid template should fail to parse")
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got := ParseTargets(" OPS, syslog/noc,,webhook/Ticket ")
+	want := []string{"feishu/ops", "syslog/noc", "webhook/ticket"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].String() != want[i] {
+			t.Errorf("target %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package report
+
+import (
+	"alertmanagerWebhookAdapter/pkg/cron"
+	"alertmanagerWebhookAdapter/pkg/history"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Config 报表配置，从环境变量加载。
+type Config struct {
+	Schedule *cron.Expr         // 定时发送的 cron 表达式，为 nil 时不定时发送
+	Location *time.Location     // cron 表达式使用的时区
+	Period   time.Duration      // 报表覆盖的时间段，默认 24h
+	Targets  []notify.Target    // 报表发送的目标
+	Allowed  []notify.Target    // 通过接口按需发送时允许指定的目标，包含 Targets
+	Token    string             // 通过接口发送报表需要的共享密钥，为空时接口不发送报表
+	Template *template.Template // 报表模板
+	Options  Options
+}
+
+// config 全局的报表配置。
+var config = Config{
+	Location: time.Local,
+	Period:   24 * time.Hour,
+}
+
+// Init 从环境变量加载报表配置，配置了 REPORT_SCHEDULE 和 REPORT_TARGETS 时启动定时发送任务：
+// REPORT_SCHEDULE 为 cron 表达式（如 "0 9 * * *"），REPORT_TIMEZONE 为其时区；
+// REPORT_PERIOD 为报表覆盖的时间段（默认 24h，周报可设置为 168h）；
+// REPORT_TARGETS 为发送目标，多个用逗号分隔，格式为 channel/target，省略渠道时为飞书目标；
+// REPORT_TEMPLATE 为模板文件路径；REPORT_TEAM_LABEL 为团队标签名；REPORT_TOP 为排行榜条数；
+// REPORT_TOKEN 为通过 /api/report 发送报表需要的共享密钥，REPORT_ALLOWED_TARGETS 为接口额外允许发送的目标。
+func Init() {
+	loadConfig()
+
+	val := os.Getenv("REPORT_SCHEDULE")
+	if val == "" {
+		return
+	}
+	schedule, err := cron.Parse(val)
+	if err != nil {
+		log.Printf("⚠️ Invalid REPORT_SCHEDULE %q, scheduled report disabled: %v", val, err)
+		return
+	}
+	if len(config.Targets) == 0 {
+		log.Println("⚠️ REPORT_SCHEDULE is set but REPORT_TARGETS is empty, scheduled report disabled")
+		return
+	}
+	if history.Default() == nil {
+		log.Println("⚠️ REPORT_SCHEDULE requires HISTORY_DB_PATH, scheduled report disabled")
+		return
+	}
+	config.Schedule = schedule
+
+	go scheduleLoop()
+	log.Printf("✅ Scheduled report enabled: schedule=%q, period=%v, targets=%v", val, config.Period, config.Targets)
+}
+
+// loadConfig 从环境变量加载报表的模板、时间段、目标等配置。
+func loadConfig() {
+	tmplText := ""
+	if path := os.Getenv("REPORT_TEMPLATE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️ Failed to read REPORT_TEMPLATE %s, using default template: %v", path, err)
+		} else {
+			tmplText = string(data)
+		}
+	}
+	tmpl, err := ParseTemplate(tmplText)
+	if err != nil {
+		log.Printf("⚠️ Invalid REPORT_TEMPLATE, using default template: %v", err)
+		tmpl, _ = ParseTemplate("")
+	}
+	config.Template = tmpl
+
+	if val := os.Getenv("REPORT_PERIOD"); val != "" {
+		if d, err := time.ParseDuration(val); err == nil && d > 0 {
+			config.Period = d
+		} else {
+			log.Printf("⚠️ Invalid REPORT_PERIOD %q, using default %v", val, config.Period)
+		}
+	}
+	if val := os.Getenv("REPORT_TIMEZONE"); val != "" {
+		if loc, err := time.LoadLocation(val); err == nil {
+			config.Location = loc
+		} else {
+			log.Printf("⚠️ Invalid REPORT_TIMEZONE %q, using local timezone", val)
+		}
+	}
+	config.Options.TeamLabel = os.Getenv("REPORT_TEAM_LABEL")
+	if val := os.Getenv("REPORT_TOP"); val != "" {
+		config.Options.Top, _ = strconv.Atoi(val)
+	}
+	config.Targets = ParseTargets(os.Getenv("REPORT_TARGETS"))
+	config.Allowed = slices.Concat(config.Targets, ParseTargets(os.Getenv("REPORT_ALLOWED_TARGETS")))
+	config.Token = os.Getenv("REPORT_TOKEN")
+}
+
+// allowed 判断目标是否允许通过接口按需发送报表。
+func allowed(target notify.Target) bool {
+	for _, t := range config.Allowed {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTargets 解析逗号分隔的发送目标，省略渠道时为飞书目标。
+func ParseTargets(value string) []notify.Target {
+	var targets []notify.Target
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		channel, target, ok := strings.Cut(item, "/")
+		if !ok {
+			channel, target = "feishu", item
+		}
+		targets = append(targets, notify.Target{Channel: channel, Target: strings.ToLower(target)})
+	}
+	return targets
+}
+
+// scheduleLoop 按 cron 表达式定时生成并发送报表。
+func scheduleLoop() {
+	for {
+		now := time.Now().In(config.Location)
+		next, ok := config.Schedule.Next(now, 366*24*time.Hour)
+		if !ok {
+			log.Println("⚠️ REPORT_SCHEDULE never fires within a year, scheduled report stopped")
+			return
+		}
+		time.Sleep(time.Until(next))
+
+		if err := SendReport(next.Add(-config.Period), next, config.Targets); err != nil {
+			log.Printf("❌ Failed to send scheduled report: %v", err)
+		}
+	}
+}
+
+// Build 使用全局配置生成并渲染 [from, to) 时间段的报表。
+func Build(from, to time.Time) (*Report, string, error) {
+	store := history.Default()
+	if store == nil {
+		return nil, "", errors.New("alert history not configured (HISTORY_DB_PATH)")
+	}
+	return BuildFrom(store, from, to)
+}
+
+// BuildFrom 使用指定的历史存储生成并渲染报表。
+func BuildFrom(store *history.Store, from, to time.Time) (*Report, string, error) {
+	r, err := Generate(store, from, to, config.Options)
+	if err != nil {
+		return nil, "", err
+	}
+	tmpl := config.Template
+	if tmpl == nil {
+		tmpl, _ = ParseTemplate("")
+	}
+	text, err := r.Render(tmpl)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, text, nil
+}
+
+// SendReport 生成 [from, to) 时间段的报表并发送到指定目标。
+func SendReport(from, to time.Time, targets []notify.Target) error {
+	_, text, err := Build(from, to)
+	if err != nil {
+		return err
+	}
+	return Send(text, targets)
+}
+
+// Send 将渲染后的报表发送到指定目标，返回所有发送失败的错误。
+func Send(text string, targets []notify.Target) error {
+	if len(targets) == 0 {
+		return errors.New("no report targets")
+	}
+
+	msg := notify.Message{Title: "告警报表", Text: text, Severity: "info"}
+	var errs []error
+	for _, t := range targets {
+		if err := notify.Send(t.Channel, t.Target, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t, err))
+			continue
+		}
+		log.Printf("📊 Sent report to %s", t)
+	}
+	return errors.Join(errs...)
+}
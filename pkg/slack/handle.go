@@ -0,0 +1,186 @@
+package slack
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将告警信息以 Block Kit 布局发送到指定的 Slack incoming webhook。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的 Slack webhook。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的 Slack webhook
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.SlackWebhook)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid slack targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "slack", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		msg := buildMessage(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标
+		for name, webhookURL := range sendTargets {
+			_ = delivery.Send(alert, "slack", name, func() error {
+				return msg.Send(name, webhookURL)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// buildMessage 为单个告警构建 Block Kit 消息：标题、摘要和描述、标签字段、触发日志代码块以及跳转按钮，
+// 放在按告警级别着色的附件中。
+func buildMessage(alert common.Alert, externalURL string, flap flapping.Status) *Message {
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	status := alert.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	severity := alert.Labels["severity"]
+
+	// 尝试从 Loki 查询实际日志内容
+	triggerLogs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	metricTrend := common.MetricTrendText(alert)
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(status), alertName)
+	if flap.Flapping {
+		title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(status), alertName)
+	}
+	icon := ":rotating_light:"
+	if status == "resolved" {
+		icon = ":white_check_mark:"
+	}
+
+	blocks := []Block{{Type: "header", Text: plainText(truncate(icon+" "+title, headerLimit))}}
+
+	// 摘要和描述
+	var text strings.Builder
+	if summary := alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&text, "*%s*\n", escape(summary))
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&text, "%s\n", escape(desc))
+	}
+	if metricTrend != "" {
+		fmt.Fprintf(&text, "*Metric trend:* %s\n", escape(metricTrend))
+	}
+	if text.Len() > 0 {
+		blocks = append(blocks, Block{Type: "section", Text: mrkdwn(truncate(strings.TrimSuffix(text.String(), "\n"), sectionLimit))})
+	}
+
+	// 状态、时间和标签字段，每个 section 块最多 10 个字段
+	fields := []*Text{mrkdwn("*Status*\n" + status)}
+	if !alert.StartsAt.IsZero() {
+		fields = append(fields, mrkdwn("*Started*\n"+alert.StartsAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	if status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		fields = append(fields, mrkdwn("*Resolved*\n"+alert.EndsAt.Local().Format("2006-01-02 15:04:05")))
+		fields = append(fields, mrkdwn("*Duration*\n"+alert.EndsAt.Sub(alert.StartsAt).Round(time.Second).String()))
+	}
+	for _, key := range sortedLabels(alert.Labels) {
+		fields = append(fields, mrkdwn(truncate(fmt.Sprintf("*%s*\n%s", key, escape(alert.Labels[key])), fieldLimit)))
+	}
+	for len(fields) > 0 {
+		n := min(len(fields), fieldsLimit)
+		blocks = append(blocks, Block{Type: "section", Fields: fields[:n]})
+		fields = fields[n:]
+	}
+
+	// 触发日志，超出长度限制时截断
+	if triggerLogs != "" {
+		logs := truncate(escape(strings.TrimRight(triggerLogs, "\n")), sectionLimit-len("*Trigger logs*\n``````"))
+		blocks = append(blocks, Block{Type: "section", Text: mrkdwn("*Trigger logs*\n```" + logs + "```")})
+	}
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		blocks = append(blocks, Block{Type: "context", Elements: []Element{{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf(":twisted_rightwards_arrows: Flapping: %d transitions in %v, muted until stable for %v",
+				flap.Transitions, flap.Window, flap.Stable),
+		}}})
+	}
+
+	// 跳转按钮
+	var buttons []Element
+	if alert.GeneratorURL != "" {
+		buttons = append(buttons, Element{Type: "button", Text: plainText("Open in Prometheus"), URL: alert.GeneratorURL, Style: "primary"})
+	}
+	if externalURL != "" {
+		buttons = append(buttons, Element{Type: "button", Text: plainText("Open Alertmanager"), URL: externalURL})
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, Block{Type: "actions", Elements: buttons})
+	}
+
+	return &Message{
+		Text:        title,
+		Attachments: []Attachment{{Color: SeverityColor(status, severity), Blocks: blocks}},
+	}
+}
+
+// sortedLabels 返回排序后的标签名，alertname 已在标题中展示，不再重复。
+func sortedLabels(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		if k != "alertname" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package slack
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBuildMessageFields(t *testing.T) {
+	labels := map[string]string{"alertname": "HighCPU"}
+	for i := 0; i < 20; i++ {
+		labels[fmt.Sprintf("label%02d", i)] = "value"
+	}
+	labels["long"] = strings.Repeat("<", 1000)
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      labels,
+		Annotations: map[string]string{"summary": "CPU > 90% & rising"},
+		StartsAt:    time.Now(),
+	}
+
+	msg := buildMessage(alert, "", flapping.Status{})
+	blocks := msg.Attachments[0].Blocks
+
+	// 状态、开始时间和 21 个标签共 23 个字段，拆分为 10、10、3 个字段的 section 块
+	var counts []int
+	for _, b := range blocks {
+		if len(b.Fields) > 0 {
+			counts = append(counts, len(b.Fields))
+		}
+	}
+	if fmt.Sprint(counts) != "[10 10 3]" {
+		t.Errorf("field counts = %v, want [10 10 3]", counts)
+	}
+
+	if blocks[1].Text == nil || blocks[1].Text.Text != "*CPU &gt; 90% &amp; rising*" {
+		t.Errorf("summary = %+v, want escaped", blocks[1].Text)
+	}
+	for _, b := range blocks {
+		for _, f := range b.Fields {
+			if !strings.HasPrefix(f.Text, "*long*") {
+				continue
+			}
+			if n := utf8.RuneCountInString(f.Text); n > fieldLimit {
+				t.Errorf("long label field has %d characters, want at most %d", n, fieldLimit)
+			}
+			if !strings.HasSuffix(f.Text, "&lt;…") {
+				t.Errorf("long label field ends with %q, want whole entity before …", f.Text[len(f.Text)-8:])
+			}
+		}
+	}
+}
+
+func TestBuildMessageTriggerLogs(t *testing.T) {
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "HighCPU"},
+		Annotations: map[string]string{"trigger_logs": strings.Repeat("a&b\n", 1000)},
+	}
+	msg := buildMessage(alert, "", flapping.Status{})
+	for _, b := range msg.Attachments[0].Blocks {
+		if b.Text == nil || !strings.HasPrefix(b.Text.Text, "*Trigger logs*") {
+			continue
+		}
+		if n := utf8.RuneCountInString(b.Text.Text); n > sectionLimit {
+			t.Errorf("trigger logs section has %d characters, want at most %d", n, sectionLimit)
+		}
+		if !strings.HasSuffix(b.Text.Text, "…```") || strings.Contains(b.Text.Text, "&b\n") {
+			t.Errorf("trigger logs should be escaped and truncated, ends with %q", b.Text.Text[len(b.Text.Text)-12:])
+		}
+		return
+	}
+	t.Error("trigger logs section not found")
+}
@@ -0,0 +1,184 @@
+// Package slack 提供通过 Slack incoming webhook 发送告警通知的功能，告警以 Block Kit 布局展示。
+package slack
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Block Kit 的长度限制（字符）。
+const (
+	headerLimit  = 150  // header 块文本
+	sectionLimit = 3000 // section 块文本
+	fieldLimit   = 2000 // section 块中的单个字段
+	fieldsLimit  = 10   // section 块中的字段数量
+	blocksLimit  = 50   // 消息中的块数量
+)
+
+// client 发送 Slack 消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Text Block Kit 文本对象。
+type Text struct {
+	Type  string `json:"type"` // plain_text 或 mrkdwn
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// plainText 创建 plain_text 文本对象。
+func plainText(text string) *Text {
+	return &Text{Type: "plain_text", Text: text, Emoji: true}
+}
+
+// mrkdwn 创建 mrkdwn 文本对象。
+func mrkdwn(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+// Block Block Kit 布局块，只包含本渠道使用的字段。
+type Block struct {
+	Type     string    `json:"type"`               // header、section、context、actions、divider
+	Text     *Text     `json:"text,omitempty"`     // header、section
+	Fields   []*Text   `json:"fields,omitempty"`   // section
+	Elements []Element `json:"elements,omitempty"` // context、actions
+}
+
+// Element context 块中的文本或 actions 块中的按钮。
+type Element struct {
+	Type  string `json:"type"` // mrkdwn 或 button
+	Text  any    `json:"text"` // mrkdwn 为字符串，button 为文本对象
+	URL   string `json:"url,omitempty"`
+	Style string `json:"style,omitempty"` // primary 或 danger
+}
+
+// Attachment 带颜色条的附件，告警内容放在附件的 blocks 中。
+type Attachment struct {
+	Color  string  `json:"color"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Message 定义了发送到 Slack incoming webhook 的消息结构。
+// text 用于通知和不支持 Block Kit 的客户端中的展示。
+type Message struct {
+	Text        string       `json:"text"`
+	Blocks      []Block      `json:"blocks,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Send 发送消息到指定的 Slack incoming webhook。
+// Slack 在成功时返回 200 和 ok，失败时返回错误状态码和错误原因（如 invalid_payload、no_service）。
+// 限流（429）和服务端错误可以重试，其他错误不再重试。
+func (m *Message) Send(name, webhookURL string) error {
+	body, _ := json.Marshal(m)
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("slack %s returned %s: %s", name, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// SeverityColor 根据告警状态和级别返回附件颜色条的颜色。
+func SeverityColor(status, severity string) string {
+	if status == "resolved" {
+		return "#2eb67d"
+	}
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "#e01e5a"
+	case "warning":
+		return "#ecb22e"
+	case "info":
+		return "#36c5f0"
+	default:
+		return "#9e9e9e"
+	}
+}
+
+// truncate 将字符串截断为最多 n 个字符，超出部分以省略号代替。
+// 截断位置落在 escape 生成的实体中间时，整个实体一起去掉，避免 Slack 显示残缺的 &am 等文本。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	cut := string([]rune(s)[:n-1])
+	if i := strings.LastIndexByte(cut, '&'); i >= 0 {
+		for _, entity := range []string{"&amp;", "&lt;", "&gt;"} {
+			if len(cut)-i < len(entity) && strings.HasPrefix(entity, cut[i:]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+	return cut + "…"
+}
+
+// escape 转义 mrkdwn 中的控制字符。
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// sections 将文本按行拆分为多个 section 块，每块不超过 section 块的长度限制。
+func sections(text string) []Block {
+	var blocks []Block
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			blocks = append(blocks, Block{Type: "section", Text: mrkdwn(strings.TrimSuffix(current.String(), "\n"))})
+			current.Reset()
+		}
+	}
+	for _, line := range strings.SplitAfter(escape(text), "\n") {
+		line = truncate(line, sectionLimit)
+		if utf8.RuneCountInString(current.String())+utf8.RuneCountInString(line) > sectionLimit {
+			flush()
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return blocks
+}
+
+// SendText 发送通用通知消息到指定名称的 Slack 目标，供升级、报表等后台任务使用。
+// 通用消息中的 @ 对象为飞书 open_id，Slack 不支持，会被忽略。
+func SendText(name string, msg notify.Message) error {
+	webhookURL, ok := common.SlackWebhook[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("slack target '%s' not found in configuration", name))
+	}
+
+	message := &Message{Text: truncate(msg.Text, sectionLimit)}
+	if msg.Title != "" {
+		message.Text = msg.Title
+		message.Blocks = append(message.Blocks, Block{Type: "header", Text: plainText(truncate(msg.Title, headerLimit))})
+	}
+	message.Blocks = append(message.Blocks, sections(msg.Text)...)
+
+	// 超出块数量限制时 Slack 会拒绝整条消息，只保留前面的块并提示内容被截断
+	if len(message.Blocks) > blocksLimit {
+		omitted := len(message.Blocks) - blocksLimit + 1
+		message.Blocks = append(message.Blocks[:blocksLimit-1], Block{Type: "context", Elements: []Element{{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("_%d more sections omitted_", omitted),
+		}}})
+	}
+	return message.Send(name, webhookURL)
+}
@@ -0,0 +1,173 @@
+package slack
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"short", "abc", 3, "abc"},
+		{"runes", "告警告警告警", 4, "告警告…"},
+		{"entity kept whole", "ab&amp;cd", 8, "ab&amp;…"},
+		{"entity cut", "ab&amp;cd", 6, "ab…"},
+		{"lt cut", "abc&lt;", 6, "abc…"},
+		{"gt cut at ampersand", "abcd&gt;", 6, "abcd…"},
+		{"plain ampersand", "a & b c", 5, "a & …"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.s, tt.n)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > tt.n {
+				t.Errorf("truncate(%q, %d) = %q, longer than %d", tt.s, tt.n, got, tt.n)
+			}
+		})
+	}
+}
+
+func TestSections(t *testing.T) {
+	line := strings.Repeat("a", 999) + "\n" // 1000 个字符
+	tests := []struct {
+		name    string
+		text    string
+		lengths []int
+	}{
+		{"single section", "line1\nline2", []int{11}},
+		{"split at limit", strings.Repeat(line, 4), []int{2999, 999}},
+		{"exactly at limit", strings.Repeat(line, 3), []int{2999}},
+		{"long line truncated", strings.Repeat("b", 4000), []int{sectionLimit}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := sections(tt.text)
+			if len(blocks) != len(tt.lengths) {
+				t.Fatalf("got %d sections, want %d", len(blocks), len(tt.lengths))
+			}
+			for i, b := range blocks {
+				if n := utf8.RuneCountInString(b.Text.Text); n != tt.lengths[i] || n > sectionLimit {
+					t.Errorf("section %d has %d characters, want %d", i, n, tt.lengths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSectionsEscapeBeforeTruncate(t *testing.T) {
+	// 转义后 "&" 变为 5 个字符，按转义后的长度拆分，且不会截断实体
+	text := strings.Repeat("&", 700)
+	blocks := sections(text)
+	if len(blocks) != 1 {
+		t.Fatalf("got %d sections, want 1", len(blocks))
+	}
+	got := blocks[0].Text.Text
+	if utf8.RuneCountInString(got) > sectionLimit {
+		t.Errorf("section has %d characters, want at most %d", utf8.RuneCountInString(got), sectionLimit)
+	}
+	if want := strings.Repeat("&amp;", 599) + "…"; got != want {
+		t.Errorf("section ends with %q, want whole entities followed by …", got[len(got)-10:])
+	}
+
+	blocks = sections("<@U123> a < b && c > d")
+	if got := blocks[0].Text.Text; got != "&lt;@U123&gt; a &lt; b &amp;&amp; c &gt; d" {
+		t.Errorf("escaped = %q", got)
+	}
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		ok        bool
+		permanent bool
+	}{
+		{"ok", http.StatusOK, "ok", true, false},
+		{"rate limited", http.StatusTooManyRequests, "rate_limited", false, false},
+		{"server error", http.StatusInternalServerError, "", false, false},
+		{"invalid payload", http.StatusBadRequest, "invalid_payload", false, true},
+		{"no service", http.StatusNotFound, "no_service", false, true},
+		{"channel archived", http.StatusGone, "channel_is_archived", false, true},
+	}
+	t.Setenv("SEND_RETRY_ATTEMPTS", "2")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := notify.Retry(func() error { return (&Message{Text: "hello"}).Send("ops", srv.URL) })
+			if (err == nil) != tt.ok {
+				t.Fatalf("err = %v, want ok=%v", err, tt.ok)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.body) {
+				t.Errorf("err = %v, want response body %q", err, tt.body)
+			}
+			want := 1
+			if !tt.ok && !tt.permanent {
+				want = 2
+			}
+			if requests != want {
+				t.Errorf("requests = %d, want %d", requests, want)
+			}
+		})
+	}
+}
+
+func TestSendTextBlocksLimit(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	saved := common.SlackWebhook
+	t.Cleanup(func() { common.SlackWebhook = saved })
+	common.SlackWebhook = map[string]string{"ops": srv.URL}
+
+	// 每行接近 section 长度限制，每行一个 section，加上标题共 81 个块
+	line := strings.Repeat("x", sectionLimit-1) + "\n"
+	if err := SendText("ops", notify.Message{Title: "Weekly report", Text: strings.Repeat(line, 80)}); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if len(got.Blocks) != blocksLimit {
+		t.Fatalf("got %d blocks, want %d", len(got.Blocks), blocksLimit)
+	}
+	if got.Blocks[0].Type != "header" {
+		t.Errorf("first block = %s, want header", got.Blocks[0].Type)
+	}
+	last := got.Blocks[blocksLimit-1]
+	if last.Type != "context" || len(last.Elements) != 1 || last.Elements[0].Text != "_32 more sections omitted_" {
+		t.Errorf("last block = %+v, want omitted notice", last)
+	}
+
+	if err := SendText("missing", notify.Message{Text: "x"}); err == nil {
+		t.Error("SendText to unknown target should fail")
+	}
+}
@@ -0,0 +1,102 @@
+package snmptrap
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并为每个告警发送一个 trap：firing 告警为 alertFiring，resolved 告警为 alertResolved。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认发送到所有已配置的 SNMP 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则发送到所有配置的 SNMP 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.SNMPTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid snmp targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "snmp", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		notification := notificationFiring
+		if alert.Status == "resolved" {
+			notification = notificationResolved
+		}
+		vars := variables(alert, flap.Flapping)
+
+		// 发送到所有目标
+		for name, addr := range sendTargets {
+			_ = delivery.Send(alert, "snmp", name, func() error {
+				return send(name, addr, notification, vars)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// variables 返回告警的变量绑定：告警名称、状态、级别、摘要、描述、指纹、实例和开始时间。
+// 抖动中的告警在摘要前加上 [FLAPPING] 标记。
+func variables(alert common.Alert, flapping bool) []gosnmp.SnmpPDU {
+	summary := alert.Annotations["summary"]
+	if flapping {
+		summary = "[FLAPPING] " + summary
+	}
+
+	var startsAt string
+	if !alert.StartsAt.IsZero() {
+		startsAt = alert.StartsAt.UTC().Format(time.RFC3339)
+	}
+
+	return []gosnmp.SnmpPDU{
+		text(objectName, alert.Labels["alertname"]),
+		text(objectStatus, alert.Status),
+		text(objectSeverity, alert.Labels["severity"]),
+		text(objectSummary, summary),
+		text(objectDescription, alert.Annotations["description"]),
+		text(objectFingerprint, alert.Fingerprint),
+		text(objectInstance, alert.Labels["instance"]),
+		text(objectStartsAt, startsAt),
+	}
+}
@@ -0,0 +1,200 @@
+// Package snmptrap 提供发送 SNMP trap 的功能，供只能接收 SNMPv2c/v3 trap 的传统网管系统使用。
+// trap 定义见 deploy/mib/ALERTMANAGER-ADAPTER-MIB.txt，所有 OID 位于 SNMP_ENTERPRISE_OID 之下。
+package snmptrap
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// 通知的 OID：<enterprise>.0.<n>。
+const (
+	notificationFiring   = 1 // alertFiring
+	notificationResolved = 2 // alertResolved
+	notificationMessage  = 3 // adapterMessage，升级、报表等后台任务的通用消息
+)
+
+// 变量绑定的 OID：<enterprise>.1.<n>。
+const (
+	objectName        = 1 // alertName
+	objectStatus      = 2 // alertStatus
+	objectSeverity    = 3 // alertSeverity
+	objectSummary     = 4 // alertSummary
+	objectDescription = 5 // alertDescription
+	objectFingerprint = 6 // alertFingerprint
+	objectInstance    = 7 // alertInstance
+	objectStartsAt    = 8 // alertStartsAt
+)
+
+// 标准 trap 变量的 OID。
+const (
+	oidSysUpTime   = ".1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID = ".1.3.6.1.6.3.1.1.4.1.0"
+)
+
+// valueLimit 字符串变量的长度限制（字节），MIB 中定义为 SnmpAdminString (SIZE (0..255))。
+const valueLimit = 255
+
+// defaultPort trap 接收方的默认端口。
+const defaultPort = 162
+
+// start adapter 启动时间，用于 sysUpTime 和 SNMPv3 的引擎时间。
+var start = time.Now()
+
+// engineBoots SNMPv3 的引擎启动次数（snmpEngineBoots）。adapter 不保存状态，以启动时间的 Unix 秒数作为启动次数：
+// 每次重启都会增大，引擎时间同时从 0 开始计算，接收方按 RFC 3414 的时效性检查不会丢弃重启后的 trap。
+// Unix 秒数在 2038 年之前不会超过 snmpEngineBoots 的上限 2147483647。
+var engineBoots = uint32(start.Unix())
+
+// oid 返回企业 OID 之下的 OID。
+func oid(parts ...int) string {
+	var b strings.Builder
+	b.WriteString("." + common.SNMPConfig.EnterpriseOID)
+	for _, p := range parts {
+		b.WriteString("." + strconv.Itoa(p))
+	}
+	return b.String()
+}
+
+// text 返回字符串类型的变量绑定，超过长度限制时在 UTF-8 字符边界处截断。
+func text(object int, value string) gosnmp.SnmpPDU {
+	if len(value) > valueLimit {
+		n := valueLimit
+		for n > 0 && !utf8.RuneStart(value[n]) {
+			n--
+		}
+		value = value[:n]
+	}
+	return gosnmp.SnmpPDU{Name: oid(1, object), Type: gosnmp.OctetString, Value: value}
+}
+
+// authProtocols SNMPv3 认证协议。
+var authProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"":       gosnmp.NoAuth,
+	"none":   gosnmp.NoAuth,
+	"md5":    gosnmp.MD5,
+	"sha":    gosnmp.SHA,
+	"sha224": gosnmp.SHA224,
+	"sha256": gosnmp.SHA256,
+	"sha384": gosnmp.SHA384,
+	"sha512": gosnmp.SHA512,
+}
+
+// privProtocols SNMPv3 加密协议。
+var privProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"":        gosnmp.NoPriv,
+	"none":    gosnmp.NoPriv,
+	"des":     gosnmp.DES,
+	"aes":     gosnmp.AES,
+	"aes192":  gosnmp.AES192,
+	"aes256":  gosnmp.AES256,
+	"aes192c": gosnmp.AES192C,
+	"aes256c": gosnmp.AES256C,
+}
+
+// newClient 按 SNMP 配置创建发送到指定地址的客户端。
+func newClient(addr string) (*gosnmp.GoSNMP, error) {
+	cfg := common.SNMPConfig
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, portText = addr, strconv.Itoa(defaultPort)
+	}
+	port, err := strconv.ParseUint(portText, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in %q", addr)
+	}
+
+	client := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      uint16(port),
+		Transport: "udp",
+		Community: cfg.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   cfg.Timeout,
+		Retries:   cfg.Retries,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	if cfg.Version != common.SNMPv3 {
+		return client, nil
+	}
+
+	auth, ok := authProtocols[cfg.AuthProtocol]
+	if !ok {
+		return nil, fmt.Errorf("unknown SNMP_AUTH_PROTOCOL %q", cfg.AuthProtocol)
+	}
+	priv, ok := privProtocols[cfg.PrivProtocol]
+	if !ok {
+		return nil, fmt.Errorf("unknown SNMP_PRIV_PROTOCOL %q", cfg.PrivProtocol)
+	}
+
+	client.Version = gosnmp.Version3
+	client.SecurityModel = gosnmp.UserSecurityModel
+	switch {
+	case auth == gosnmp.NoAuth:
+		client.MsgFlags = gosnmp.NoAuthNoPriv
+	case priv == gosnmp.NoPriv:
+		client.MsgFlags = gosnmp.AuthNoPriv
+	default:
+		client.MsgFlags = gosnmp.AuthPriv
+	}
+	params := &gosnmp.UsmSecurityParameters{
+		UserName:                 cfg.Username,
+		AuthenticationProtocol:   auth,
+		AuthenticationPassphrase: cfg.AuthPassword,
+		PrivacyProtocol:          priv,
+		PrivacyPassphrase:        cfg.PrivPassword,
+	}
+	// trap 的权威引擎是发送方，使用配置的引擎 ID；INFORM 的权威引擎是接收方，由客户端自动发现
+	if !cfg.Inform {
+		params.AuthoritativeEngineID = string(cfg.EngineID)
+		params.AuthoritativeEngineBoots = engineBoots
+		params.AuthoritativeEngineTime = uint32(time.Since(start).Seconds())
+	}
+	client.SecurityParameters = params
+	return client, nil
+}
+
+// send 发送一个通知到指定地址，变量绑定前自动加上 sysUpTime 和 snmpTrapOID。
+// 配置错误不再重试；INFORM 未收到确认可以重试。
+func send(name, addr string, notification int, vars []gosnmp.SnmpPDU) error {
+	client, err := newClient(addr)
+	if err != nil {
+		return notify.Permanent(fmt.Errorf("snmp target '%s': %w", name, err))
+	}
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to snmp target %s (%s): %w", name, addr, err)
+	}
+	defer client.Conn.Close()
+
+	pdus := append([]gosnmp.SnmpPDU{
+		{Name: oidSysUpTime, Type: gosnmp.TimeTicks, Value: uint32(time.Since(start) / (10 * time.Millisecond))},
+		{Name: oidSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: oid(0, notification)},
+	}, vars...)
+	if _, err := client.SendTrap(gosnmp.SnmpTrap{Variables: pdus, IsInform: common.SNMPConfig.Inform}); err != nil {
+		return fmt.Errorf("failed to send snmp trap to %s (%s): %w", name, addr, err)
+	}
+	return nil
+}
+
+// SendText 发送通用通知消息到指定名称的 SNMP 目标，供升级、报表等后台任务使用。
+// 消息以 adapterMessage 通知发送，标题和正文分别放在 alertSummary 和 alertDescription 中。
+func SendText(name string, msg notify.Message) error {
+	addr, ok := common.SNMPTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("snmp target '%s' not found in configuration", name))
+	}
+
+	return send(name, addr, notificationMessage, []gosnmp.SnmpPDU{
+		text(objectSeverity, msg.Severity),
+		text(objectSummary, msg.Title),
+		text(objectDescription, strings.ReplaceAll(msg.Text, "\n", " | ")),
+	})
+}
@@ -0,0 +1,178 @@
+package snmptrap
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const testEnterprise = "1.3.6.1.4.1.32473.1"
+
+// trapReceiver 本地 trap 接收方，收到的 trap 写入 channel。
+func trapReceiver(t *testing.T, params *gosnmp.GoSNMP) (string, <-chan *gosnmp.SnmpPacket) {
+	t.Helper()
+	// TrapListener 不暴露监听的端口，先获取一个空闲端口
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	traps := make(chan *gosnmp.SnmpPacket, 10)
+	tl := gosnmp.NewTrapListener()
+	tl.Params = params
+	tl.OnNewTrap = func(p *gosnmp.SnmpPacket, _ *net.UDPAddr) { traps <- p }
+	go func() {
+		if err := tl.Listen(addr); err != nil {
+			t.Errorf("trap listener: %v", err)
+		}
+	}()
+	select {
+	case <-tl.Listening():
+	case <-time.After(5 * time.Second):
+		t.Fatal("trap listener did not start")
+	}
+	t.Cleanup(tl.Close)
+	return addr, traps
+}
+
+// useSNMP 在测试期间使用指定版本的 SNMP 配置和唯一的目标。
+func useSNMP(t *testing.T, version, addr string) {
+	t.Helper()
+	cfg, targets := common.SNMPConfig, common.SNMPTargets
+	t.Cleanup(func() { common.SNMPConfig, common.SNMPTargets = cfg, targets })
+
+	common.SNMPTargets = map[string]string{"nms": addr}
+	common.SNMPConfig.Version = version
+	common.SNMPConfig.Community = "public"
+	common.SNMPConfig.EnterpriseOID = testEnterprise
+	common.SNMPConfig.Inform = false
+	common.SNMPConfig.Timeout = time.Second
+	common.SNMPConfig.Username = "adapter"
+	common.SNMPConfig.EngineID = []byte("\x80\x00\x7e\xd9\x04alertmanager")
+}
+
+// receive 等待下一个 trap。
+func receive(t *testing.T, traps <-chan *gosnmp.SnmpPacket) *gosnmp.SnmpPacket {
+	t.Helper()
+	select {
+	case p := <-traps:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for trap")
+		return nil
+	}
+}
+
+// value 将变量绑定的值转换为字符串。
+func value(pdu gosnmp.SnmpPDU) string {
+	if b, ok := pdu.Value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(pdu.Value)
+}
+
+func TestHandlerVarbinds(t *testing.T) {
+	addr, traps := trapReceiver(t, &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "public", Logger: gosnmp.Default.Logger})
+	useSNMP(t, common.SNMPv2c, addr)
+
+	startsAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		status       string
+		notification int
+		flapping     bool
+	}{
+		{"firing", notificationFiring, false},
+		{"resolved", notificationResolved, false},
+	} {
+		t.Run(tt.status, func(t *testing.T) {
+			payload := fmt.Sprintf(`{"status": %q, "alerts": [{"status": %q, "fingerprint": "snmp-%s",
+				"startsAt": "2024-05-01T10:00:00Z",
+				"labels": {"alertname": "DiskFull", "severity": "critical", "instance": "db-1"},
+				"annotations": {"summary": "disk full", "description": "%s"}}]}`,
+				tt.status, tt.status, tt.status, strings.Repeat("磁盘", 100))
+			rec := httptest.NewRecorder()
+			Handler(rec, httptest.NewRequest(http.MethodPost, "/snmp", strings.NewReader(payload)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Handler returned %d", rec.Code)
+			}
+
+			p := receive(t, traps)
+			if p.PDUType != gosnmp.SNMPv2Trap {
+				t.Errorf("PDU type = %v, want SNMPv2-Trap", p.PDUType)
+			}
+			want := []struct {
+				oid   string
+				value string
+			}{
+				{oidSysUpTime, ""},
+				{oidSnmpTrapOID, fmt.Sprintf(".%s.0.%d", testEnterprise, tt.notification)},
+				{"." + testEnterprise + ".1.1", "DiskFull"},
+				{"." + testEnterprise + ".1.2", tt.status},
+				{"." + testEnterprise + ".1.3", "critical"},
+				{"." + testEnterprise + ".1.4", "disk full"},
+				{"." + testEnterprise + ".1.5", strings.Repeat("磁盘", 42) + "磁"}, // 截断到 255 字节内的字符边界
+				{"." + testEnterprise + ".1.6", "snmp-" + tt.status},
+				{"." + testEnterprise + ".1.7", "db-1"},
+				{"." + testEnterprise + ".1.8", startsAt.Format(time.RFC3339)},
+			}
+			if len(p.Variables) != len(want) {
+				t.Fatalf("got %d varbinds, want %d: %+v", len(p.Variables), len(want), p.Variables)
+			}
+			for i, w := range want {
+				got := p.Variables[i]
+				if got.Name != w.oid {
+					t.Errorf("varbind %d oid = %s, want %s", i, got.Name, w.oid)
+				}
+				if w.value != "" && value(got) != w.value {
+					t.Errorf("varbind %s = %q, want %q", w.oid, value(got), w.value)
+				}
+			}
+			if p.Variables[0].Type != gosnmp.TimeTicks {
+				t.Errorf("sysUpTime type = %v, want TimeTicks", p.Variables[0].Type)
+			}
+		})
+	}
+}
+
+// TestTrapV3EngineBoots SNMPv3 trap 以启动时间作为引擎启动次数，重启后增大；引擎时间从启动开始计算。
+func TestTrapV3EngineBoots(t *testing.T) {
+	addr, traps := trapReceiver(t, &gosnmp.GoSNMP{
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           gosnmp.NoAuthNoPriv,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{UserName: "adapter"},
+		Logger:             gosnmp.Default.Logger,
+	})
+	useSNMP(t, common.SNMPv3, addr)
+
+	if err := SendText("nms", notify.Message{Title: "[ESCALATED] DiskFull", Text: "escalated", Severity: "critical"}); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	p := receive(t, traps)
+	params, ok := p.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		t.Fatalf("security parameters = %T", p.SecurityParameters)
+	}
+	if params.AuthoritativeEngineBoots != uint32(start.Unix()) || params.AuthoritativeEngineBoots <= 1 {
+		t.Errorf("engine boots = %d, want process start time %d", params.AuthoritativeEngineBoots, start.Unix())
+	}
+	if uptime := uint32(time.Since(start).Seconds()); params.AuthoritativeEngineTime > uptime {
+		t.Errorf("engine time = %d, want at most %d", params.AuthoritativeEngineTime, uptime)
+	}
+	if params.AuthoritativeEngineID != string(common.SNMPConfig.EngineID) {
+		t.Errorf("engine id = %x, want %x", params.AuthoritativeEngineID, common.SNMPConfig.EngineID)
+	}
+	if got := p.Variables[1]; value(got) != fmt.Sprintf(".%s.0.%d", testEnterprise, notificationMessage) {
+		t.Errorf("snmpTrapOID = %v, want adapterMessage", got.Value)
+	}
+}
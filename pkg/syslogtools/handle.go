@@ -2,7 +2,7 @@ package syslogtools
 
 import (
 	"alertmanagerWebhookAdapter/pkg/common"
-	"alertmanagerWebhookAdapter/pkg/loki"
+	"alertmanagerWebhookAdapter/pkg/delivery"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -32,25 +32,8 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 处理每个告警 - 单独发送到 syslog，避免消息过大
-	targetParam := r.URL.Query().Get("target")
-	var targetAddrs map[string]string
-
-	if targetParam != "" {
-		// 解析指定的目标
-		targetAddrs = make(map[string]string)
-		targets := strings.Split(targetParam, ",")
-		for _, t := range targets {
-			t = strings.TrimSpace(strings.ToLower(t))
-			if addr, exists := common.SyslogWebhook[t]; exists {
-				targetAddrs[t] = addr
-			} else {
-				log.Printf("⚠️ Target '%s' not found in configuration", t)
-			}
-		}
-	} else {
-		// 广播到所有配置的 syslog
-		targetAddrs = common.SyslogWebhook
-	}
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的 syslog
+	targetAddrs := common.SelectTargets(r.URL.Query().Get("target"), common.SyslogWebhook)
 
 	// 如果没有有效的目标，直接返回
 	if len(targetAddrs) == 0 {
@@ -64,6 +47,15 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 
 	// 逐个处理告警
 	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "syslog", targetAddrs)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
 		// 为每个告警构建消息
 		var builder strings.Builder
 
@@ -88,36 +80,11 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			desc = "No description"
 		}
 
-		triggerLogs := alert.Annotations["trigger_logs"]
-
 		// 尝试从 Loki 查询实际日志内容
-		if common.LokiConfig.Enabled && common.LokiClient != nil {
-			logQuery := alert.Annotations["log_query"]
-			if logQuery != "" {
-				logs, err := common.LokiClient.QueryLogs(
-					logQuery,
-					common.LokiConfig.LogLimit,
-					common.LokiConfig.QueryRange,
-				)
-				if err != nil {
-					log.Printf("⚠️ Failed to query Loki for alert %s: %v", alertName, err)
-					// 查询失败时保留原有的 trigger_logs 或添加错误提示
-					if triggerLogs == "" {
-						triggerLogs = fmt.Sprintf("(Loki query failed: %v)", err)
-					}
-				} else if len(logs) > 0 {
-					// 查询成功，格式化日志内容
-					formattedLogs := loki.FormatLogs(logs, common.LokiConfig.LogLimit)
-					triggerLogs = formattedLogs
-					log.Printf("✅ Queried %d logs from Loki for alert %s", len(logs), alertName)
-				} else {
-					// 查询成功但没有日志
-					if triggerLogs == "" {
-						triggerLogs = "(No matching logs in query range)"
-					}
-				}
-			}
-		}
+		triggerLogs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)")
+
+		// 尝试从 Prometheus 查询指标的当前值和近期趋势
+		metricTrend := common.MetricTrendText(alert)
 
 		builder.WriteString(fmt.Sprintf("Alert: %s | Status: %s | Summary: %s | Description: %s",
 			alertName, status, summary, desc))
@@ -127,15 +94,24 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			builder.WriteString(fmt.Sprintf(" | Trigger Logs: %s", triggerLogs))
 		}
 
+		// 如果有指标趋势信息，则添加显示
+		if metricTrend != "" {
+			builder.WriteString(fmt.Sprintf(" | Metric Trend: %s", metricTrend))
+		}
+
+		// 抖动中的告警附带抖动提示
+		if flap.Flapping {
+			builder.WriteString(fmt.Sprintf(" | Flapping: %d transitions in %v, muted until stable for %v",
+				flap.Transitions, flap.Window, flap.Stable))
+		}
+
 		text := builder.String()
 
 		// 发送到所有目标
-		for name, syslogAddr := range targetAddrs {
-			if err := sendToSyslogServer(syslogAddr, text); err != nil {
-				log.Printf("❌ Failed to send alert %s to %s: %v", alertName, name, err)
-			} else {
-				log.Printf("✅ Sent alert %s to syslog %s", alertName, name)
-			}
+		for name, syslogAddr := range sendTargets {
+			_ = delivery.Send(alert, "syslog", name, func() error {
+				return sendToSyslogServer(syslogAddr, text)
+			})
 		}
 	}
 
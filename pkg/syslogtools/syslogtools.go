@@ -2,17 +2,54 @@
 package syslogtools
 
 import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
 	"fmt"
 	"log"
 	"log/syslog"
+	"strings"
 )
 
 // Protocol 定义了发送 syslog 的协议，默认为 "udp"。
 // UDP 避免了 TCP octet counting 的 framing 问题
 var Protocol = "udp"
 
-// sendToSyslogServer 发送日志到指定的 syslog 服务器地址。
+// sendToSyslogServer 发送日志到指定的 syslog 服务器地址，使用 LOG_ALERT 严重级别。
 func sendToSyslogServer(url, text string) error {
+	return sendWithSeverity(url, text, syslog.LOG_ALERT)
+}
+
+// severities 通知严重级别到 syslog 严重级别的映射，告警级别按相近的 syslog 级别处理。
+var severities = map[string]syslog.Priority{
+	"emerg":    syslog.LOG_EMERG,
+	"alert":    syslog.LOG_ALERT,
+	"crit":     syslog.LOG_CRIT,
+	"critical": syslog.LOG_CRIT,
+	"err":      syslog.LOG_ERR,
+	"error":    syslog.LOG_ERR,
+	"warning":  syslog.LOG_WARNING,
+	"notice":   syslog.LOG_NOTICE,
+	"info":     syslog.LOG_INFO,
+}
+
+// SendText 发送通用通知消息到指定名称的 syslog 目标，供升级等后台任务使用。
+// 消息的 Severity 决定 syslog 严重级别，未知级别使用 LOG_ALERT。
+func SendText(name string, msg notify.Message) error {
+	addr, ok := common.SyslogWebhook[name]
+	if !ok {
+		return fmt.Errorf("syslog target '%s' not found in configuration", name)
+	}
+
+	severity, ok := severities[strings.ToLower(msg.Severity)]
+	if !ok {
+		severity = syslog.LOG_ALERT
+	}
+	text := strings.ReplaceAll(msg.Text, "\n", " | ")
+	return sendWithSeverity(addr, text, severity)
+}
+
+// sendWithSeverity 以指定的严重级别发送日志到 syslog 服务器。
+func sendWithSeverity(url, text string, severity syslog.Priority) error {
 	log.Printf("Protocol: %v, url:%v, text: %v", Protocol, url, text)
 	// 连接到本地 syslog 服务，使用 LOG_LOCAL0 作为日志设施
 	server, err := syslog.Dial(Protocol, url, syslog.LOG_LOCAL0, "")
@@ -26,8 +63,28 @@ func sendToSyslogServer(url, text string) error {
 	}()
 
 	// 发送信息到 syslog
-	if err := server.Alert(text); err != nil {
+	if err := writeWithSeverity(server, text, severity); err != nil {
 		return fmt.Errorf("发送日志失败 protocol: %s, url: %s: %w", Protocol, url, err)
 	}
 	return nil
 }
+
+// writeWithSeverity 按严重级别调用对应的 syslog 写入方法。
+func writeWithSeverity(w *syslog.Writer, text string, severity syslog.Priority) error {
+	switch severity {
+	case syslog.LOG_EMERG:
+		return w.Emerg(text)
+	case syslog.LOG_CRIT:
+		return w.Crit(text)
+	case syslog.LOG_ERR:
+		return w.Err(text)
+	case syslog.LOG_WARNING:
+		return w.Warning(text)
+	case syslog.LOG_NOTICE:
+		return w.Notice(text)
+	case syslog.LOG_INFO:
+		return w.Info(text)
+	default:
+		return w.Alert(text)
+	}
+}
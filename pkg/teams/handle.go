@@ -0,0 +1,225 @@
+package teams
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将告警信息以 Adaptive Card 发送到指定的 Teams webhook。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的 Teams webhook。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的 Teams webhook
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.TeamsWebhook)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid teams targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "teams", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		card := buildCard(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标
+		for name, webhookURL := range sendTargets {
+			_ = delivery.Send(alert, "teams", name, func() error {
+				return card.Send(name, webhookURL)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// hiddenAnnotations 不在注解列表中展示的注解：摘要和描述单独展示，触发日志放在可折叠区域中。
+var hiddenAnnotations = map[string]bool{
+	"summary":      true,
+	"description":  true,
+	"trigger_logs": true,
+	"log_query":    true,
+}
+
+// buildCard 为单个告警构建 Adaptive Card：按级别着色的标题区域、摘要和描述、
+// 标签和注解列表、可折叠的触发日志以及跳转按钮。
+func buildCard(alert common.Alert, externalURL string, flap flapping.Status) *Card {
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	status := alert.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	severity := alert.Labels["severity"]
+
+	// 尝试从 Loki 查询实际日志内容
+	triggerLogs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	metricTrend := common.MetricTrendText(alert)
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(status), alertName)
+	if flap.Flapping {
+		title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(status), alertName)
+	}
+
+	card := NewCard()
+
+	// 标题区域按告警级别着色
+	style, color := SeverityStyle(status, severity)
+	subtitle := "Status: " + status
+	if severity != "" {
+		subtitle += " | Severity: " + severity
+	}
+	if !alert.StartsAt.IsZero() {
+		subtitle += " | Started: " + alert.StartsAt.Local().Format("2006-01-02 15:04:05")
+	}
+	if status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		subtitle += " | Duration: " + alert.EndsAt.Sub(alert.StartsAt).Round(time.Second).String()
+	}
+	card.Body = append(card.Body, map[string]interface{}{
+		"type":  "Container",
+		"style": style,
+		"bleed": true,
+		"items": []map[string]interface{}{
+			{"type": "TextBlock", "text": title, "size": "Large", "weight": "Bolder", "color": color, "wrap": true},
+			{"type": "TextBlock", "text": subtitle, "isSubtle": true, "spacing": "None", "wrap": true},
+		},
+	})
+
+	// 摘要、描述和指标趋势
+	if summary := alert.Annotations["summary"]; summary != "" {
+		card.AddText(summary, map[string]interface{}{"weight": "Bolder"})
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		card.AddText(desc, nil)
+	}
+	if metricTrend != "" {
+		card.AddText("**Metric trend:** "+metricTrend, nil)
+	}
+	if flap.Flapping {
+		card.AddText(fmt.Sprintf("🔀 Flapping: %d transitions in %v, muted until stable for %v",
+			flap.Transitions, flap.Window, flap.Stable), map[string]interface{}{"isSubtle": true})
+	}
+
+	// 标签和注解
+	card.AddFacts("Labels", facts(alert.Labels, map[string]bool{"alertname": true}))
+	card.AddFacts("Annotations", facts(alert.Annotations, hiddenAnnotations))
+
+	// 触发日志放在默认折叠的区域中，点击按钮展开
+	if triggerLogs != "" {
+		addLogs(card, triggerLogs)
+	}
+
+	// 跳转按钮
+	if alert.GeneratorURL != "" {
+		card.AddOpenURL("Open in Prometheus", alert.GeneratorURL)
+	}
+	if externalURL != "" {
+		card.AddOpenURL("Open Alertmanager", externalURL)
+	}
+	return card
+}
+
+// facts 将键值对转换为排序后的 FactSet 条目，跳过 skip 中的键。
+func facts(kv map[string]string, skip map[string]bool) []map[string]string {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		if !skip[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]map[string]string, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, map[string]string{"title": k, "value": kv[k]})
+	}
+	return result
+}
+
+// addLogs 添加可折叠的触发日志区域。每行日志使用一个等宽文本块，超过 maxLogLines 行时截断。
+func addLogs(card *Card, triggerLogs string) {
+	lines := strings.Split(strings.TrimRight(triggerLogs, "\n"), "\n")
+	label := fmt.Sprintf("Trigger logs (%d lines)", len(lines))
+	if len(lines) > maxLogLines {
+		lines = append(lines[:maxLogLines], fmt.Sprintf("... %d more lines", len(lines)-maxLogLines))
+	}
+
+	items := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, map[string]interface{}{
+			"type":     "TextBlock",
+			"text":     line,
+			"fontType": "Monospace",
+			"size":     "Small",
+			"spacing":  "None",
+			"wrap":     true,
+		})
+	}
+
+	card.Body = append(card.Body,
+		map[string]interface{}{
+			"type":    "ActionSet",
+			"spacing": "Medium",
+			"actions": []map[string]interface{}{{
+				"type":           "Action.ToggleVisibility",
+				"title":          label,
+				"targetElements": []string{"trigger-logs"},
+			}},
+		},
+		map[string]interface{}{
+			"type":      "Container",
+			"id":        "trigger-logs",
+			"isVisible": false,
+			"style":     "emphasis",
+			"items":     items,
+		},
+	)
+}
@@ -0,0 +1,139 @@
+package teams
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// element 解析后的卡片元素，用于按 JSON 结构检查卡片。
+type element map[string]interface{}
+
+// walk 遍历卡片 body 中的所有元素，包括 Container 的 items 和 ActionSet 的 actions。
+func walk(elements []interface{}, fn func(element)) {
+	for _, e := range elements {
+		el, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fn(el)
+		for _, key := range []string{"items", "actions"} {
+			if children, ok := el[key].([]interface{}); ok {
+				walk(children, fn)
+			}
+		}
+	}
+}
+
+// cardBody 将卡片编码为 JSON 后解析 body，与 Teams 收到的内容一致。
+func cardBody(t *testing.T, card *Card) []interface{} {
+	t.Helper()
+	data, err := json.Marshal(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Body []interface{} `json:"body"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Body
+}
+
+func TestTriggerLogsToggle(t *testing.T) {
+	alert := common.Alert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "HighCPU", "severity": "critical"},
+		Annotations: map[string]string{"trigger_logs": "line1\nline2\n"},
+	}
+	body := cardBody(t, buildCard(alert, "", flapping.Status{}))
+
+	containers := make(map[string]element)
+	var targets []string
+	walk(body, func(el element) {
+		if id, ok := el["id"].(string); ok && el["type"] == "Container" {
+			containers[id] = el
+		}
+		if el["type"] == "Action.ToggleVisibility" {
+			for _, target := range el["targetElements"].([]interface{}) {
+				targets = append(targets, target.(string))
+			}
+			if el["title"] != "Trigger logs (2 lines)" {
+				t.Errorf("toggle title = %v", el["title"])
+			}
+		}
+	})
+
+	if len(targets) != 1 {
+		t.Fatalf("toggle targets = %v, want one", targets)
+	}
+	container, ok := containers[targets[0]]
+	if !ok {
+		t.Fatalf("toggle target %q does not match any container id, containers: %v", targets[0], containers)
+	}
+	if container["isVisible"] != false {
+		t.Errorf("trigger logs container isVisible = %v, want hidden by default", container["isVisible"])
+	}
+	if items := container["items"].([]interface{}); len(items) != 2 {
+		t.Errorf("trigger logs container has %d lines, want 2", len(items))
+	}
+}
+
+func TestTriggerLogsTruncated(t *testing.T) {
+	card := NewCard()
+	addLogs(card, strings.Repeat("log line\n", maxLogLines+10))
+
+	items := card.Body[1]["items"].([]map[string]interface{})
+	if len(items) != maxLogLines+1 {
+		t.Fatalf("got %d lines, want %d", len(items), maxLogLines+1)
+	}
+	if last := items[maxLogLines]["text"]; last != "... 10 more lines" {
+		t.Errorf("last line = %v, want truncation notice", last)
+	}
+	title := card.Body[0]["actions"].([]map[string]interface{})[0]["title"]
+	if title != fmt.Sprintf("Trigger logs (%d lines)", maxLogLines+10) {
+		t.Errorf("toggle title = %v, want total line count", title)
+	}
+}
+
+func TestBuildCardHeader(t *testing.T) {
+	alert := common.Alert{
+		Status: "resolved",
+		Labels: map[string]string{"alertname": "HighCPU", "severity": "critical", "instance": "node-1"},
+		Annotations: map[string]string{
+			"summary":      "CPU high",
+			"runbook":      "https://runbook",
+			"trigger_logs": "",
+			"log_query":    `{app="x"}`,
+		},
+	}
+	card := buildCard(alert, "http://alertmanager", flapping.Status{})
+
+	header := card.Body[0]
+	if header["style"] != "good" {
+		t.Errorf("header style = %v, want good for resolved", header["style"])
+	}
+	title := header["items"].([]map[string]interface{})[0]
+	if title["text"] != "[RESOLVED] HighCPU" || title["color"] != "Good" {
+		t.Errorf("title = %v", title)
+	}
+
+	var facts []string
+	for _, el := range card.Body {
+		if el["type"] == "FactSet" {
+			for _, f := range el["facts"].([]map[string]string) {
+				facts = append(facts, f["title"])
+			}
+		}
+	}
+	if got := strings.Join(facts, ","); got != "instance,severity,runbook" {
+		t.Errorf("facts = %s, want labels without alertname and annotations without hidden ones", got)
+	}
+	if len(card.Actions) != 1 || card.Actions[0]["url"] != "http://alertmanager" {
+		t.Errorf("actions = %v, want Open Alertmanager", card.Actions)
+	}
+}
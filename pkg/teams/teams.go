@@ -0,0 +1,153 @@
+// Package teams 提供通过 Microsoft Teams Workflows 或 incoming webhook 发送 Adaptive Card 告警通知的功能。
+package teams
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxLogLines 卡片中展示的触发日志最大行数，避免超过 Teams 28 KB 的消息大小限制。
+const maxLogLines = 50
+
+// client 发送 Teams 消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Card 定义了 Adaptive Card 的结构，body 和 actions 中的元素使用 map 表示。
+type Card struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	MSTeams map[string]string        `json:"msteams"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []map[string]interface{} `json:"actions,omitempty"`
+}
+
+// NewCard 创建一个全宽的 Adaptive Card。
+func NewCard() *Card {
+	return &Card{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		MSTeams: map[string]string{"width": "Full"},
+	}
+}
+
+// AddText 在卡片中添加一个文本块，Teams 中的换行需要使用空行表示。
+func (c *Card) AddText(text string, options map[string]interface{}) {
+	block := map[string]interface{}{
+		"type": "TextBlock",
+		"text": strings.ReplaceAll(text, "\n", "\n\n"),
+		"wrap": true,
+	}
+	for k, v := range options {
+		block[k] = v
+	}
+	c.Body = append(c.Body, block)
+}
+
+// AddFacts 在卡片中添加一组键值对，facts 为空时不添加。
+func (c *Card) AddFacts(title string, facts []map[string]string) {
+	if len(facts) == 0 {
+		return
+	}
+	c.AddText(title, map[string]interface{}{"weight": "Bolder", "spacing": "Medium"})
+	c.Body = append(c.Body, map[string]interface{}{
+		"type":    "FactSet",
+		"facts":   facts,
+		"spacing": "Small",
+	})
+}
+
+// AddOpenURL 在卡片底部添加跳转按钮。
+func (c *Card) AddOpenURL(title, url string) {
+	c.Actions = append(c.Actions, map[string]interface{}{
+		"type":  "Action.OpenUrl",
+		"title": title,
+		"url":   url,
+	})
+}
+
+// message 将卡片包装为 Teams webhook 接受的消息格式。
+func (c *Card) message() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"contentUrl":  nil,
+			"content":     c,
+		}},
+	}
+}
+
+// Send 发送卡片到指定的 Teams webhook。
+// Workflows 成功时返回 202，incoming webhook 成功时返回 200 和 1；
+// incoming webhook 在下游失败时也可能返回 200，此时响应中包含错误信息。
+// 限流（429）和服务端错误可以重试，其他错误不再重试。
+func (c *Card) Send(name, webhookURL string) error {
+	body, _ := json.Marshal(c.message())
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	text := strings.TrimSpace(string(respBody))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if !strings.Contains(strings.ToLower(text), "error") {
+			return nil
+		}
+		err := fmt.Errorf("teams %s returned %s: %s", name, resp.Status, text)
+		if strings.Contains(text, "429") {
+			return err
+		}
+		return notify.Permanent(err)
+	}
+
+	err = fmt.Errorf("teams %s returned %s: %s", name, resp.Status, text)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// SeverityStyle 根据告警状态和级别返回卡片标题区域的容器样式和文本颜色。
+func SeverityStyle(status, severity string) (style, color string) {
+	if status == "resolved" {
+		return "good", "Good"
+	}
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "attention", "Attention"
+	case "warning":
+		return "warning", "Warning"
+	case "info":
+		return "accent", "Accent"
+	default:
+		return "emphasis", "Default"
+	}
+}
+
+// SendText 发送通用通知消息到指定名称的 Teams 目标，供升级、报表等后台任务使用。
+// 通用消息中的 @ 对象为飞书 open_id，Teams 不支持，会被忽略。
+func SendText(name string, msg notify.Message) error {
+	webhookURL, ok := common.TeamsWebhook[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("teams target '%s' not found in configuration", name))
+	}
+
+	card := NewCard()
+	if msg.Title != "" {
+		card.AddText(msg.Title, map[string]interface{}{"size": "Large", "weight": "Bolder"})
+	}
+	card.AddText(msg.Text, nil)
+	return card.Send(name, webhookURL)
+}
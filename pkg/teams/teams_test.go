@@ -0,0 +1,85 @@
+package teams
+
+import (
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSeverityStyle(t *testing.T) {
+	tests := []struct {
+		status, severity string
+		style, color     string
+	}{
+		{"firing", "critical", "attention", "Attention"},
+		{"firing", "Critical", "attention", "Attention"},
+		{"firing", "warning", "warning", "Warning"},
+		{"firing", "info", "accent", "Accent"},
+		{"firing", "", "emphasis", "Default"},
+		{"firing", "page", "emphasis", "Default"},
+		{"resolved", "critical", "good", "Good"},
+	}
+	for _, tt := range tests {
+		style, color := SeverityStyle(tt.status, tt.severity)
+		if style != tt.style || color != tt.color {
+			t.Errorf("SeverityStyle(%q, %q) = %q, %q; want %q, %q", tt.status, tt.severity, style, color, tt.style, tt.color)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "2")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		ok       bool
+		requests int // 按重试策略发送的请求次数
+	}{
+		{"workflow accepted", http.StatusAccepted, "", true, 1},
+		{"incoming webhook", http.StatusOK, "1", true, 1},
+		{"downstream rate limited", http.StatusOK, "Webhook message delivery failed with error: Microsoft Teams endpoint returned HTTP error 429", false, 2},
+		{"downstream error", http.StatusOK, "Webhook message delivery failed with error: Microsoft Teams endpoint returned HTTP error 400", false, 1},
+		{"rate limited", http.StatusTooManyRequests, "", false, 2},
+		{"server error", http.StatusBadGateway, "", false, 2},
+		{"bad request", http.StatusBadRequest, "Bad payload received by generic incoming webhook.", false, 1},
+		{"workflow not found", http.StatusNotFound, "", false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var msg struct {
+					Type        string `json:"type"`
+					Attachments []struct {
+						ContentType string `json:"contentType"`
+						Content     Card   `json:"content"`
+					} `json:"attachments"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&msg); err != nil || msg.Type != "message" || len(msg.Attachments) != 1 ||
+					msg.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" || msg.Attachments[0].Content.Type != "AdaptiveCard" {
+					t.Errorf("unexpected message %+v: %v", msg, err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			card := NewCard()
+			card.AddText("hello", nil)
+			err := notify.Retry(func() error { return card.Send("ops", srv.URL) })
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok=%v", err, tt.ok)
+			}
+			if requests != tt.requests {
+				t.Errorf("requests = %d, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"strings"
+)
+
+// htmlEscaper 转义 HTML 格式中的特殊字符，Telegram 只识别 &lt; &gt; &amp; &quot; 四种实体。
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownSpecial MarkdownV2 格式中需要用反斜杠转义的字符。
+const markdownSpecial = "_*[]()~`>#+-=|{}.!\\"
+
+// format 按消息格式生成转义后的文本。
+type format struct {
+	mode string // HTML 或 MarkdownV2
+}
+
+// formatter 返回指定消息格式的 format，未知格式按 HTML 处理。
+func formatter(mode string) format {
+	if mode != common.TelegramMarkdownV2 {
+		mode = common.TelegramHTML
+	}
+	return format{mode: mode}
+}
+
+// escape 转义普通文本。
+func (f format) escape(s string) string {
+	if f.mode == common.TelegramHTML {
+		return htmlEscaper.Replace(s)
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownSpecial, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeCode 转义代码块中的文本，MarkdownV2 代码块中只需要转义 ` 和 \。
+func (f format) escapeCode(s string) string {
+	if f.mode == common.TelegramHTML {
+		return htmlEscaper.Replace(s)
+	}
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(s)
+}
+
+// bold 返回加粗的文本，s 为未转义的原始文本。
+func (f format) bold(s string) string {
+	if f.mode == common.TelegramHTML {
+		return "<b>" + f.escape(s) + "</b>"
+	}
+	return "*" + f.escape(s) + "*"
+}
+
+// italic 返回斜体的文本，s 为未转义的原始文本。
+func (f format) italic(s string) string {
+	if f.mode == common.TelegramHTML {
+		return "<i>" + f.escape(s) + "</i>"
+	}
+	return "_" + f.escape(s) + "_"
+}
+
+// code 返回代码块的开始和结束标记。
+func (f format) code() (open, close string) {
+	if f.mode == common.TelegramHTML {
+		return "<pre>", "</pre>"
+	}
+	return "```\n", "\n```"
+}
+
+// expansion 返回转义后文本长度相对原始文本的最大倍数，用于拆分超长的行。
+func (f format) expansion() int {
+	if f.mode == common.TelegramHTML {
+		return len("&amp;")
+	}
+	return 2
+}
@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将告警信息通过 Telegram 机器人发送到指定的 chat。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的 Telegram 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的 Telegram 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.TelegramTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid telegram targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "telegram", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		msgs := buildMessages(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标，超长的告警拆分为多条消息，重试时从失败的消息继续发送
+		for name, target := range sendTargets {
+			_ = delivery.Send(alert, "telegram", name, sender(name, target, msgs))
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// builder 按顺序组装消息片段。
+type builder struct {
+	f     format
+	parts []part
+}
+
+// formatted 添加已格式化的文本。
+func (b *builder) formatted(s string) {
+	b.parts = append(b.parts, part{kind: formatted, text: s})
+}
+
+// plain 添加需要转义的原始文本。
+func (b *builder) plain(s string) {
+	b.parts = append(b.parts, part{kind: plain, text: s})
+}
+
+// field 添加一行加粗名称的字段。
+func (b *builder) field(name, value string) {
+	b.formatted(b.f.bold(name+":") + " ")
+	b.plain(value + "\n")
+}
+
+// buildMessages 为单个告警构建消息：标题、摘要和描述、状态和时间、标签、触发日志代码块，
+// 超过长度限制时拆分为多条消息，最后一条消息附带跳转按钮。
+func buildMessages(alert common.Alert, externalURL string, flap flapping.Status) []*Message {
+	// 获取字段值，提供默认值
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	status := alert.Status
+	if status == "" {
+		status = "unknown"
+	}
+
+	// 尝试从 Loki 查询实际日志内容
+	triggerLogs := common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	metricTrend := common.MetricTrendText(alert)
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(status), alertName)
+	if flap.Flapping {
+		title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(status), alertName)
+	}
+	icon := "🚨"
+	if status == "resolved" {
+		icon = "✅"
+	}
+
+	b := &builder{f: formatter(common.TelegramConfig.ParseMode)}
+	b.formatted(icon + " " + b.f.bold(title) + "\n\n")
+
+	// 摘要、描述、状态和时间
+	if summary := alert.Annotations["summary"]; summary != "" {
+		b.field("Summary", summary)
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		b.field("Description", desc)
+	}
+	b.field("Status", status)
+	if severity := alert.Labels["severity"]; severity != "" {
+		b.field("Severity", severity)
+	}
+	if !alert.StartsAt.IsZero() {
+		b.field("Started", alert.StartsAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		b.field("Resolved", alert.EndsAt.Local().Format("2006-01-02 15:04:05"))
+		b.field("Duration", alert.EndsAt.Sub(alert.StartsAt).Round(time.Second).String())
+	}
+	if metricTrend != "" {
+		b.field("Metric trend", metricTrend)
+	}
+
+	// 抖动中的告警附带抖动提示
+	if flap.Flapping {
+		b.plain(fmt.Sprintf("🔀 Flapping: %d transitions in %v, muted until stable for %v\n",
+			flap.Transitions, flap.Window, flap.Stable))
+	}
+
+	// 标签，alertname 已在标题中展示，不再重复
+	keys := make([]string, 0, len(alert.Labels))
+	for k := range alert.Labels {
+		if k != "alertname" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		b.formatted("\n" + b.f.bold("Labels:") + "\n")
+		for _, k := range keys {
+			b.plain(fmt.Sprintf("• %s = %s\n", k, alert.Labels[k]))
+		}
+	}
+
+	// 触发日志
+	if triggerLogs != "" {
+		b.formatted("\n" + b.f.bold("Trigger logs:") + "\n")
+		b.parts = append(b.parts, part{kind: code, text: triggerLogs})
+	}
+
+	var msgs []*Message
+	for _, chunk := range split(b.parts, b.f, textLimit) {
+		msgs = append(msgs, &Message{Text: chunk, ParseMode: b.f.mode})
+	}
+
+	// 跳转按钮
+	var buttons []Button
+	if alert.GeneratorURL != "" {
+		buttons = append(buttons, Button{Text: "Open in Prometheus", URL: alert.GeneratorURL})
+	}
+	if externalURL != "" {
+		buttons = append(buttons, Button{Text: "Open Alertmanager", URL: externalURL})
+	}
+	if len(buttons) > 0 && len(msgs) > 0 {
+		msgs[len(msgs)-1].ReplyMarkup = &Markup{InlineKeyboard: [][]Button{buttons}}
+	}
+	return msgs
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// continuedReserve 为续传标记（如 "(continued 2/3)\n"）预留的长度。
+const continuedReserve = 32
+
+// 消息片段的类型。
+const (
+	formatted = iota // 已格式化的文本，每一行不可拆分
+	plain            // 需要转义的原始文本
+	code             // 需要放入代码块的原始文本
+)
+
+// part 消息的一个片段。
+type part struct {
+	kind int
+	text string
+}
+
+// length 返回文本的长度。Telegram 按 UTF-16 编码单元计算长度，这里包含格式标记，比实际长度偏大。
+func length(s string) int {
+	n := 0
+	for _, r := range s {
+		if r > 0xFFFF { // 基本多文种平面以外的字符（如 emoji）占两个编码单元
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// pieces 将原始文本的一行按转义后的长度拆分为多段，拼接后与原行相同。
+func pieces(line string, f format, size int) []string {
+	n := size / f.expansion()
+	var result []string
+	for utf8.RuneCountInString(line) > n {
+		cut := 0
+		for i := 0; i < n; i++ {
+			_, w := utf8.DecodeRuneInString(line[cut:])
+			cut += w
+		}
+		result = append(result, line[:cut])
+		line = line[cut:]
+	}
+	return append(result, line)
+}
+
+// split 将消息片段按行拆分为不超过 limit 的多条消息，代码块跨消息时在每条消息中分别闭合。
+// 从第二条消息开始在开头添加续传标记。
+func split(parts []part, f format, limit int) []string {
+	size := limit - continuedReserve
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if text := strings.TrimRight(current.String(), "\n"); text != "" {
+			chunks = append(chunks, text)
+		}
+		current.Reset()
+	}
+	write := func(s string) {
+		if length(current.String())+length(s) > size {
+			flush()
+		}
+		current.WriteString(s)
+	}
+
+	for _, p := range parts {
+		switch p.kind {
+		case formatted:
+			for _, line := range strings.SplitAfter(p.text, "\n") {
+				write(line)
+			}
+		case plain:
+			for _, line := range strings.SplitAfter(p.text, "\n") {
+				for _, piece := range pieces(line, f, size) {
+					write(f.escape(piece))
+				}
+			}
+		case code:
+			open, close := f.code()
+			var block strings.Builder
+			closeBlock := func() {
+				if block.Len() > 0 {
+					current.WriteString(open + strings.TrimSuffix(block.String(), "\n") + close + "\n")
+					block.Reset()
+				}
+			}
+			for _, line := range strings.SplitAfter(strings.TrimRight(p.text, "\n"), "\n") {
+				for _, piece := range pieces(line, f, size-length(open+close)) {
+					piece = f.escapeCode(piece)
+					if length(current.String())+length(open)+length(block.String())+length(piece)+length(close) > size {
+						closeBlock()
+						flush()
+					}
+					block.WriteString(piece)
+				}
+			}
+			closeBlock()
+		}
+	}
+	flush()
+
+	for i := 1; i < len(chunks); i++ {
+		chunks[i] = f.italic(fmt.Sprintf("(continued %d/%d)", i+1, len(chunks))) + "\n" + chunks[i]
+	}
+	return chunks
+}
@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Len 返回文本按 UTF-16 编码单元计算的长度，与 Telegram 的计算方式相同。
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+var formats = []format{formatter(common.TelegramHTML), formatter(common.TelegramMarkdownV2)}
+
+// unescape 还原转义后的普通文本。
+func unescape(f format, s string) string {
+	if f.mode == common.TelegramHTML {
+		return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+	}
+	return regexp.MustCompile(`\\(.)`).ReplaceAllString(s, "$1")
+}
+
+// continued 匹配续传标记所在的行。
+var continued = regexp.MustCompile(`^(<i>|_)\\?\(continued \d+/\d+\\?\)(</i>|_)\n`)
+
+func TestEscape(t *testing.T) {
+	html, md := formats[0], formats[1]
+	if got := html.escape(`a<b & c>"d"`); got != `a&lt;b &amp; c&gt;"d"` {
+		t.Errorf("html escape = %q", got)
+	}
+	if got := md.escape("v1.2_x*[y](z)!"); got != `v1\.2\_x\*\[y\]\(z\)\!` {
+		t.Errorf("markdown escape = %q", got)
+	}
+	if got := md.escapeCode("a`b\\c_d"); got != "a\\`b\\\\c_d" {
+		t.Errorf("markdown escapeCode = %q", got)
+	}
+	if got := md.bold("a.b"); got != `*a\.b*` {
+		t.Errorf("markdown bold = %q", got)
+	}
+	if got := html.italic("<x>"); got != "<i>&lt;x&gt;</i>" {
+		t.Errorf("html italic = %q", got)
+	}
+	if formatter("unknown").mode != common.TelegramHTML {
+		t.Error("unknown parse mode should fall back to HTML")
+	}
+}
+
+func TestLength(t *testing.T) {
+	for _, s := range []string{"abc", "告警", "🔥 firing", "a🚨b🔥c"} {
+		if got, want := length(s), utf16Len(s); got != want {
+			t.Errorf("length(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestSplitShortMessage(t *testing.T) {
+	for _, f := range formats {
+		chunks := split([]part{{kind: formatted, text: f.bold("title") + "\n"}, {kind: plain, text: "a < b."}}, f, textLimit)
+		if len(chunks) != 1 {
+			t.Fatalf("%s: got %d chunks, want 1", f.mode, len(chunks))
+		}
+		if continued.MatchString(chunks[0]) {
+			t.Errorf("%s: single message should not have continuation marker", f.mode)
+		}
+	}
+}
+
+// TestSplitPlainAtLimit 拆分包含大量需要转义字符的长文本：每条消息不超过长度限制，
+// 转义序列不会被拆开，去掉续传标记并还原转义后与原文相同。
+func TestSplitPlainAtLimit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 20000; i++ {
+		fmt.Fprintf(&b, "line %d: <tag> & v1.2_x*[y](z)! 🔥 告警\n", i)
+	}
+	// 一整行超过长度限制，只能在行内拆分
+	b.WriteString(strings.Repeat("&<>._", 2000))
+	text := b.String()
+
+	for _, f := range formats {
+		t.Run(f.mode, func(t *testing.T) {
+			chunks := split([]part{{kind: plain, text: text}}, f, textLimit)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want several", len(chunks))
+			}
+
+			var joined strings.Builder
+			for i, chunk := range chunks {
+				if n := utf16Len(chunk); n > textLimit {
+					t.Errorf("chunk %d has %d UTF-16 units, limit %d", i, n, textLimit)
+				}
+				if i > 0 {
+					marker := fmt.Sprintf("(continued %d/%d)", i+1, len(chunks))
+					if !strings.HasPrefix(chunk, f.italic(marker)+"\n") {
+						t.Errorf("chunk %d starts with %q, want continuation marker %s", i, chunk[:40], marker)
+					}
+					chunk = continued.ReplaceAllString(chunk, "")
+				}
+				if f.mode == common.TelegramHTML && regexp.MustCompile(`&(?:[a-z]*)$`).MatchString(chunk) {
+					t.Errorf("chunk %d ends inside an entity", i)
+				}
+				if f.mode == common.TelegramMarkdownV2 && strings.HasSuffix(chunk, `\`) && !strings.HasSuffix(chunk, `\\`) {
+					t.Errorf("chunk %d ends with a dangling escape", i)
+				}
+				joined.WriteString(unescape(f, chunk))
+			}
+			if got, want := strings.ReplaceAll(joined.String(), "\n", ""), strings.ReplaceAll(text, "\n", ""); got != want {
+				t.Error("chunks do not reassemble to the original text")
+			}
+		})
+	}
+}
+
+// TestSplitCodeBlock 拆分跨越多条消息的代码块：每条消息中的代码块分别闭合。
+func TestSplitCodeBlock(t *testing.T) {
+	logs := strings.Repeat("2026-10-19T06:00:00Z level=error msg=\"x < y && `z`\"\n", 300)
+
+	for _, f := range formats {
+		t.Run(f.mode, func(t *testing.T) {
+			parts := []part{
+				{kind: formatted, text: f.bold("Logs") + "\n"},
+				{kind: code, text: logs},
+				{kind: plain, text: "end."},
+			}
+			chunks := split(parts, f, textLimit)
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want several", len(chunks))
+			}
+			open, close := f.code()
+			lines := 0
+			for i, chunk := range chunks {
+				if n := utf16Len(chunk); n > textLimit {
+					t.Errorf("chunk %d has %d UTF-16 units, limit %d", i, n, textLimit)
+				}
+				if o, c := strings.Count(chunk, strings.TrimSpace(open)), strings.Count(chunk, strings.TrimSpace(close)); f.mode == common.TelegramHTML && o != c {
+					t.Errorf("chunk %d has %d <pre> and %d </pre>", i, o, c)
+				}
+				if f.mode == common.TelegramMarkdownV2 && (strings.Count(chunk, "```")-strings.Count(chunk, "\\`\\`\\`"))%2 != 0 {
+					t.Errorf("chunk %d has an unclosed code block", i)
+				}
+				lines += strings.Count(chunk, "level=error")
+			}
+			if lines != 300 {
+				t.Errorf("got %d log lines across chunks, want 300", lines)
+			}
+			if !strings.HasSuffix(chunks[len(chunks)-1], f.escape("end.")) {
+				t.Error("text after the code block should be in the last chunk")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package telegram 提供通过 Telegram Bot API 发送告警通知的功能。
+// 消息使用 HTML 或 MarkdownV2 格式，超过长度限制时拆分为多条发送。
+package telegram
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// textLimit 单条消息的长度限制（字符，按 UTF-16 编码单元计算）。
+const textLimit = 4096
+
+// client 发送 Telegram 消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// maxRetryAfter 限流时最多等待的时长。需要等待更久时不再重试，避免长时间占用投递队列，
+// 在等待结束前重试只会再次被限流。
+var maxRetryAfter = 10 * time.Second
+
+// sleep 限流时等待 retry_after 指定的时长，测试中替换以避免等待。
+var sleep = time.Sleep
+
+// Button 内联键盘中的链接按钮。
+type Button struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Markup 消息下方的内联键盘，每个元素为一行按钮。
+type Markup struct {
+	InlineKeyboard [][]Button `json:"inline_keyboard"`
+}
+
+// Message 定义了通过 sendMessage 接口发送的消息，chat_id 在发送时按目标填充。
+type Message struct {
+	Text        string  `json:"text"`
+	ParseMode   string  `json:"parse_mode,omitempty"` // HTML 或 MarkdownV2，为空时按纯文本发送
+	ReplyMarkup *Markup `json:"reply_markup,omitempty"`
+}
+
+// APIError Bot API 返回的错误。
+type APIError struct {
+	Code        int    // error_code，与 HTTP 状态码相同
+	Description string // 错误描述，如 Bad Request: chat not found
+	RetryAfter  int    // 限流时需要等待的秒数
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%d %s (retry after %ds)", e.Code, e.Description, e.RetryAfter)
+	}
+	return fmt.Sprintf("%d %s", e.Code, e.Description)
+}
+
+// Send 发送消息到指定的 Telegram 目标，并检查响应中的 ok 字段。
+// 限流（429）时先等待 retry_after 指定的时长（超过 maxRetryAfter 时不再重试），限流和服务端错误可以重试，其他错误（如 chat 不存在、机器人被移出群组、消息格式错误）不再重试。
+// 按钮链接不被 Telegram 接受时（如内网地址），去掉按钮后重新发送。
+func (m *Message) Send(name string, target common.TelegramTarget) error {
+	err := m.send(name, target)
+	var apiErr *APIError
+	if m.ReplyMarkup != nil && errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest &&
+		(strings.Contains(apiErr.Description, "BUTTON_URL_INVALID") || strings.Contains(apiErr.Description, "wrong HTTP URL")) {
+		log.Printf("⚠️ Telegram %s rejected button URL, sending without buttons: %s", name, apiErr.Description)
+		plain := *m
+		plain.ReplyMarkup = nil
+		return plain.send(name, target)
+	}
+	return err
+}
+
+// send 调用 sendMessage 接口发送一次消息。
+func (m *Message) send(name string, target common.TelegramTarget) error {
+	if target.Token == "" {
+		return notify.Permanent(fmt.Errorf("telegram target '%s' has no bot token", name))
+	}
+
+	body, _ := json.Marshal(struct {
+		ChatID string `json:"chat_id"`
+		*Message
+	}{target.ChatID, m})
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", common.TelegramConfig.APIBase, target.Token)
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 请求地址中包含机器人 token，错误信息中不输出地址
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		err = fmt.Errorf("failed to decode response from %s (%s): %w", name, resp.Status, err)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return err
+		}
+		return notify.Permanent(err)
+	}
+	if result.OK {
+		return nil
+	}
+
+	apiErr := &APIError{Code: result.ErrorCode, Description: result.Description, RetryAfter: result.Parameters.RetryAfter}
+	if apiErr.Code == 0 {
+		apiErr.Code = resp.StatusCode
+	}
+	err = fmt.Errorf("telegram %s returned %w", name, apiErr)
+	if apiErr.Code == http.StatusTooManyRequests && apiErr.RetryAfter > 0 {
+		wait := time.Duration(apiErr.RetryAfter) * time.Second
+		if wait > maxRetryAfter {
+			log.Printf("⚠️ Telegram %s rate limited for %v (longer than %v), giving up", name, wait, maxRetryAfter)
+			return notify.Permanent(err)
+		}
+		log.Printf("⏳ Telegram %s rate limited, waiting %v before retrying", name, wait)
+		sleep(wait)
+		return err
+	}
+	if apiErr.Code >= 500 || apiErr.Code == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// sender 返回按顺序发送多条消息的函数。重试时从上次失败的消息继续发送，已发送的消息不会重复发送。
+func sender(name string, target common.TelegramTarget, msgs []*Message) func() error {
+	sent := 0
+	return func() error {
+		for ; sent < len(msgs); sent++ {
+			if err := msgs[sent].Send(name, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SendText 发送通用通知消息到指定名称的 Telegram 目标，供升级、报表等后台任务使用。
+// 超过长度限制的消息会被拆分为多条发送；通用消息中的 @ 对象为飞书 open_id，Telegram 不支持，会被忽略。
+func SendText(name string, msg notify.Message) error {
+	target, ok := common.TelegramTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("telegram target '%s' not found in configuration", name))
+	}
+
+	f := formatter(common.TelegramConfig.ParseMode)
+	var parts []part
+	if msg.Title != "" {
+		parts = append(parts, part{text: f.bold(msg.Title) + "\n\n"})
+	}
+	parts = append(parts, part{text: f.escape(msg.Text)})
+
+	var msgs []*Message
+	for _, chunk := range split(parts, f, textLimit) {
+		msgs = append(msgs, &Message{Text: chunk, ParseMode: f.mode})
+	}
+	return sender(name, target, msgs)()
+}
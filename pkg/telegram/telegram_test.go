@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTelegram 模拟 Bot API 的 sendMessage 接口，记录收到的请求。
+type fakeTelegram struct {
+	mu       sync.Mutex
+	requests []map[string]any
+	reply    func(n int, req map[string]any) (int, string) // n 为请求序号，从 1 开始
+}
+
+func newFakeTelegram(t *testing.T, reply func(n int, req map[string]any) (int, string)) *fakeTelegram {
+	f := &fakeTelegram{reply: reply}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottoken/sendMessage" {
+			http.NotFound(w, r)
+			return
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		n := len(f.requests)
+		f.mu.Unlock()
+
+		status, body := f.reply(n, req)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	apiBase := common.TelegramConfig.APIBase
+	common.TelegramConfig.APIBase = srv.URL
+	t.Cleanup(func() { common.TelegramConfig.APIBase = apiBase })
+	return f
+}
+
+const okReply = `{"ok":true,"result":{"message_id":1}}`
+
+var testTarget = common.TelegramTarget{ChatID: "-100123", Token: "token"}
+
+func TestSendFallbackWithoutButtons(t *testing.T) {
+	for _, description := range []string{
+		"Bad Request: BUTTON_URL_INVALID",
+		"Bad Request: wrong HTTP URL specified",
+	} {
+		t.Run(description, func(t *testing.T) {
+			f := newFakeTelegram(t, func(n int, req map[string]any) (int, string) {
+				if _, ok := req["reply_markup"]; ok {
+					return http.StatusBadRequest, fmt.Sprintf(`{"ok":false,"error_code":400,"description":%q}`, description)
+				}
+				return http.StatusOK, okReply
+			})
+
+			msg := &Message{
+				Text:        "<b>alert</b>",
+				ParseMode:   common.TelegramHTML,
+				ReplyMarkup: &Markup{InlineKeyboard: [][]Button{{{Text: "Open in Prometheus", URL: "http://prometheus:9090/graph"}}}},
+			}
+			if err := msg.Send("ops", testTarget); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if len(f.requests) != 2 {
+				t.Fatalf("got %d requests, want 2", len(f.requests))
+			}
+			if _, ok := f.requests[1]["reply_markup"]; ok {
+				t.Error("fallback request should not contain reply_markup")
+			}
+			if f.requests[1]["text"] != "<b>alert</b>" || f.requests[1]["chat_id"] != "-100123" || f.requests[1]["parse_mode"] != "HTML" {
+				t.Errorf("unexpected fallback request: %v", f.requests[1])
+			}
+			if msg.ReplyMarkup == nil {
+				t.Error("Send should not modify the original message")
+			}
+		})
+	}
+}
+
+func TestSendOtherBadRequestNoFallback(t *testing.T) {
+	f := newFakeTelegram(t, func(n int, req map[string]any) (int, string) {
+		return http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`
+	})
+	msg := &Message{Text: "x", ReplyMarkup: &Markup{InlineKeyboard: [][]Button{{{Text: "a", URL: "https://example.com"}}}}}
+	if err := msg.Send("ops", testTarget); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(f.requests) != 1 {
+		t.Errorf("got %d requests, want 1", len(f.requests))
+	}
+}
+
+// useSleep 在测试期间记录限流等待的时长，不实际等待。
+func useSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	saved := sleep
+	t.Cleanup(func() { sleep = saved })
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	return &waits
+}
+
+func TestSendRetry(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		attempts int
+		waits    int // 按 retry_after 等待的次数
+	}{
+		{"chat not found", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, 1, 0},
+		{"bot kicked", http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was kicked from the group chat"}`, 1, 0},
+		{"rate limited", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`, 3, 3},
+		{"rate limited too long", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 60","parameters":{"retry_after":60}}`, 1, 0},
+		{"rate limited without retry_after", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests"}`, 3, 0},
+		{"server error", http.StatusBadGateway, `<html>bad gateway</html>`, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waits := useSleep(t)
+			f := newFakeTelegram(t, func(n int, req map[string]any) (int, string) {
+				return tt.status, tt.body
+			})
+			err := notify.Retry(func() error {
+				return (&Message{Text: "x"}).Send("ops", testTarget)
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if strings.Contains(err.Error(), testTarget.Token) {
+				t.Errorf("error leaks bot token: %v", err)
+			}
+			if len(f.requests) != tt.attempts {
+				t.Errorf("attempts = %d, want %d", len(f.requests), tt.attempts)
+			}
+			if len(*waits) != tt.waits {
+				t.Errorf("waited %v, want %d waits", *waits, tt.waits)
+			}
+			for _, d := range *waits {
+				if d != time.Second {
+					t.Errorf("waited %v, want retry_after of 1s", d)
+				}
+			}
+		})
+	}
+}
+
+// TestSenderResumes 重试时从失败的消息继续发送，已发送的消息不会重复发送。
+func TestSenderResumes(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	f := newFakeTelegram(t, func(n int, req map[string]any) (int, string) {
+		if n == 2 {
+			return http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1"}`
+		}
+		return http.StatusOK, okReply
+	})
+	msgs := []*Message{{Text: "1/3"}, {Text: "2/3"}, {Text: "3/3"}}
+	if err := notify.Retry(sender("ops", testTarget, msgs)); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	var texts []string
+	for _, req := range f.requests {
+		texts = append(texts, req["text"].(string))
+	}
+	if got := strings.Join(texts, ","); got != "1/3,2/3,2/3,3/3" {
+		t.Errorf("sent %s, want 1/3,2/3,2/3,3/3", got)
+	}
+}
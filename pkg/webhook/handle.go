@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Handler 处理来自 Alertmanager 的通用 webhook 请求。
+// 每个告警单独渲染请求体并发送到指定的 webhook 目标。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的 webhook 目标。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的 webhook 目标
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.WebhookTargets)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid webhook targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "webhook", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		data := newTemplateData(payload, alert)
+		data.Flapping = flap.Flapping
+
+		// 发送到所有目标
+		for name, target := range sendTargets {
+			data := data
+			data.Target = name
+			_ = delivery.Send(alert, "webhook", name, func() error {
+				body, err := render(name, target, data)
+				if err != nil {
+					return notify.Permanent(err)
+				}
+				return send(name, target, body)
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// newTemplateData 为单个告警构建请求体模板的数据，并补充触发日志和指标趋势。
+func newTemplateData(payload common.WebhookMessage, alert common.Alert) TemplateData {
+	msg := payload
+	msg.Status = alert.Status
+	msg.Alerts = []common.Alert{alert}
+
+	alertName := alert.Labels["alertname"]
+	if alertName == "" {
+		alertName = "Unknown Alert"
+	}
+
+	data := TemplateData{
+		WebhookMessage: msg,
+		Alert:          alert,
+		Title:          fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Status), alertName),
+		Severity:       alert.Labels["severity"],
+		TriggerLogs:    common.TriggerLogs(alert, "(Loki query failed: %v)", "(No matching logs in query range)"),
+		MetricTrend:    common.MetricTrendText(alert),
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s\n", data.Title)
+	if data.Severity != "" {
+		fmt.Fprintf(&builder, "Severity: %s\n", data.Severity)
+	}
+	if summary := alert.Annotations["summary"]; summary != "" {
+		fmt.Fprintf(&builder, "Summary: %s\n", summary)
+	}
+	if desc := alert.Annotations["description"]; desc != "" {
+		fmt.Fprintf(&builder, "Description: %s\n", desc)
+	}
+	if data.TriggerLogs != "" {
+		fmt.Fprintf(&builder, "Trigger Logs:\n%s\n", data.TriggerLogs)
+	}
+	if data.MetricTrend != "" {
+		fmt.Fprintf(&builder, "Metric Trend: %s\n", data.MetricTrend)
+	}
+	data.Text = strings.TrimSuffix(builder.String(), "\n")
+	return data
+}
@@ -0,0 +1,107 @@
+// Package webhook 提供通用的 HTTP webhook 通知渠道，
+// 按目标配置的请求方法、请求头、认证和请求体模板将告警转发到内部 HTTP 服务。
+package webhook
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// TemplateData 请求体模板的数据。
+// 内嵌的 WebhookMessage 与 Alertmanager 的 webhook 消息格式一致，其中 Alerts 只包含当前告警；
+// 通过升级、报表等后台任务发送的消息没有告警信息，只有 Title、Text 和 Severity。
+type TemplateData struct {
+	common.WebhookMessage
+	Alert       common.Alert // 当前告警
+	Title       string       // 消息标题，如 [FIRING] HighCPU
+	Text        string       // 纯文本格式的消息内容
+	Severity    string       // 告警级别
+	TriggerLogs string       // 触发日志
+	MetricTrend string       // 指标趋势
+	Flapping    bool         // 告警是否处于抖动状态
+	Target      string       // 目标名称
+}
+
+// render 渲染请求体。未配置模板时：有告警信息则发送 Alertmanager 格式的 JSON，否则发送标题和正文。
+func render(name string, target common.WebhookTarget, data TemplateData) ([]byte, error) {
+	tmpl := target.BodyTemplate()
+	if tmpl == nil {
+		if len(data.Alerts) > 0 {
+			return json.Marshal(data.WebhookMessage)
+		}
+		return json.Marshal(map[string]string{
+			"title":    data.Title,
+			"text":     data.Text,
+			"severity": data.Severity,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render body template of webhook target '%s': %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// send 按目标配置发送请求。5xx 和 429 响应可以重试，其他非 2xx 响应不重试。
+func send(name string, target common.WebhookTarget, body []byte) error {
+	req, err := http.NewRequest(target.Method, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return notify.Permanent(fmt.Errorf("failed to create request for %s: %w", name, err))
+	}
+	req.Header.Set("Content-Type", target.ContentType)
+	for k, v := range target.Headers {
+		req.Header.Set(k, v)
+	}
+	if auth := target.Auth; auth != nil {
+		switch strings.ToLower(auth.Type) {
+		case "basic":
+			req.SetBasicAuth(auth.Username, auth.Password)
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		}
+	}
+
+	client := &http.Client{Timeout: target.RequestTimeout()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook %s returned %s: %s", name, resp.Status, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return notify.Permanent(err)
+}
+
+// SendText 将通用消息发送到指定的 webhook 目标，供升级、报表等后台任务使用。
+func SendText(name string, msg notify.Message) error {
+	target, ok := common.WebhookTargets[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("webhook target '%s' not found in configuration", name))
+	}
+
+	body, err := render(name, target, TemplateData{
+		Title:    msg.Title,
+		Text:     msg.Text,
+		Severity: msg.Severity,
+		Target:   name,
+	})
+	if err != nil {
+		return notify.Permanent(err)
+	}
+	return send(name, target, body)
+}
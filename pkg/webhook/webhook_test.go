@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// request webhook 测试服务器收到的请求。
+type request struct {
+	Method string
+	Header http.Header
+	Body   string
+}
+
+// newServer 启动 webhook 测试服务器，按 statuses 依次返回响应状态码，之后返回 200。
+func newServer(t *testing.T, statuses ...int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, request{Method: r.Method, Header: r.Header.Clone(), Body: string(body)})
+		if n := len(requests); n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+// useTarget 在测试期间只配置一个 webhook 目标。
+func useTarget(t *testing.T, name, value string) {
+	t.Helper()
+	target, err := common.ParseWebhookTarget(name, value)
+	if err != nil {
+		t.Fatalf("ParseWebhookTarget: %v", err)
+	}
+	targets := common.WebhookTargets
+	common.WebhookTargets = map[string]common.WebhookTarget{name: target}
+	t.Cleanup(func() { common.WebhookTargets = targets })
+}
+
+// post 将 Alertmanager 消息发送到 Handler。
+func post(t *testing.T, payload string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Handler returned %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+const testPayload = `{"status": "firing", "receiver": "ops", "externalURL": "http://alertmanager",
+	"alerts": [{"status": "firing", "fingerprint": "%s",
+		"labels": {"alertname": "DiskFull", "severity": "critical", "instance": "db-1"},
+		"annotations": {"summary": "disk \"/data\" is full"}}]}`
+
+func TestHandlerRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantMethod string
+		wantHeader map[string]string
+		wantBody   string
+	}{
+		{
+			name: "template with basic auth",
+			target: `{"url": "%s", "method": "put", "headers": {"X-Source": "alertmanager"},
+				"auth": {"type": "basic", "username": "ops", "password": "secret"},
+				"body": "{\"title\": {{ json .Title }}, \"summary\": {{ json (index .Alert.Annotations \"summary\") }}, \"host\": \"{{ upper .Alert.Labels.instance }}\", \"target\": \"{{ .Target }}\"}"}`,
+			wantMethod: http.MethodPut,
+			wantHeader: map[string]string{
+				"X-Source":      "alertmanager",
+				"Content-Type":  "application/json",
+				"Authorization": "Basic b3BzOnNlY3JldA==",
+			},
+			wantBody: `{"title": "[FIRING] DiskFull", "summary": "disk \"/data\" is full", "host": "DB-1", "target": "hook"}`,
+		},
+		{
+			name:       "bearer with text body",
+			target:     `{"url": "%s", "content_type": "text/plain", "auth": {"type": "bearer", "token": "t0ken"}, "body": "{{ .Severity }}: {{ .Title }}"}`,
+			wantMethod: http.MethodPost,
+			wantHeader: map[string]string{"Content-Type": "text/plain", "Authorization": "Bearer t0ken"},
+			wantBody:   `critical: [FIRING] DiskFull`,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t)
+			useTarget(t, "hook", fmt.Sprintf(tt.target, srv.URL))
+			post(t, fmt.Sprintf(testPayload, fmt.Sprintf("request-%d", i)))
+
+			got := requests()
+			if len(got) != 1 {
+				t.Fatalf("received %d requests, want 1", len(got))
+			}
+			if got[0].Method != tt.wantMethod {
+				t.Errorf("method = %s, want %s", got[0].Method, tt.wantMethod)
+			}
+			for k, v := range tt.wantHeader {
+				if got[0].Header.Get(k) != v {
+					t.Errorf("header %s = %q, want %q", k, got[0].Header.Get(k), v)
+				}
+			}
+			if got[0].Body != tt.wantBody {
+				t.Errorf("body = %s\nwant %s", got[0].Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestHandlerDefaultBody 未配置模板时发送 Alertmanager 格式的 JSON，只包含当前告警。
+func TestHandlerDefaultBody(t *testing.T) {
+	srv, requests := newServer(t)
+	useTarget(t, "plain", srv.URL)
+	post(t, fmt.Sprintf(testPayload, "default-body"))
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("received %d requests, want 1", len(got))
+	}
+	for _, want := range []string{`"receiver":"ops"`, `"fingerprint":"default-body"`, `"alertname":"DiskFull"`} {
+		if !strings.Contains(got[0].Body, want) {
+			t.Errorf("body missing %s: %s", want, got[0].Body)
+		}
+	}
+}
+
+// TestSendRetry 5xx 和 429 响应按重试策略重试，其他 4xx 响应不重试。
+func TestSendRetry(t *testing.T) {
+	t.Setenv("SEND_RETRY_ATTEMPTS", "3")
+	t.Setenv("SEND_RETRY_BACKOFF", "1ms")
+	notify.Init()
+
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		attempts int
+	}{
+		{"ok", nil, false, 1},
+		{"recovers after 5xx", []int{http.StatusBadGateway}, false, 2},
+		{"always 5xx", []int{500, 502, 503}, true, 3},
+		{"too many requests", []int{429, 429, 429}, true, 3},
+		{"bad request", []int{http.StatusBadRequest}, true, 1},
+		{"unauthorized", []int{http.StatusUnauthorized}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t, tt.statuses...)
+			useTarget(t, "retry", srv.URL)
+
+			err := notify.Retry(func() error { return SendText("retry", notify.Message{Title: "hello"}) })
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(requests()); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+// TestParseWebhookTargetTemplate 请求体模板在加载时解析，模板有误的目标被拒绝。
+func TestParseWebhookTargetTemplate(t *testing.T) {
+	if _, err := common.ParseWebhookTarget("bad", `{"url": "http://example", "body": "{{ .Title "}`); err == nil {
+		t.Error("expected error for invalid body template")
+	}
+	if _, err := common.ParseWebhookTarget("bad", `{"url": "http://example", "body": "{{ nosuchfunc .Title }}"}`); err == nil {
+		t.Error("expected error for unknown template function")
+	}
+	target, err := common.ParseWebhookTarget("good", `{"url": "http://example", "body": "{{ json .Title }}"}`)
+	if err != nil || target.BodyTemplate() == nil {
+		t.Errorf("ParseWebhookTarget = %v, %v; want parsed template", target.BodyTemplate(), err)
+	}
+}
@@ -0,0 +1,291 @@
+package wecom
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/delivery"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler 处理来自 Alertmanager 的 webhook 请求。
+// 解析请求体中的 JSON 数据，并将告警信息发送到指定的企业微信群机器人。
+// 如果请求中包含 target 参数，则只发送到指定的目标；
+// 如果没有指定，则默认广播到所有已配置的企业微信群机器人。
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var payload common.WebhookMessage
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// 验证告警数量
+	if len(payload.Alerts) == 0 {
+		log.Println("⚠️ No alerts in payload")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 如果请求中指定了 target 参数则只发送到指定目标，否则广播到所有配置的企业微信群机器人
+	targets := common.SelectTargets(r.URL.Query().Get("target"), common.WecomWebhook)
+
+	// 如果没有有效的目标，直接返回
+	if len(targets) == 0 {
+		log.Println("⚠️ No valid wecom targets configured")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			log.Printf("❌ Failed to write response: %v", err)
+		}
+		return
+	}
+
+	// 逐个处理告警
+	for _, alert := range payload.Alerts {
+		// 记录告警历史，更新告警状态，安排或取消告警升级
+		flap := delivery.Observe(alert)
+
+		// 按静默规则、抖动和去重筛选需要发送的目标
+		sendTargets := delivery.Targets(alert, "wecom", targets)
+		if len(sendTargets) == 0 {
+			continue
+		}
+
+		msgs := buildMessages(alert, payload.ExternalURL, flap)
+
+		// 发送到所有目标，超过长度限制的告警会拆分为多条消息按顺序发送
+		for name, webhookURL := range sendTargets {
+			_ = delivery.Send(alert, "wecom", name, sender(name, webhookURL, msgs))
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Printf("❌ Failed to write response: %v", err)
+	}
+}
+
+// alertFields 单个告警消息的内容。
+type alertFields struct {
+	Name        string
+	Status      string
+	Severity    string
+	Summary     string
+	Desc        string
+	StartsAt    string
+	EndsAt      string
+	Duration    string
+	TriggerLogs string
+	MetricTrend string
+	Title       string
+}
+
+// newAlertFields 获取告警的字段值，提供默认值，并补充触发日志和指标趋势。
+func newAlertFields(alert common.Alert, flap flapping.Status) alertFields {
+	f := alertFields{
+		Name:     alert.Labels["alertname"],
+		Status:   alert.Status,
+		Severity: alert.Labels["severity"],
+		Summary:  alert.Annotations["summary"],
+		Desc:     alert.Annotations["description"],
+	}
+	if f.Name == "" {
+		f.Name = "未知告警"
+	}
+	if f.Status == "" {
+		f.Status = "unknown"
+	}
+	if f.Summary == "" {
+		f.Summary = "无摘要信息"
+	}
+	if f.Desc == "" {
+		f.Desc = "无详细描述"
+	}
+	if !alert.StartsAt.IsZero() {
+		f.StartsAt = alert.StartsAt.Local().Format("2006-01-02 15:04:05")
+	}
+	if f.Status == "resolved" && !alert.StartsAt.IsZero() && !alert.EndsAt.IsZero() {
+		f.EndsAt = alert.EndsAt.Local().Format("2006-01-02 15:04:05")
+		f.Duration = alert.EndsAt.Sub(alert.StartsAt).Round(time.Second).String()
+	}
+
+	// 尝试从 Loki 查询实际日志内容
+	f.TriggerLogs = strings.TrimRight(common.TriggerLogs(alert, "（Loki 日志查询失败: %v）", "（查询时间范围内无匹配日志）"), "\n")
+
+	// 尝试从 Prometheus 查询指标的当前值和近期趋势
+	f.MetricTrend = common.MetricTrendText(alert)
+
+	f.Title = fmt.Sprintf("[%s] %s", strings.ToUpper(f.Status), f.Name)
+	if flap.Flapping {
+		f.Title = fmt.Sprintf("[%s][FLAPPING] %s", strings.ToUpper(f.Status), f.Name)
+	}
+	return f
+}
+
+// buildMessages 按 WECOM_MSG_TYPE 为单个告警构建需要发送的消息。
+// 超过长度限制的内容（通常是触发日志）会拆分为多条消息；
+// markdown 和模板卡片消息无法按手机号 @ 人或 @所有人，需要时追加一条文本消息。
+func buildMessages(alert common.Alert, externalURL string, flap flapping.Status) []*Message {
+	f := newAlertFields(alert, flap)
+	mention := mentionsFor(alert)
+
+	switch common.WecomMsgType {
+	case common.WecomMsgText:
+		return buildText(f, flap, mention)
+	case common.WecomMsgTemplateCard:
+		return buildTemplateCard(f, flap, mention, alert, externalURL)
+	}
+	return buildMarkdown(f, flap, mention)
+}
+
+// buildText 构建文本消息，@ 信息放在第一条消息中。
+func buildText(f alertFields, flap flapping.Status, mention mentions) []*Message {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "🚨 %s\n状态: %s\n", f.Title, f.Status)
+	if f.Severity != "" {
+		fmt.Fprintf(&builder, "级别: %s\n", f.Severity)
+	}
+	fmt.Fprintf(&builder, "摘要: %s\n详情: %s\n", f.Summary, f.Desc)
+	if f.EndsAt != "" {
+		fmt.Fprintf(&builder, "恢复时间: %s\n持续时间: %s\n", f.EndsAt, f.Duration)
+	}
+	if f.MetricTrend != "" {
+		fmt.Fprintf(&builder, "指标趋势: %s\n", f.MetricTrend)
+	}
+	if flap.Flapping {
+		fmt.Fprintf(&builder, "🔀 %s\n", flap)
+	}
+	if f.TriggerLogs != "" {
+		fmt.Fprintf(&builder, "触发日志:\n%s\n", f.TriggerLogs)
+	}
+
+	var msgs []*Message
+	for _, chunk := range split(strings.TrimSuffix(builder.String(), "\n"), textLimit) {
+		msgs = append(msgs, NewText(chunk))
+	}
+	if !mention.empty() {
+		msgs[0].Text.MentionedList = mention.UserIDs
+		msgs[0].Text.MentionedMobileList = mention.Mobiles
+		if mention.All {
+			msgs[0].Text.MentionedList = append(msgs[0].Text.MentionedList, "@all")
+		}
+	}
+	return msgs
+}
+
+// buildMarkdown 构建 markdown 消息。
+func buildMarkdown(f alertFields, flap flapping.Status, mention mentions) []*Message {
+	color := "warning"
+	if f.Status == "resolved" {
+		color = "info"
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "### %s\n", f.Title)
+	fmt.Fprintf(&builder, "> 状态: <font color=\"%s\">%s</font>\n", color, f.Status)
+	if f.Severity != "" {
+		fmt.Fprintf(&builder, "> 级别: %s\n", f.Severity)
+	}
+	fmt.Fprintf(&builder, "> 摘要: %s\n", f.Summary)
+	if f.StartsAt != "" {
+		fmt.Fprintf(&builder, "> 开始时间: %s\n", f.StartsAt)
+	}
+	if f.EndsAt != "" {
+		fmt.Fprintf(&builder, "> 恢复时间: %s\n> 持续时间: %s\n", f.EndsAt, f.Duration)
+	}
+	fmt.Fprintf(&builder, "\n**详情**: %s\n", f.Desc)
+	if f.MetricTrend != "" {
+		fmt.Fprintf(&builder, "**指标趋势**: %s\n", f.MetricTrend)
+	}
+	if flap.Flapping {
+		fmt.Fprintf(&builder, "<font color=\"comment\">🔀 %s</font>\n", flap)
+	}
+	if ids := markdownMentions(mention); ids != "" {
+		fmt.Fprintf(&builder, "**通知**: %s\n", ids)
+	}
+	if f.TriggerLogs != "" {
+		fmt.Fprintf(&builder, "**触发日志**:\n%s\n", f.TriggerLogs)
+	}
+
+	var msgs []*Message
+	for _, chunk := range split(strings.TrimSuffix(builder.String(), "\n"), markdownLimit) {
+		msgs = append(msgs, NewMarkdown(chunk))
+	}
+	if len(mention.Mobiles) > 0 || mention.All {
+		msgs = append(msgs, mentionText(f.Name, mentions{Mobiles: mention.Mobiles, All: mention.All}))
+	}
+	return msgs
+}
+
+// buildTemplateCard 构建文本通知模板卡片。卡片字段有长度限制，触发日志以 markdown 消息单独发送。
+func buildTemplateCard(f alertFields, flap flapping.Status, mention mentions, alert common.Alert, externalURL string) []*Message {
+	fields := []map[string]string{{"keyname": "状态", "value": f.Status}}
+	if f.Severity != "" {
+		fields = append(fields, map[string]string{"keyname": "级别", "value": f.Severity})
+	}
+	if f.StartsAt != "" {
+		fields = append(fields, map[string]string{"keyname": "开始时间", "value": f.StartsAt})
+	}
+	if f.EndsAt != "" {
+		fields = append(fields, map[string]string{"keyname": "持续时间", "value": f.Duration})
+	}
+	if f.MetricTrend != "" {
+		fields = append(fields, map[string]string{"keyname": "指标趋势", "value": truncate(f.MetricTrend, 26)})
+	}
+	if flap.Flapping {
+		fields = append(fields, map[string]string{"keyname": "抖动", "value": truncate(flap.String(), 26)})
+	}
+
+	var jumps []map[string]interface{}
+	if alert.GeneratorURL != "" {
+		jumps = append(jumps, map[string]interface{}{"type": 1, "title": "打开 Prometheus", "url": alert.GeneratorURL})
+	}
+	if externalURL != "" {
+		jumps = append(jumps, map[string]interface{}{"type": 1, "title": "打开 Alertmanager", "url": externalURL})
+	}
+
+	card := map[string]interface{}{
+		"card_type": "text_notice",
+		"source":    map[string]string{"desc": "Alertmanager"},
+		"main_title": map[string]string{
+			"title": truncate(f.Title, 26),
+			"desc":  truncate(f.Summary, 30),
+		},
+		"sub_title_text":          truncate(f.Desc, 112),
+		"horizontal_content_list": fields,
+		"card_action":             cardAction(alert, externalURL),
+	}
+	if len(jumps) > 0 {
+		card["jump_list"] = jumps
+	}
+
+	msgs := []*Message{NewTemplateCard(card)}
+	if f.TriggerLogs != "" {
+		for _, chunk := range split(fmt.Sprintf("**%s 触发日志**:\n%s", f.Name, f.TriggerLogs), markdownLimit) {
+			msgs = append(msgs, NewMarkdown(chunk))
+		}
+	}
+	if !mention.empty() {
+		msgs = append(msgs, mentionText(f.Name, mention))
+	}
+	return msgs
+}
+
+// cardAction 返回模板卡片的点击事件：优先跳转到告警的 generatorURL，其次跳转到 Alertmanager 地址；
+// 两者都没有时不跳转（type 为 0），避免发送空的跳转地址。
+func cardAction(alert common.Alert, externalURL string) map[string]interface{} {
+	url := alert.GeneratorURL
+	if url == "" {
+		url = externalURL
+	}
+	if url == "" {
+		return map[string]interface{}{"type": 0}
+	}
+	return map[string]interface{}{"type": 1, "url": url}
+}
@@ -0,0 +1,42 @@
+package wecom
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/flapping"
+	"encoding/json"
+	"testing"
+)
+
+func TestTemplateCardAction(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		generatorURL string
+		externalURL  string
+		want         string
+	}{
+		{"generator url", "http://prometheus/graph", "http://alertmanager", `{"type":1,"url":"http://prometheus/graph"}`},
+		{"external url", "", "http://alertmanager", `{"type":1,"url":"http://alertmanager"}`},
+		{"no url", "", "", `{"type":0}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := common.Alert{Status: "firing", GeneratorURL: tt.generatorURL}
+			f := alertFields{Name: "DiskFull", Status: "firing", Title: "[FIRING] DiskFull", Summary: "disk full", Desc: "disk full"}
+			msgs := buildTemplateCard(f, flapping.Status{}, mentions{}, alert, tt.externalURL)
+			if len(msgs) != 1 || msgs[0].TemplateCard == nil {
+				t.Fatalf("got %d messages, want one template card", len(msgs))
+			}
+
+			card := msgs[0].TemplateCard
+			action, err := json.Marshal(card["card_action"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(action) != tt.want {
+				t.Errorf("card_action = %s, want %s", action, tt.want)
+			}
+			if _, ok := card["jump_list"]; ok != (tt.generatorURL != "" || tt.externalURL != "") {
+				t.Errorf("jump_list present = %v", ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package wecom
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mentions 单个告警需要 @ 的对象。
+type mentions struct {
+	UserIDs []string // 企业微信 userid
+	Mobiles []string // 手机号
+	All     bool     // 是否 @所有人
+}
+
+// empty 判断是否没有需要 @ 的对象。
+func (m mentions) empty() bool {
+	return len(m.UserIDs) == 0 && len(m.Mobiles) == 0 && !m.All
+}
+
+// mentionsFor 根据告警标签和级别计算需要 @ 的对象，包括标签映射的用户和当前值班人。
+// 与飞书相同，只有 firing 状态且级别不低于 min_severity 的告警才会 @ 人。
+func mentionsFor(alert common.Alert) mentions {
+	var m mentions
+	if (!common.MentionConfig.Enabled && common.Oncall == nil) || alert.Status != "firing" {
+		return m
+	}
+
+	severity := alert.Labels["severity"]
+	if common.SeverityRank(severity) < common.SeverityRank(common.MentionConfig.MinSeverity) {
+		return m
+	}
+
+	for _, s := range common.MentionConfig.AtAll {
+		if strings.EqualFold(s, severity) {
+			m.All = true
+		}
+	}
+
+	userIDs := make(map[string]bool)
+	mobiles := make(map[string]bool)
+	for _, rule := range common.MentionConfig.Rules {
+		if value, ok := alert.Labels[rule.Label]; !ok || value != rule.Value {
+			continue
+		}
+		for _, id := range rule.UserIDs {
+			userIDs[id] = true
+		}
+		for _, mobile := range rule.Mobiles {
+			mobiles[mobile] = true
+		}
+	}
+
+	// 当前值班人，同时配置了 userid 和手机号时使用 userid
+	for _, member := range common.Oncall.OnCall(alert.Labels, time.Now()) {
+		switch {
+		case member.UserID != "":
+			userIDs[member.UserID] = true
+		case member.Mobile != "":
+			mobiles[member.Mobile] = true
+		}
+	}
+
+	m.UserIDs = sortedKeys(userIDs)
+	m.Mobiles = sortedKeys(mobiles)
+	return m
+}
+
+// sortedKeys 返回排序后的 key。
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// markdownMentions 生成 markdown 消息中的 @ 标记。markdown 消息只支持按 userid @ 人。
+func markdownMentions(m mentions) string {
+	parts := make([]string, 0, len(m.UserIDs))
+	for _, id := range m.UserIDs {
+		parts = append(parts, fmt.Sprintf("<@%s>", id))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mentionText 创建用于 @ 人的文本消息。markdown 和模板卡片消息不支持按手机号 @ 人和 @所有人，
+// 此时在告警消息之后单独发送一条文本消息。
+func mentionText(alertName string, m mentions) *Message {
+	msg := NewText(fmt.Sprintf("告警 %s 需要处理", alertName))
+	msg.Text.MentionedList = m.UserIDs
+	msg.Text.MentionedMobileList = m.Mobiles
+	if m.All {
+		msg.Text.MentionedList = append(msg.Text.MentionedList, "@all")
+	}
+	return msg
+}
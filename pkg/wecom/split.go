@@ -0,0 +1,67 @@
+package wecom
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// continuedReserve 为续传标记（如 "（续 2/3）\n"）预留的字节数。
+const continuedReserve = 32
+
+// split 将内容按行拆分为不超过 limit 字节的多段，从第二段开始在开头添加续传标记。
+// 超过长度限制的单行会在 UTF-8 字符边界处截断。
+func split(content string, limit int) []string {
+	if len(content) <= limit {
+		return []string{content}
+	}
+
+	size := limit - continuedReserve
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, strings.TrimSuffix(current.String(), "\n"))
+			current.Reset()
+		}
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		for len(line) > size {
+			flush()
+			cut := truncateIndex(line, size)
+			chunks = append(chunks, line[:cut])
+			line = line[cut:]
+		}
+		if current.Len()+len(line) > size {
+			flush()
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	for i := 1; i < len(chunks); i++ {
+		chunks[i] = fmt.Sprintf("（续 %d/%d）\n%s", i+1, len(chunks), chunks[i])
+	}
+	return chunks
+}
+
+// truncateIndex 返回不超过 n 字节且位于 UTF-8 字符边界的截断位置。
+func truncateIndex(s string, n int) int {
+	if len(s) <= n {
+		return len(s)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// truncate 将字符串截断为最多 n 个字符，超出部分以省略号代替。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
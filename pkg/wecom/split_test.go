@@ -0,0 +1,99 @@
+package wecom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// continued 匹配续传标记所在的行。
+var continued = regexp.MustCompile(`^（续 (\d+)/(\d+)）\n`)
+
+func TestSplitShortMessage(t *testing.T) {
+	content := strings.Repeat("告", markdownLimit/3)
+	chunks := split(content, markdownLimit)
+	if len(chunks) != 1 || chunks[0] != content {
+		t.Fatalf("content within limit should not be split, got %d chunks", len(chunks))
+	}
+}
+
+// TestSplitAtLimit 拆分包含多字节字符的长文本：每条消息不超过长度限制，续传标记不超过预留的长度，
+// 超长的行在字符边界处截断，去掉续传标记后与原文相同。
+func TestSplitAtLimit(t *testing.T) {
+	var b strings.Builder
+	for i := 0; b.Len() < 50000; i++ {
+		fmt.Fprintf(&b, "line %d: 🔥 磁盘使用率超过 90%%\n", i)
+	}
+	// 一整行超过长度限制，只能在行内拆分；前缀使截断位置落在多字节字符中间
+	b.WriteString("x" + strings.Repeat("告警🚨", 2000))
+	content := b.String()
+
+	for _, limit := range []int{textLimit, markdownLimit} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			chunks := split(content, limit)
+			if len(chunks) < 10 {
+				t.Fatalf("got %d chunks, want at least 10 so the marker has two-digit numbers", len(chunks))
+			}
+
+			var joined strings.Builder
+			for i, chunk := range chunks {
+				if len(chunk) > limit {
+					t.Errorf("chunk %d has %d bytes, limit %d", i, len(chunk), limit)
+				}
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %d is cut inside a multi-byte character", i)
+				}
+				if i > 0 {
+					marker := continued.FindString(chunk)
+					if want := fmt.Sprintf("（续 %d/%d）\n", i+1, len(chunks)); marker != want {
+						t.Errorf("chunk %d starts with marker %q, want %q", i, marker, want)
+					}
+					if len(marker) > continuedReserve {
+						t.Errorf("marker %q has %d bytes, reserve %d", marker, len(marker), continuedReserve)
+					}
+					chunk = chunk[len(marker):]
+				} else if continued.MatchString(chunk) {
+					t.Error("first chunk should not have continuation marker")
+				}
+				joined.WriteString(chunk)
+			}
+			if got, want := strings.ReplaceAll(joined.String(), "\n", ""), strings.ReplaceAll(content, "\n", ""); got != want {
+				t.Error("chunks do not reassemble to the original content")
+			}
+		})
+	}
+}
+
+// TestSplitKeepsLines 未超长的行不会被拆到两条消息中。
+func TestSplitKeepsLines(t *testing.T) {
+	line := strings.Repeat("日志", 100) + "\n"
+	content := strings.Repeat(line, 50)
+	for i, chunk := range split(content, textLimit) {
+		chunk = continued.ReplaceAllString(chunk, "")
+		for _, l := range strings.Split(chunk, "\n") {
+			if l+"\n" != line {
+				t.Errorf("chunk %d has a partial line of %d bytes", i, len(l))
+			}
+		}
+	}
+}
+
+func TestTruncateIndex(t *testing.T) {
+	s := "a告警"
+	for n, want := range map[int]int{0: 0, 1: 1, 2: 1, 3: 1, 4: 4, 6: 4, 7: 7, 10: 7} {
+		if got := truncateIndex(s, n); got != want {
+			t.Errorf("truncateIndex(%q, %d) = %d, want %d", s, n, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("磁盘使用率", 5); got != "磁盘使用率" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate("磁盘使用率过高", 5); got != "磁盘使用…" {
+		t.Errorf("truncate = %q", got)
+	}
+}
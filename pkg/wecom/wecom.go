@@ -0,0 +1,121 @@
+// Package wecom 提供通过企业微信群机器人发送告警通知的功能。
+package wecom
+
+import (
+	"alertmanagerWebhookAdapter/pkg/common"
+	"alertmanagerWebhookAdapter/pkg/notify"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 企业微信群机器人的消息长度限制（字节，UTF-8 编码）。
+const (
+	textLimit     = 2048
+	markdownLimit = 4096
+)
+
+// 可以重试的错误码：系统繁忙和接口调用超过频率限制（每个机器人每分钟最多发送 20 条消息）。
+const (
+	errcodeBusy      = -1
+	errcodeFreqLimit = 45009
+)
+
+// client 发送企业微信消息使用的 HTTP 客户端。
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Text 文本消息内容。
+type Text struct {
+	Content             string   `json:"content"`
+	MentionedList       []string `json:"mentioned_list,omitempty"`        // 需要 @ 的 userid，@all 表示所有人
+	MentionedMobileList []string `json:"mentioned_mobile_list,omitempty"` // 需要 @ 的手机号，@all 表示所有人
+}
+
+// Markdown markdown 消息内容，只能通过 <@userid> 的方式 @ 人。
+type Markdown struct {
+	Content string `json:"content"`
+}
+
+// Message 定义了发送到企业微信群机器人的消息结构。
+type Message struct {
+	MsgType      string                 `json:"msgtype"`
+	Text         *Text                  `json:"text,omitempty"`
+	Markdown     *Markdown              `json:"markdown,omitempty"`
+	TemplateCard map[string]interface{} `json:"template_card,omitempty"`
+}
+
+// NewText 创建一个文本消息。
+func NewText(content string) *Message {
+	return &Message{MsgType: common.WecomMsgText, Text: &Text{Content: content}}
+}
+
+// NewMarkdown 创建一个 markdown 消息。
+func NewMarkdown(content string) *Message {
+	return &Message{MsgType: common.WecomMsgMarkdown, Markdown: &Markdown{Content: content}}
+}
+
+// NewTemplateCard 创建一个模板卡片消息，card 为 template_card 字段的内容。
+func NewTemplateCard(card map[string]interface{}) *Message {
+	return &Message{MsgType: common.WecomMsgTemplateCard, TemplateCard: card}
+}
+
+// Send 发送消息到指定的企业微信群机器人，并检查响应中的 errcode。
+// 限流和系统繁忙可以重试，其他错误（如消息格式错误、webhook 地址失效）不再重试。
+func (m *Message) Send(name, webhookURL string) error {
+	body, _ := json.Marshal(m)
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wecom %s returned %s", name, resp.Status)
+	}
+
+	var result struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", name, err)
+	}
+	if result.Errcode != 0 {
+		err := fmt.Errorf("wecom %s returned errcode %d: %s", name, result.Errcode, result.Errmsg)
+		if result.Errcode == errcodeBusy || result.Errcode == errcodeFreqLimit {
+			return err
+		}
+		return notify.Permanent(err)
+	}
+	return nil
+}
+
+// sender 返回按顺序发送多条消息的函数。重试时从上次失败的消息继续发送，已发送的消息不会重复发送。
+func sender(name, webhookURL string, msgs []*Message) func() error {
+	sent := 0
+	return func() error {
+		for ; sent < len(msgs); sent++ {
+			if err := msgs[sent].Send(name, webhookURL); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SendText 发送通用通知消息到指定名称的企业微信目标，供升级、报表等后台任务使用。
+// 超过长度限制的消息会被拆分为多条发送；通用消息中的 @ 对象为飞书 open_id，企业微信不支持，会被忽略。
+func SendText(name string, msg notify.Message) error {
+	webhookURL, ok := common.WecomWebhook[name]
+	if !ok {
+		return notify.Permanent(fmt.Errorf("wecom target '%s' not found in configuration", name))
+	}
+
+	var msgs []*Message
+	for _, chunk := range split(msg.Text, textLimit) {
+		msgs = append(msgs, NewText(chunk))
+	}
+	return sender(name, webhookURL, msgs)()
+}